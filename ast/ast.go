@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -17,9 +18,16 @@ type Node interface {
 type File struct {
 	*Attr
 	Program *Program
+	// LeadingComments are the comments ahead of the first statement of the
+	// program, e.g. a license header
+	LeadingComments []*Comment
 }
 
-func (f *File) UnmarshalJSON(data []byte) error {
+// UnmarshalJSON unmarshals the AST JSON of a file. Malformed JSON, such as
+// a node missing a key or of an unsupported type, is an error.
+func (f *File) UnmarshalJSON(data []byte) (err error) {
+	defer recoverUnmarshal(&err)
+
 	m := make(map[string]interface{})
 	if err := json.Unmarshal(data, &m); err != nil {
 		return err
@@ -27,10 +35,25 @@ func (f *File) UnmarshalJSON(data []byte) error {
 
 	f.Attr = unmarshalAttr(m)
 	f.Program = unmarshalProgram(convertMap(m["program"]))
+	if comments, ok := m["comments"]; ok && comments != nil {
+		f.LeadingComments = leadingComments(unmarshalComments(convertSliceMap(comments)), f.Program)
+	}
 
 	return nil
 }
 
+// UnmarshalStatement returns the statement whose AST JSON is data.
+func UnmarshalStatement(data []byte) (s Statement, err error) {
+	defer recoverUnmarshal(&err)
+
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return unmarshalStatement(m), nil
+}
+
 func (f *File) GetAttr() *Attr {
 	return f.Attr
 }
@@ -46,7 +69,10 @@ func (f *File) String() string {
 type Program struct {
 	*Attr
 	SourceType string
-	Body       []Statement
+	// Interpreter is the interpreter of the shebang line the program starts
+	// with, e.g. "/usr/bin/env node", or "" when it has none
+	Interpreter string
+	Body        []Statement
 }
 
 func (p *Program) GetAttr() *Attr {
@@ -56,8 +82,12 @@ func (p *Program) GetAttr() *Attr {
 func (p *Program) String() string {
 	var out bytes.Buffer
 
+	if p.Interpreter != "" {
+		out.WriteString("#!" + p.Interpreter + "\n")
+	}
 	for _, s := range p.Body {
 		out.WriteString(s.String())
+		out.WriteString("\n")
 	}
 
 	return out.String()
@@ -68,6 +98,29 @@ type Attr struct {
 	Start int
 	End   int
 	Loc   *SourceLocation
+	// LeadingComments are the comments the parser attached ahead of the
+	// node, e.g. the JSDoc block of a function declaration
+	LeadingComments []*Comment
+}
+
+// Comment is a block comment, e.g. `/* a */`, or a line comment, e.g.
+// `// a`, of the source
+type Comment struct {
+	*Attr
+	Value string
+}
+
+func (c *Comment) GetAttr() *Attr {
+	return c.Attr
+}
+
+// String returns the comment as written in the source.
+func (c *Comment) String() string {
+	if c.Type == "CommentBlock" {
+		return "/*" + c.Value + "*/"
+	}
+
+	return "//" + c.Value
 }
 
 type SourceLocation struct {
@@ -103,212 +156,1423 @@ func (e *ExpressionStatement) GetAttr() *Attr {
 	return e.Attr
 }
 
+// String parenthesizes expressions which would otherwise read as a
+// declaration, a block or a directive.
 func (e *ExpressionStatement) String() string {
-	return e.Expression.String()
+	s := e.Expression.String()
+	if _, ok := e.Expression.(*StringLiteral); ok || strings.HasPrefix(s, "{") || strings.HasPrefix(s, "function") {
+		s = "(" + s + ")"
+	}
+
+	return s + ";"
 }
 
-// declarations
+type BlockStatement struct {
+	*Attr
+	Body []Statement
+}
 
-type Declaration interface {
-	Statement
-	declarationNode()
+func (b *BlockStatement) statementNode() {}
+
+func (b *BlockStatement) GetAttr() *Attr {
+	return b.Attr
 }
 
-type VariableDeclaration struct {
+func (b *BlockStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("{\n")
+	for _, s := range b.Body {
+		out.WriteString(s.String())
+		out.WriteString("\n")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+type ReturnStatement struct {
 	*Attr
-	Declarations []*VariableDeclarator
-	Kind         string
+	Argument Expression
 }
 
-func (v *VariableDeclaration) statementNode() {}
+func (r *ReturnStatement) statementNode() {}
 
-func (v *VariableDeclaration) declarationNode() {}
+func (r *ReturnStatement) GetAttr() *Attr {
+	return r.Attr
+}
 
-func (v *VariableDeclaration) GetAttr() *Attr {
-	return v.Attr
+func (r *ReturnStatement) String() string {
+	if r.Argument == nil {
+		return "return;"
+	}
+
+	return "return " + r.Argument.String() + ";"
 }
 
-func (v *VariableDeclaration) String() string {
+type ForStatement struct {
+	*Attr
+	Init   Node
+	Test   Expression
+	Update Expression
+	Body   Statement
+}
+
+func (f *ForStatement) statementNode() {}
+
+func (f *ForStatement) GetAttr() *Attr {
+	return f.Attr
+}
+
+func (f *ForStatement) String() string {
 	var out bytes.Buffer
 
-	out.WriteString(v.Kind)
-	out.WriteString(" ")
-	for _, d := range v.Declarations {
-		out.WriteString(d.String())
+	out.WriteString("for (")
+	if f.Init != nil {
+		out.WriteString(strings.TrimSuffix(f.Init.String(), ";"))
 	}
+	out.WriteString("; ")
+	if f.Test != nil {
+		out.WriteString(f.Test.String())
+	}
+	out.WriteString("; ")
+	if f.Update != nil {
+		out.WriteString(f.Update.String())
+	}
+	out.WriteString(") ")
+	out.WriteString(f.Body.String())
 
 	return out.String()
 }
 
-type VariableDeclarator struct {
+// ForOfStatement is a for...of loop over the values of Right, which are
+// assigned to Left, either a declaration of the loop variable or an
+// assignment target.
+type ForOfStatement struct {
 	*Attr
-	ID   *Identifier
-	Init Expression
+	// Await tells whether the loop is a for await...of loop, iterating
+	// asynchronously
+	Await bool
+	Left  Node
+	Right Expression
+	Body  Statement
 }
 
-func (v *VariableDeclarator) GetAttr() *Attr {
-	return v.Attr
+func (f *ForOfStatement) statementNode() {}
+
+func (f *ForOfStatement) GetAttr() *Attr {
+	return f.Attr
 }
 
-func (v *VariableDeclarator) String() string {
+func (f *ForOfStatement) String() string {
+	left := strings.TrimSuffix(f.Left.String(), ";")
+	if f.Await {
+		return fmt.Sprintf("for await (%s of %s) %s", left, f.Right, f.Body)
+	}
+
+	return fmt.Sprintf("for (%s of %s) %s", left, f.Right, f.Body)
+}
+
+// DoWhileStatement is a do...while loop, whose Test is evaluated after each
+// run of its Body.
+type DoWhileStatement struct {
+	*Attr
+	Body Statement
+	Test Expression
+}
+
+func (d *DoWhileStatement) statementNode() {}
+
+func (d *DoWhileStatement) GetAttr() *Attr {
+	return d.Attr
+}
+
+func (d *DoWhileStatement) String() string {
+	return fmt.Sprintf("do %s while (%s);", d.Body, d.Test)
+}
+
+// SwitchStatement is a switch statement, whose cases share a single block
+// scope.
+type SwitchStatement struct {
+	*Attr
+	Discriminant Expression
+	Cases        []*SwitchCase
+}
+
+func (s *SwitchStatement) statementNode() {}
+
+func (s *SwitchStatement) GetAttr() *Attr {
+	return s.Attr
+}
+
+func (s *SwitchStatement) String() string {
 	var out bytes.Buffer
 
-	out.WriteString(v.ID.String())
-	if v.Init != nil {
-		out.WriteString(" = ")
-		out.WriteString(v.Init.String())
+	out.WriteString("switch (" + s.Discriminant.String() + ") {\n")
+	for _, c := range s.Cases {
+		out.WriteString(c.String())
 	}
+	out.WriteString("}")
 
 	return out.String()
 }
 
-// expressions
+// SwitchCase is a case of a switch statement, whose Test is nil for the
+// default case.
+type SwitchCase struct {
+	*Attr
+	Test       Expression
+	Consequent []Statement
+}
 
-type Expression interface {
-	Node
-	expressionNode()
+func (c *SwitchCase) GetAttr() *Attr {
+	return c.Attr
 }
 
-type Identifier struct {
+func (c *SwitchCase) String() string {
+	var out bytes.Buffer
+
+	if c.Test == nil {
+		out.WriteString("default:\n")
+	} else {
+		out.WriteString("case " + c.Test.String() + ":\n")
+	}
+	for _, s := range c.Consequent {
+		out.WriteString(s.String())
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+type WithStatement struct {
 	*Attr
-	Name string
+	Object Expression
+	Body   Statement
 }
 
-func (i *Identifier) expressionNode() {}
+func (w *WithStatement) statementNode() {}
 
-func (i *Identifier) GetAttr() *Attr {
-	return i.Attr
+func (w *WithStatement) GetAttr() *Attr {
+	return w.Attr
 }
 
-func (i *Identifier) String() string {
-	return i.Name
+func (w *WithStatement) String() string {
+	return fmt.Sprintf("with (%s) %s", w.Object, w.Body)
 }
 
-type CallExpression struct {
+type LabeledStatement struct {
 	*Attr
-	Callee    Expression
-	Arguments []Expression
+	Label *Identifier
+	Body  Statement
 }
 
-func (c *CallExpression) expressionNode() {}
+func (l *LabeledStatement) statementNode() {}
 
-func (c *CallExpression) GetAttr() *Attr {
-	return c.Attr
+func (l *LabeledStatement) GetAttr() *Attr {
+	return l.Attr
 }
 
-func (c *CallExpression) String() string {
-	var out bytes.Buffer
+func (l *LabeledStatement) String() string {
+	return l.Label.Name + ": " + l.Body.String()
+}
 
-	out.WriteString(c.Callee.String())
-	out.WriteString("(")
+// BreakStatement is a break out of the innermost loop, or out of the
+// statement of its Label when it has one.
+type BreakStatement struct {
+	*Attr
+	Label *Identifier
+}
 
-	var args []string
-	for _, arg := range c.Arguments {
-		args = append(args, arg.String())
-	}
-	out.WriteString(strings.Join(args, ", "))
+func (b *BreakStatement) statementNode() {}
 
-	out.WriteString(")")
+func (b *BreakStatement) GetAttr() *Attr {
+	return b.Attr
+}
 
-	return out.String()
+func (b *BreakStatement) String() string {
+	if b.Label == nil {
+		return "break;"
+	}
+
+	return "break " + b.Label.Name + ";"
 }
 
-type MemberExpression struct {
+// ContinueStatement continues the innermost loop, or the loop of its Label
+// when it has one.
+type ContinueStatement struct {
 	*Attr
-	Object   Expression
-	Property Expression
-	Computed bool
+	Label *Identifier
 }
 
-func (e *MemberExpression) expressionNode() {}
+func (c *ContinueStatement) statementNode() {}
 
-func (m *MemberExpression) GetAttr() *Attr {
-	return m.Attr
+func (c *ContinueStatement) GetAttr() *Attr {
+	return c.Attr
 }
 
-func (e *MemberExpression) String() string {
-	return fmt.Sprintf("%s.%s", e.Object, e.Property)
+func (c *ContinueStatement) String() string {
+	if c.Label == nil {
+		return "continue;"
+	}
+
+	return "continue " + c.Label.Name + ";"
 }
 
-type AssignmentExpression struct {
+type DebuggerStatement struct {
 	*Attr
-	Operator AssignmentOperator
-	Left     Expression
-	Right    Expression
 }
 
-func (a *AssignmentExpression) expressionNode() {}
+func (d *DebuggerStatement) statementNode() {}
 
-func (a *AssignmentExpression) GetAttr() *Attr {
-	return a.Attr
+func (d *DebuggerStatement) GetAttr() *Attr {
+	return d.Attr
 }
 
-func (a *AssignmentExpression) String() string {
-	return fmt.Sprintf("%s %s %s", a.Left, a.Operator, a.Right)
+func (d *DebuggerStatement) String() string {
+	return "debugger;"
 }
 
-type AssignmentOperator string
+// declarations
 
-type BinaryExpression struct {
+type Declaration interface {
+	Statement
+	declarationNode()
+}
+
+type VariableDeclaration struct {
 	*Attr
-	Operator BinaryOperator
-	Left     Expression
-	Right    Expression
+	Declarations []*VariableDeclarator
+	Kind         string
 }
 
-func (b *BinaryExpression) expressionNode() {}
+func (v *VariableDeclaration) statementNode() {}
 
-func (b *BinaryExpression) GetAttr() *Attr {
-	return b.Attr
+func (v *VariableDeclaration) declarationNode() {}
+
+func (v *VariableDeclaration) GetAttr() *Attr {
+	return v.Attr
 }
 
-func (a *BinaryExpression) String() string {
-	return fmt.Sprintf("%s %s %s", a.Left, a.Operator, a.Right)
+func (v *VariableDeclaration) String() string {
+	var out bytes.Buffer
+
+	var decls []string
+	for _, d := range v.Declarations {
+		decls = append(decls, d.String())
+	}
+
+	out.WriteString(v.Kind)
+	out.WriteString(" ")
+	out.WriteString(strings.Join(decls, ", "))
+	out.WriteString(";")
+
+	return out.String()
 }
 
-type BinaryOperator string
+type FunctionDeclaration struct {
+	*Attr
+	ID *Identifier
+	// Params are the bindings of the parameters: identifiers or
+	// destructuring patterns, e.g. `{a, b}` in `function f({a, b}) {}`
+	Params []Expression
+	Body   *BlockStatement
+}
 
-// literals
+func (f *FunctionDeclaration) statementNode() {}
 
-type Literal interface {
-	Expression
-	literalNode()
+func (f *FunctionDeclaration) declarationNode() {}
+
+func (f *FunctionDeclaration) GetAttr() *Attr {
+	return f.Attr
 }
 
-type StringLiteral struct {
+func (f *FunctionDeclaration) String() string {
+	var out bytes.Buffer
+
+	var params []string
+	for _, p := range f.Params {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("function ")
+	if f.ID != nil {
+		out.WriteString(f.ID.String())
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(f.Body.String())
+
+	return out.String()
+}
+
+type ClassDeclaration struct {
 	*Attr
-	Extra *Extra
-	Value string
+	ID         *Identifier
+	SuperClass Expression
+	Body       *ClassBody
 }
 
-func (s *StringLiteral) expressionNode() {}
+func (c *ClassDeclaration) statementNode() {}
 
-func (s *StringLiteral) literalNode() {}
+func (c *ClassDeclaration) declarationNode() {}
 
-func (s *StringLiteral) GetAttr() *Attr {
-	return s.Attr
+func (c *ClassDeclaration) GetAttr() *Attr {
+	return c.Attr
 }
 
-func (s *StringLiteral) String() string {
-	return fmt.Sprintf(`"%s"`, s.Value)
+func (c *ClassDeclaration) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("class ")
+	if c.ID != nil {
+		out.WriteString(c.ID.String())
+		out.WriteString(" ")
+	}
+	if c.SuperClass != nil {
+		out.WriteString("extends ")
+		out.WriteString(calleeOperand(c.SuperClass))
+		out.WriteString(" ")
+	}
+	out.WriteString(c.Body.String())
+
+	return out.String()
 }
 
-// TODO: Value is always float64
-// Can delay conversion and adapt to int vs. float
-type NumericLiteral struct {
+type ClassBody struct {
 	*Attr
-	Extra *Extra
-	Value float64
+	Body []Node
 }
 
-func (n *NumericLiteral) expressionNode() {}
+func (c *ClassBody) GetAttr() *Attr {
+	return c.Attr
+}
 
-func (n *NumericLiteral) literalNode() {}
+func (c *ClassBody) String() string {
+	var out bytes.Buffer
 
-func (n *NumericLiteral) GetAttr() *Attr {
-	return n.Attr
+	out.WriteString("{\n")
+	for _, m := range c.Body {
+		out.WriteString(m.String())
+		out.WriteString("\n")
+	}
+	out.WriteString("}")
+
+	return out.String()
 }
 
-func (n *NumericLiteral) String() string {
-	return fmt.Sprintf("%.16f", n.Value)
+// ClassProperty is a class field, e.g. `count = 0`, whose Value is nil when
+// it has no initializer.
+type ClassProperty struct {
+	*Attr
+	Key      Expression
+	Value    Expression
+	Computed bool
+	Static   bool
+}
+
+func (c *ClassProperty) GetAttr() *Attr {
+	return c.Attr
+}
+
+func (c *ClassProperty) String() string {
+	var out bytes.Buffer
+
+	if c.Static {
+		out.WriteString("static ")
+	}
+	if c.Computed {
+		out.WriteString("[" + c.Key.String() + "]")
+	} else {
+		out.WriteString(c.Key.String())
+	}
+	if c.Value != nil {
+		out.WriteString(" = ")
+		out.WriteString(operand(c.Value, assignmentPrecedence))
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+type VariableDeclarator struct {
+	*Attr
+	// ID is the binding the declarator declares: an identifier or a
+	// destructuring pattern, e.g. `{a: [b, c]}` in `const {a: [b, c]} = obj`
+	ID   Expression
+	Init Expression
+}
+
+func (v *VariableDeclarator) GetAttr() *Attr {
+	return v.Attr
+}
+
+func (v *VariableDeclarator) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(v.ID.String())
+	if v.Init != nil {
+		out.WriteString(" = ")
+		out.WriteString(operand(v.Init, assignmentPrecedence))
+	}
+
+	return out.String()
+}
+
+// modules
+
+type ExportNamedDeclaration struct {
+	*Attr
+	Declaration Statement
+	Specifiers  []*ExportSpecifier
+	// Source is the module the specifiers re-export from, if any
+	Source *StringLiteral
+}
+
+func (e *ExportNamedDeclaration) statementNode() {}
+
+func (e *ExportNamedDeclaration) GetAttr() *Attr {
+	return e.Attr
+}
+
+func (e *ExportNamedDeclaration) String() string {
+	if e.Declaration != nil {
+		return "export " + e.Declaration.String()
+	}
+
+	var specs []string
+	for _, s := range e.Specifiers {
+		specs = append(specs, s.String())
+	}
+
+	if e.Source != nil {
+		return fmt.Sprintf("export { %s } from %s;", strings.Join(specs, ", "), e.Source)
+	}
+
+	return fmt.Sprintf("export { %s };", strings.Join(specs, ", "))
+}
+
+type ExportSpecifier struct {
+	*Attr
+	Local    *Identifier
+	Exported *Identifier
+}
+
+func (e *ExportSpecifier) GetAttr() *Attr {
+	return e.Attr
+}
+
+func (e *ExportSpecifier) String() string {
+	if e.Local.Name == e.Exported.Name {
+		return e.Local.String()
+	}
+
+	return fmt.Sprintf("%s as %s", e.Local, e.Exported)
+}
+
+type ImportDeclaration struct {
+	*Attr
+	Specifiers []Node
+	Source     *StringLiteral
+}
+
+func (i *ImportDeclaration) statementNode() {}
+
+func (i *ImportDeclaration) GetAttr() *Attr {
+	return i.Attr
+}
+
+func (i *ImportDeclaration) String() string {
+	if len(i.Specifiers) == 0 {
+		return "import " + i.Source.String() + ";"
+	}
+
+	var specs, named []string
+	for _, s := range i.Specifiers {
+		if _, ok := s.(*ImportSpecifier); ok {
+			named = append(named, s.String())
+		} else {
+			specs = append(specs, s.String())
+		}
+	}
+	if len(named) > 0 {
+		specs = append(specs, fmt.Sprintf("{ %s }", strings.Join(named, ", ")))
+	}
+
+	return fmt.Sprintf("import %s from %s;", strings.Join(specs, ", "), i.Source)
+}
+
+type ImportDefaultSpecifier struct {
+	*Attr
+	Local *Identifier
+}
+
+func (i *ImportDefaultSpecifier) GetAttr() *Attr {
+	return i.Attr
+}
+
+func (i *ImportDefaultSpecifier) String() string {
+	return i.Local.String()
+}
+
+type ImportSpecifier struct {
+	*Attr
+	Local    *Identifier
+	Imported *Identifier
+}
+
+func (i *ImportSpecifier) GetAttr() *Attr {
+	return i.Attr
+}
+
+func (i *ImportSpecifier) String() string {
+	if i.Local.Name == i.Imported.Name {
+		return i.Local.String()
+	}
+
+	return fmt.Sprintf("%s as %s", i.Imported, i.Local)
+}
+
+type ImportNamespaceSpecifier struct {
+	*Attr
+	Local *Identifier
+}
+
+func (i *ImportNamespaceSpecifier) GetAttr() *Attr {
+	return i.Attr
+}
+
+func (i *ImportNamespaceSpecifier) String() string {
+	return "* as " + i.Local.String()
+}
+
+// ExportDefaultDeclaration exports a declaration, possibly anonymous, or an
+// expression as the module default.
+type ExportDefaultDeclaration struct {
+	*Attr
+	Declaration Node
+}
+
+func (e *ExportDefaultDeclaration) statementNode() {}
+
+func (e *ExportDefaultDeclaration) GetAttr() *Attr {
+	return e.Attr
+}
+
+func (e *ExportDefaultDeclaration) String() string {
+	if e, ok := e.Declaration.(Expression); ok {
+		return "export default " + operand(e, assignmentPrecedence) + ";"
+	}
+
+	return "export default " + e.Declaration.String()
+}
+
+// expressions
+
+type Expression interface {
+	Node
+	expressionNode()
+}
+
+type Identifier struct {
+	*Attr
+	Name string
+	// TypeAnnotation is the TypeScript type the identifier is annotated
+	// with when it's declared, e.g. number for `let x: number`, if any
+	TypeAnnotation TSType
+}
+
+func (i *Identifier) expressionNode() {}
+
+func (i *Identifier) GetAttr() *Attr {
+	return i.Attr
+}
+
+func (i *Identifier) String() string {
+	return i.Name
+}
+
+// TSType is a TypeScript type annotation.
+type TSType interface {
+	Node
+	tsTypeNode()
+}
+
+// TSKeywordType is a predefined TypeScript type, e.g. number or any.
+type TSKeywordType struct {
+	*Attr
+	Keyword string
+}
+
+func (k *TSKeywordType) tsTypeNode() {}
+
+func (k *TSKeywordType) GetAttr() *Attr {
+	return k.Attr
+}
+
+func (k *TSKeywordType) String() string {
+	return k.Keyword
+}
+
+// TSArrayType is an array type, e.g. `number[]`.
+type TSArrayType struct {
+	*Attr
+	ElementType TSType
+}
+
+func (a *TSArrayType) tsTypeNode() {}
+
+func (a *TSArrayType) GetAttr() *Attr {
+	return a.Attr
+}
+
+func (a *TSArrayType) String() string {
+	return a.ElementType.String() + "[]"
+}
+
+// TSTypeReference is a named type, e.g. `Array<number>`.
+type TSTypeReference struct {
+	*Attr
+	TypeName       *Identifier
+	TypeParameters []TSType
+}
+
+func (r *TSTypeReference) tsTypeNode() {}
+
+func (r *TSTypeReference) GetAttr() *Attr {
+	return r.Attr
+}
+
+func (r *TSTypeReference) String() string {
+	if len(r.TypeParameters) == 0 {
+		return r.TypeName.String()
+	}
+
+	var params []string
+	for _, p := range r.TypeParameters {
+		params = append(params, p.String())
+	}
+
+	return r.TypeName.String() + "<" + strings.Join(params, ", ") + ">"
+}
+
+// TSOpaqueType is a TypeScript type which isn't looked into, e.g. a union
+// type. It's told apart by the type of its node only.
+type TSOpaqueType struct {
+	*Attr
+}
+
+func (o *TSOpaqueType) tsTypeNode() {}
+
+func (o *TSOpaqueType) GetAttr() *Attr {
+	return o.Attr
+}
+
+func (o *TSOpaqueType) String() string {
+	return o.Type
+}
+
+// FunctionExpression is a function defined in an expression, e.g. the callee
+// of `(function() { ... })()`. Its ID, when named, is only bound in its body.
+type FunctionExpression struct {
+	*Attr
+	ID     *Identifier
+	Params []Expression
+	Body   *BlockStatement
+}
+
+func (f *FunctionExpression) expressionNode() {}
+
+func (f *FunctionExpression) GetAttr() *Attr {
+	return f.Attr
+}
+
+func (f *FunctionExpression) String() string {
+	return (&FunctionDeclaration{ID: f.ID, Params: f.Params, Body: f.Body}).String()
+}
+
+// ArrowFunctionExpression is an arrow function. Its Body is either a block
+// statement or, for a concise body as in `x => x + 1`, the expression it
+// returns.
+type ArrowFunctionExpression struct {
+	*Attr
+	Params []Expression
+	Body   Node
+}
+
+func (a *ArrowFunctionExpression) expressionNode() {}
+
+func (a *ArrowFunctionExpression) GetAttr() *Attr {
+	return a.Attr
+}
+
+// String parenthesizes a concise body which would otherwise read as a block,
+// e.g. `() => ({ a: 1 })`.
+func (a *ArrowFunctionExpression) String() string {
+	var params []string
+	for _, p := range a.Params {
+		params = append(params, p.String())
+	}
+
+	var body string
+	switch b := a.Body.(type) {
+	case *ObjectExpression:
+		body = "(" + b.String() + ")"
+	case Expression:
+		body = operand(b, assignmentPrecedence)
+	default:
+		body = b.String()
+	}
+
+	return fmt.Sprintf("(%s) => %s", strings.Join(params, ", "), body)
+}
+
+type CallExpression struct {
+	*Attr
+	Callee    Expression
+	Arguments []Expression
+}
+
+func (c *CallExpression) expressionNode() {}
+
+func (c *CallExpression) GetAttr() *Attr {
+	return c.Attr
+}
+
+func (c *CallExpression) String() string {
+	return calleeOperand(c.Callee) + argumentsString(c.Arguments)
+}
+
+// OptionalCallExpression is a call of an optional chain, e.g. `f?.()`, or
+// `a?.f()` whose callee is optional. Optional tells whether the call itself
+// is skipped when the callee is nullish.
+type OptionalCallExpression struct {
+	*Attr
+	Callee    Expression
+	Arguments []Expression
+	Optional  bool
+}
+
+func (o *OptionalCallExpression) expressionNode() {}
+
+func (o *OptionalCallExpression) GetAttr() *Attr {
+	return o.Attr
+}
+
+func (o *OptionalCallExpression) String() string {
+	if o.Optional {
+		return calleeOperand(o.Callee) + "?." + argumentsString(o.Arguments)
+	}
+
+	return calleeOperand(o.Callee) + argumentsString(o.Arguments)
+}
+
+func argumentsString(arguments []Expression) string {
+	var args []string
+	for _, arg := range arguments {
+		args = append(args, operand(arg, assignmentPrecedence))
+	}
+
+	return "(" + strings.Join(args, ", ") + ")"
+}
+
+type NewExpression struct {
+	*Attr
+	Callee    Expression
+	Arguments []Expression
+}
+
+func (n *NewExpression) expressionNode() {}
+
+func (n *NewExpression) GetAttr() *Attr {
+	return n.Attr
+}
+
+func (n *NewExpression) String() string {
+	var args []string
+	for _, arg := range n.Arguments {
+		args = append(args, operand(arg, assignmentPrecedence))
+	}
+
+	return fmt.Sprintf("new %s(%s)", calleeOperand(n.Callee), strings.Join(args, ", "))
+}
+
+type MemberExpression struct {
+	*Attr
+	Object   Expression
+	Property Expression
+	Computed bool
+}
+
+func (e *MemberExpression) expressionNode() {}
+
+func (m *MemberExpression) GetAttr() *Attr {
+	return m.Attr
+}
+
+func (e *MemberExpression) String() string {
+	if e.Computed {
+		return fmt.Sprintf("%s[%s]", calleeOperand(e.Object), e.Property)
+	}
+
+	return fmt.Sprintf("%s.%s", calleeOperand(e.Object), e.Property)
+}
+
+// OptionalMemberExpression is a member of an optional chain, e.g. each of
+// `a?.b.c`. It's Optional when the object is followed by `?.`.
+type OptionalMemberExpression struct {
+	*Attr
+	Object   Expression
+	Property Expression
+	Computed bool
+	Optional bool
+}
+
+func (o *OptionalMemberExpression) expressionNode() {}
+
+func (o *OptionalMemberExpression) GetAttr() *Attr {
+	return o.Attr
+}
+
+func (o *OptionalMemberExpression) String() string {
+	op := "."
+	if o.Optional {
+		op = "?."
+	}
+	object := calleeOperand(o.Object)
+
+	if o.Computed {
+		if o.Optional {
+			return fmt.Sprintf("%s?.[%s]", object, o.Property)
+		}
+
+		return fmt.Sprintf("%s[%s]", object, o.Property)
+	}
+
+	return fmt.Sprintf("%s%s%s", object, op, o.Property)
+}
+
+type TemplateLiteral struct {
+	*Attr
+	Quasis      []*TemplateElement
+	Expressions []Expression
+}
+
+func (t *TemplateLiteral) expressionNode() {}
+
+func (t *TemplateLiteral) literalNode() {}
+
+func (t *TemplateLiteral) GetAttr() *Attr {
+	return t.Attr
+}
+
+func (t *TemplateLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("`")
+	for i, q := range t.Quasis {
+		out.WriteString(q.Raw)
+		if i < len(t.Expressions) {
+			out.WriteString("${")
+			out.WriteString(t.Expressions[i].String())
+			out.WriteString("}")
+		}
+	}
+	out.WriteString("`")
+
+	return out.String()
+}
+
+// TemplateElement is a string part of a template literal, whose Cooked
+// value has its escapes interpreted.
+type TemplateElement struct {
+	*Attr
+	Raw    string
+	Cooked string
+	Tail   bool
+}
+
+func (t *TemplateElement) GetAttr() *Attr {
+	return t.Attr
+}
+
+func (t *TemplateElement) String() string {
+	return t.Raw
+}
+
+// ArrayExpression is an array literal, whose Elements are nil for holes, as
+// in `[1, , 3]`.
+type ArrayExpression struct {
+	*Attr
+	Elements []Expression
+}
+
+func (a *ArrayExpression) expressionNode() {}
+
+func (a *ArrayExpression) GetAttr() *Attr {
+	return a.Attr
+}
+
+func (a *ArrayExpression) String() string {
+	return arrayString(a.Elements)
+}
+
+// arrayString returns the source of an array literal or pattern of
+// elements, which are nil for holes. A trailing hole takes an extra comma,
+// as the last comma of a literal doesn't count.
+func arrayString(elements []Expression) string {
+	var out []string
+	for _, e := range elements {
+		if e == nil {
+			out = append(out, "")
+		} else {
+			out = append(out, operand(e, assignmentPrecedence))
+		}
+	}
+	if len(elements) > 0 && elements[len(elements)-1] == nil {
+		out = append(out, "")
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(out, ", "))
+}
+
+type ObjectExpression struct {
+	*Attr
+	Properties []Node
+}
+
+func (o *ObjectExpression) expressionNode() {}
+
+func (o *ObjectExpression) GetAttr() *Attr {
+	return o.Attr
+}
+
+func (o *ObjectExpression) String() string {
+	if len(o.Properties) == 0 {
+		return "{}"
+	}
+
+	var props []string
+	for _, p := range o.Properties {
+		props = append(props, p.String())
+	}
+
+	return fmt.Sprintf("{ %s }", strings.Join(props, ", "))
+}
+
+type ObjectProperty struct {
+	*Attr
+	Key       Expression
+	Value     Expression
+	Computed  bool
+	Shorthand bool
+}
+
+func (o *ObjectProperty) GetAttr() *Attr {
+	return o.Attr
+}
+
+func (o *ObjectProperty) String() string {
+	value := operand(o.Value, assignmentPrecedence)
+	switch {
+	case o.Shorthand:
+		return value
+	case o.Computed:
+		return fmt.Sprintf("[%s]: %s", o.Key, value)
+	}
+
+	return fmt.Sprintf("%s: %s", o.Key, value)
+}
+
+// ObjectMethod is a method or an accessor of an object literal, e.g.
+// `get x() {...}`, whose Kind is "method", "get" or "set".
+type ObjectMethod struct {
+	*Attr
+	Kind     string
+	Key      Expression
+	Computed bool
+	Params   []Expression
+	Body     *BlockStatement
+}
+
+func (o *ObjectMethod) GetAttr() *Attr {
+	return o.Attr
+}
+
+func (o *ObjectMethod) String() string {
+	var out bytes.Buffer
+
+	if o.Kind != "method" {
+		out.WriteString(o.Kind + " ")
+	}
+	if o.Computed {
+		out.WriteString("[" + o.Key.String() + "]")
+	} else {
+		out.WriteString(o.Key.String())
+	}
+
+	var params []string
+	for _, p := range o.Params {
+		params = append(params, p.String())
+	}
+	out.WriteString("(" + strings.Join(params, ", ") + ") ")
+	out.WriteString(o.Body.String())
+
+	return out.String()
+}
+
+// SpreadElement spreads its argument, e.g. `...a` in `{...a, b: 1}` or
+// `[...a, b]`.
+type SpreadElement struct {
+	*Attr
+	Argument Expression
+}
+
+func (s *SpreadElement) expressionNode() {}
+
+func (s *SpreadElement) GetAttr() *Attr {
+	return s.Attr
+}
+
+func (s *SpreadElement) String() string {
+	return "..." + operand(s.Argument, assignmentPrecedence)
+}
+
+// ArrayPattern is an array destructuring target, e.g. `[a, , b]`, whose
+// Elements are nil for holes.
+type ArrayPattern struct {
+	*Attr
+	Elements []Expression
+}
+
+func (a *ArrayPattern) expressionNode() {}
+
+func (a *ArrayPattern) GetAttr() *Attr {
+	return a.Attr
+}
+
+func (a *ArrayPattern) String() string {
+	return arrayString(a.Elements)
+}
+
+// AssignmentPattern is a destructuring target with a default, e.g. `b = 5`
+// in `[, b = 5]`: Left is assigned Right when the value is undefined.
+type AssignmentPattern struct {
+	*Attr
+	Left  Expression
+	Right Expression
+}
+
+func (a *AssignmentPattern) expressionNode() {}
+
+func (a *AssignmentPattern) GetAttr() *Attr {
+	return a.Attr
+}
+
+func (a *AssignmentPattern) String() string {
+	return fmt.Sprintf("%s = %s", a.Left, operand(a.Right, assignmentPrecedence))
+}
+
+// ObjectPattern is an object destructuring target, e.g. `{a, b: c}`, whose
+// properties have patterns as values.
+type ObjectPattern struct {
+	*Attr
+	Properties []Node
+}
+
+func (o *ObjectPattern) expressionNode() {}
+
+func (o *ObjectPattern) GetAttr() *Attr {
+	return o.Attr
+}
+
+func (o *ObjectPattern) String() string {
+	if len(o.Properties) == 0 {
+		return "{}"
+	}
+
+	var props []string
+	for _, p := range o.Properties {
+		props = append(props, p.String())
+	}
+
+	return fmt.Sprintf("{ %s }", strings.Join(props, ", "))
+}
+
+type AssignmentExpression struct {
+	*Attr
+	Operator AssignmentOperator
+	Left     Expression
+	Right    Expression
+}
+
+func (a *AssignmentExpression) expressionNode() {}
+
+func (a *AssignmentExpression) GetAttr() *Attr {
+	return a.Attr
+}
+
+func (a *AssignmentExpression) String() string {
+	return fmt.Sprintf("%s %s %s", a.Left, a.Operator, operand(a.Right, assignmentPrecedence))
+}
+
+type AssignmentOperator string
+
+type BinaryExpression struct {
+	*Attr
+	Operator BinaryOperator
+	Left     Expression
+	Right    Expression
+}
+
+func (b *BinaryExpression) expressionNode() {}
+
+func (b *BinaryExpression) GetAttr() *Attr {
+	return b.Attr
+}
+
+// String parenthesizes operands binding looser than the operator, and
+// operands binding as tightly when they're on the side the operator doesn't
+// associate to, e.g. `a - (b - c)` or `(a ** b) ** c`.
+func (a *BinaryExpression) String() string {
+	p := binaryPrecedence[a.Operator]
+	left, right := p, p+1
+	if a.Operator == "**" {
+		left, right = p+1, p
+		// a unary operand of ** must be parenthesized, as in `(-a) ** b`
+		if _, ok := a.Left.(*UnaryExpression); ok {
+			left = primaryPrecedence
+		}
+	}
+
+	return fmt.Sprintf("%s %s %s", operand(a.Left, left), a.Operator, operand(a.Right, right))
+}
+
+type BinaryOperator string
+
+type LogicalExpression struct {
+	*Attr
+	Operator LogicalOperator
+	Left     Expression
+	Right    Expression
+}
+
+func (l *LogicalExpression) expressionNode() {}
+
+func (l *LogicalExpression) GetAttr() *Attr {
+	return l.Attr
+}
+
+// String parenthesizes operands like BinaryExpression does, and ?? operands
+// mixing in && or || the other way around, which JavaScript requires, e.g.
+// `(a && b) ?? c`.
+func (l *LogicalExpression) String() string {
+	p := logicalPrecedence[l.Operator]
+	side := func(e Expression, min int) string {
+		if o, ok := e.(*LogicalExpression); ok && (o.Operator == "??") != (l.Operator == "??") {
+			return "(" + o.String() + ")"
+		}
+		return operand(e, min)
+	}
+
+	return fmt.Sprintf("%s %s %s", side(l.Left, p), l.Operator, side(l.Right, p+1))
+}
+
+type LogicalOperator string
+
+// ConditionalExpression is a conditional `test ? consequent : alternate`,
+// which is right-associative: `a ? b : c ? d : e` is
+// `a ? b : (c ? d : e)`.
+type ConditionalExpression struct {
+	*Attr
+	Test       Expression
+	Consequent Expression
+	Alternate  Expression
+}
+
+func (c *ConditionalExpression) expressionNode() {}
+
+func (c *ConditionalExpression) GetAttr() *Attr {
+	return c.Attr
+}
+
+func (c *ConditionalExpression) String() string {
+	return fmt.Sprintf("%s ? %s : %s",
+		operand(c.Test, conditionalPrecedence+1),
+		operand(c.Consequent, assignmentPrecedence),
+		operand(c.Alternate, assignmentPrecedence))
+}
+
+type UnaryExpression struct {
+	*Attr
+	Operator UnaryOperator
+	Argument Expression
+}
+
+func (u *UnaryExpression) expressionNode() {}
+
+func (u *UnaryExpression) GetAttr() *Attr {
+	return u.Attr
+}
+
+// String separates the operator from its argument when they'd read as
+// another token otherwise, e.g. `typeof x` or `- -x`.
+func (u *UnaryExpression) String() string {
+	arg := operand(u.Argument, prefixPrecedence)
+	if r := u.Operator[len(u.Operator)-1]; (r >= 'a' && r <= 'z') || ((r == '+' || r == '-') && arg[0] == r) {
+		return fmt.Sprintf("%s %s", u.Operator, arg)
+	}
+
+	return fmt.Sprintf("%s%s", u.Operator, arg)
+}
+
+type UnaryOperator string
+
+type UpdateExpression struct {
+	*Attr
+	Operator UpdateOperator
+	Prefix   bool
+	Argument Expression
+}
+
+func (u *UpdateExpression) expressionNode() {}
+
+func (u *UpdateExpression) GetAttr() *Attr {
+	return u.Attr
+}
+
+func (u *UpdateExpression) String() string {
+	arg := operand(u.Argument, callPrecedence)
+	if u.Prefix {
+		return fmt.Sprintf("%s%s", u.Operator, arg)
+	}
+
+	return fmt.Sprintf("%s%s", arg, u.Operator)
+}
+
+type UpdateOperator string
+
+type SequenceExpression struct {
+	*Attr
+	Expressions []Expression
+}
+
+func (s *SequenceExpression) expressionNode() {}
+
+func (s *SequenceExpression) GetAttr() *Attr {
+	return s.Attr
+}
+
+func (s *SequenceExpression) String() string {
+	var exprs []string
+	for _, e := range s.Expressions {
+		exprs = append(exprs, e.String())
+	}
+
+	return strings.Join(exprs, ", ")
+}
+
+// literals
+
+type Literal interface {
+	Expression
+	literalNode()
+}
+
+type StringLiteral struct {
+	*Attr
+	Extra *Extra
+	Value string
+}
+
+func (s *StringLiteral) expressionNode() {}
+
+func (s *StringLiteral) literalNode() {}
+
+func (s *StringLiteral) GetAttr() *Attr {
+	return s.Attr
+}
+
+func (s *StringLiteral) String() string {
+	return quote(s.Value)
+}
+
+type NullLiteral struct {
+	*Attr
+}
+
+func (n *NullLiteral) expressionNode() {}
+
+func (n *NullLiteral) literalNode() {}
+
+func (n *NullLiteral) GetAttr() *Attr {
+	return n.Attr
+}
+
+func (n *NullLiteral) String() string {
+	return "null"
+}
+
+type BooleanLiteral struct {
+	*Attr
+	Value bool
+}
+
+func (b *BooleanLiteral) expressionNode() {}
+
+func (b *BooleanLiteral) literalNode() {}
+
+func (b *BooleanLiteral) GetAttr() *Attr {
+	return b.Attr
+}
+
+func (b *BooleanLiteral) String() string {
+	return strconv.FormatBool(b.Value)
+}
+
+// RegExpLiteral is a regular expression literal, e.g. `/\d+/g`
+type RegExpLiteral struct {
+	*Attr
+	Pattern string
+	Flags   string
+}
+
+func (r *RegExpLiteral) expressionNode() {}
+
+func (r *RegExpLiteral) literalNode() {}
+
+func (r *RegExpLiteral) GetAttr() *Attr {
+	return r.Attr
+}
+
+func (r *RegExpLiteral) String() string {
+	return "/" + r.Pattern + "/" + r.Flags
+}
+
+// TODO: Value is always float64
+// Can delay conversion and adapt to int vs. float
+type NumericLiteral struct {
+	*Attr
+	Extra *Extra
+	Value float64
+}
+
+func (n *NumericLiteral) expressionNode() {}
+
+func (n *NumericLiteral) literalNode() {}
+
+func (n *NumericLiteral) GetAttr() *Attr {
+	return n.Attr
+}
+
+// String returns the number as written in the source when it's known.
+func (n *NumericLiteral) String() string {
+	if n.Extra != nil {
+		if raw, ok := n.Extra.Raw.(string); ok {
+			return raw
+		}
+	}
+
+	return formatNumber(n.Value)
 }
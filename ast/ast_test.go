@@ -2,10 +2,19 @@ package ast
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 )
 
+// position is the source location of the nodes of the AST JSON fixtures,
+// and loc their offsets too, for the nodes whose offsets don't matter
+const (
+	position = `"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":0}}`
+	loc      = `"start":0,"end":0,` + position
+)
+
 func TestUnmarshalJSON(t *testing.T) {
 	s := `{"type":"File","start":0,"end":31,"loc":{"start":{"line":1,"column":0},"end":{"line":2,"column":0}},"program":{"type":"Program","start":0,"end":31,"loc":{"start":{"line":1,"column":0},"end":{"line":2,"column":0}},"sourceType":"script","body":[{"type":"ExpressionStatement","start":0,"end":30,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":30}},"expression":{"type":"CallExpression","start":0,"end":30,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":30}},"callee":{"type":"MemberExpression","start":0,"end":11,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":11}},"object":{"type":"Identifier","start":0,"end":7,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":7},"identifierName":"console"},"name":"console"},"property":{"type":"Identifier","start":8,"end":11,"loc":{"start":{"line":1,"column":8},"end":{"line":1,"column":11},"identifierName":"log"},"name":"log"},"computed":false},"arguments":[{"type":"StringLiteral","start":12,"end":29,"loc":{"start":{"line":1,"column":12},"end":{"line":1,"column":29}},"extra":{"rawValue":"Hello, Godzilla","raw":"'Hello, Godzilla'"},"value":"Hello, Godzilla"}]}}],"directives":[]},"comments":[],"tokens":[{"type":{"label":"name","beforeExpr":false,"startsExpr":true,"rightAssociative":false,"isLoop":false,"isAssign":false,"prefix":false,"postfix":false,"binop":null},"value":"console","start":0,"end":7,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":7}}},{"type":{"label":".","beforeExpr":false,"startsExpr":false,"rightAssociative":false,"isLoop":false,"isAssign":false,"prefix":false,"postfix":false,"binop":null,"updateContext":null},"start":7,"end":8,"loc":{"start":{"line":1,"column":7},"end":{"line":1,"column":8}}},{"type":{"label":"name","beforeExpr":false,"startsExpr":true,"rightAssociative":false,"isLoop":false,"isAssign":false,"prefix":false,"postfix":false,"binop":null},"value":"log","start":8,"end":11,"loc":{"start":{"line":1,"column":8},"end":{"line":1,"column":11}}},{"type":{"label":"(","beforeExpr":true,"startsExpr":true,"rightAssociative":false,"isLoop":false,"isAssign":false,"prefix":false,"postfix":false,"binop":null},"start":11,"end":12,"loc":{"start":{"line":1,"column":11},"end":{"line":1,"column":12}}},{"type":{"label":"string","beforeExpr":false,"startsExpr":true,"rightAssociative":false,"isLoop":false,"isAssign":false,"prefix":false,"postfix":false,"binop":null,"updateContext":null},"value":"Hello, Godzilla","start":12,"end":29,"loc":{"start":{"line":1,"column":12},"end":{"line":1,"column":29}}},{"type":{"label":")","beforeExpr":false,"startsExpr":false,"rightAssociative":false,"isLoop":false,"isAssign":false,"prefix":false,"postfix":false,"binop":null},"start":29,"end":30,"loc":{"start":{"line":1,"column":29},"end":{"line":1,"column":30}}},{"type":{"label":"eof","beforeExpr":false,"startsExpr":false,"rightAssociative":false,"isLoop":false,"isAssign":false,"prefix":false,"postfix":false,"binop":null,"updateContext":null},"start":31,"end":31,"loc":{"start":{"line":2,"column":0},"end":{"line":2,"column":0}}}]}`
 	got := &File{}
@@ -35,3 +44,601 @@ func TestUnmarshalJSON(t *testing.T) {
 		t.Fatalf("file not equal: want=%s got=%s", want, got)
 	}
 }
+
+func TestStringLiteral_String(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{`say "hi"`, `"say \"hi\""`},
+		{"a\nb", `"a\nb"`},
+		{"a\tb", `"a\tb"`},
+		{`C:\dir`, `"C:\\dir"`},
+		{"\x00", `"\x00"`},
+		{"\u2028", `"\u2028"`},
+		{"héllo, 世界", `"héllo, 世界"`},
+	}
+
+	for _, test := range tests {
+		s := &StringLiteral{Value: test.value}
+		if got := s.String(); got != test.want {
+			t.Errorf("string literal %q: want=%s got=%s", test.value, test.want, got)
+		}
+	}
+}
+
+func TestUnmarshalStringLiteral_Raw(t *testing.T) {
+	tests := []struct {
+		value string
+		raw   string
+		want  string
+	}{
+		{"\U0001F600", `'😀'`, "\U0001F600"},
+		{"\U0001F600", `'\u{1F600}'`, "\U0001F600"},
+		{"\U0001F600", `"\ud83d\ude00"`, "\U0001F600"},
+		{"\uFFFD", `'\ud83d'`, "\xed\xa0\xbd"},
+		{"a\x00b", `'a\0b'`, "a\x00b"},
+		{"\u00e9\n", `'\xe9\n'`, "\u00e9\n"},
+		{"ab", "'a\\\nb'", "ab"},
+		{`it's`, `'it\'s'`, `it's`},
+	}
+
+	for _, test := range tests {
+		m := map[string]interface{}{
+			"type":  "StringLiteral",
+			"start": 0.0,
+			"end":   0.0,
+			"loc": map[string]interface{}{
+				"start": map[string]interface{}{"line": 1.0, "column": 0.0},
+				"end":   map[string]interface{}{"line": 1.0, "column": 0.0},
+			},
+			"value": test.value,
+			"extra": map[string]interface{}{"rawValue": test.value, "raw": test.raw},
+		}
+
+		if got := unmarshalStringLiteral(m).Value; got != test.want {
+			t.Errorf("string literal %s: want=%q got=%q", test.raw, test.want, got)
+		}
+	}
+}
+
+func TestUnmarshalFile_LeadingComments(t *testing.T) {
+	// /* license */
+	// // header
+	// a; // not leading
+	comment := func(typ, value string, start, end int) string {
+		return fmt.Sprintf(`{"type":%q,"value":%q,"start":%d,"end":%d,%s}`, typ, value, start, end, position)
+	}
+	s := `{"type":"File","start":0,"end":40,` + position + `,"program":{"type":"Program","start":0,"end":40,` + position +
+		`,"sourceType":"script","body":[{"type":"ExpressionStatement","start":24,"end":26,` + position +
+		`,"expression":{"type":"Identifier","start":24,"end":25,` + position + `,"name":"a"}}]},"comments":[` +
+		comment("CommentBlock", " license ", 0, 13) + `,` +
+		comment("CommentLine", " header", 14, 23) + `,` +
+		comment("CommentLine", " not leading", 27, 40) + `]}`
+
+	f := &File{}
+	if err := json.Unmarshal([]byte(s), f); err != nil {
+		t.Fatalf("json unmarshal has error: %s", err)
+	}
+
+	want := []string{"/* license */", "// header"}
+	if len(f.LeadingComments) != len(want) {
+		t.Fatalf("want %d leading comments, got %v", len(want), f.LeadingComments)
+	}
+	for i, c := range f.LeadingComments {
+		if got := c.String(); got != want[i] {
+			t.Errorf("leading comment %d: want=%s got=%s", i, want[i], got)
+		}
+	}
+}
+
+func TestUnmarshalFunctionDeclaration_LeadingComments(t *testing.T) {
+	// /** @param {number} x */
+	// function f(x) {}
+	s := `{"type":"FunctionDeclaration",` + loc + `,"id":{"type":"Identifier",` + loc + `,"name":"f"},` +
+		`"params":[{"type":"Identifier",` + loc + `,"name":"x"}],"body":{"type":"BlockStatement",` + loc + `,"body":[]},` +
+		`"leadingComments":[{"type":"CommentBlock",` + loc + `,"value":"* @param {number} x "}]}`
+
+	stmt, err := UnmarshalStatement([]byte(s))
+	if err != nil {
+		t.Fatalf("unmarshal has error: %s", err)
+	}
+
+	fd := stmt.(*FunctionDeclaration)
+	if len(fd.LeadingComments) != 1 {
+		t.Fatalf("want 1 leading comment, got %v", fd.LeadingComments)
+	}
+	if got, want := fd.LeadingComments[0].String(), "/** @param {number} x */"; got != want {
+		t.Errorf("leading comment: want=%s got=%s", want, got)
+	}
+	if got := fd.Params[0].(*Identifier).LeadingComments; got != nil {
+		t.Errorf("want no leading comments of the param, got %v", got)
+	}
+}
+
+func TestUnmarshalProgram_Interpreter(t *testing.T) {
+	// #!/usr/bin/env node
+	// a
+	s := `{"type":"File","start":0,"end":21,` + position + `,"program":{"type":"Program","start":0,"end":21,` + position +
+		`,"sourceType":"script","interpreter":{"type":"InterpreterDirective","start":0,"end":19,` + position +
+		`,"value":"/usr/bin/env node"},"body":[{"type":"ExpressionStatement","start":20,"end":21,` + position +
+		`,"expression":{"type":"Identifier","start":20,"end":21,` + position + `,"name":"a"}}]},"comments":[]}`
+
+	f := &File{}
+	if err := json.Unmarshal([]byte(s), f); err != nil {
+		t.Fatalf("json unmarshal has error: %s", err)
+	}
+
+	if got, want := f.Program.String(), "#!/usr/bin/env node\na;\n"; got != want {
+		t.Errorf("want=%q got=%q", want, got)
+	}
+}
+
+func TestUnmarshalImportDeclaration(t *testing.T) {
+	// import a, { b as c } from "./m"
+	// import * as ns from "./n"
+	id := func(name string) string {
+		return `{"type":"Identifier",` + loc + `,"name":"` + name + `"}`
+	}
+	src := func(value string) string {
+		return `{"type":"StringLiteral",` + loc + `,"extra":{"rawValue":"` + value + `","raw":"'` + value + `'"},"value":"` + value + `"}`
+	}
+	s := `{"type":"File",` + loc + `,"program":{"type":"Program",` + loc + `,"sourceType":"module","body":[` +
+		`{"type":"ImportDeclaration",` + loc + `,"specifiers":[` +
+		`{"type":"ImportDefaultSpecifier",` + loc + `,"local":` + id("a") + `},` +
+		`{"type":"ImportSpecifier",` + loc + `,"imported":` + id("b") + `,"local":` + id("c") + `}` +
+		`],"source":` + src("./m") + `},` +
+		`{"type":"ImportDeclaration",` + loc + `,"specifiers":[` +
+		`{"type":"ImportNamespaceSpecifier",` + loc + `,"local":` + id("ns") + `}` +
+		`],"source":` + src("./n") + `}` +
+		`],"directives":[]}}`
+
+	f := &File{}
+	if err := json.Unmarshal([]byte(s), f); err != nil {
+		t.Fatalf("json unmarshal has error: %s", err)
+	}
+
+	want := []string{
+		`import a, { b as c } from "./m";`,
+		`import * as ns from "./n";`,
+	}
+	for i, s := range f.Program.Body {
+		if got := s.String(); got != want[i] {
+			t.Errorf("import declaration %d: want=%s got=%s", i, want[i], got)
+		}
+	}
+}
+
+func TestUnmarshalExportNamedDeclaration_Source(t *testing.T) {
+	// export { x as y } from "./m"
+	id := func(name string) string {
+		return `{"type":"Identifier",` + loc + `,"name":"` + name + `"}`
+	}
+	s := `{"type":"ExportNamedDeclaration",` + loc + `,"declaration":null,"specifiers":[` +
+		`{"type":"ExportSpecifier",` + loc + `,"local":` + id("x") + `,"exported":` + id("y") + `}` +
+		`],"source":{"type":"StringLiteral",` + loc + `,"extra":{"rawValue":"./m","raw":"'./m'"},"value":"./m"}}`
+
+	stmt, err := UnmarshalStatement([]byte(s))
+	if err != nil {
+		t.Fatalf("unmarshal has error: %s", err)
+	}
+
+	if got, want := stmt.String(), `export { x as y } from "./m";`; got != want {
+		t.Errorf("want=%s got=%s", want, got)
+	}
+	// the re-exported name isn't a reference to a name of the module
+	if free := FreeVariables(stmt); len(free) != 0 {
+		t.Errorf("want no free variables, got %v", free)
+	}
+}
+
+func TestUnmarshalArrayExpression_Holes(t *testing.T) {
+	// [1, , 3]
+	n := func(value string) string {
+		return `{"type":"NumericLiteral",` + loc + `,"extra":{"rawValue":` + value + `,"raw":"` + value + `"},"value":` + value + `}`
+	}
+	s := `{"type":"ExpressionStatement",` + loc + `,"expression":{"type":"ArrayExpression",` + loc +
+		`,"elements":[` + n("1") + `,null,` + n("3") + `]}}`
+
+	stmt, err := UnmarshalStatement([]byte(s))
+	if err != nil {
+		t.Fatalf("unmarshal has error: %s", err)
+	}
+
+	ae := stmt.(*ExpressionStatement).Expression.(*ArrayExpression)
+	if len(ae.Elements) != 3 || ae.Elements[1] != nil {
+		t.Fatalf("want 3 elements with a nil hole, got %#v", ae.Elements)
+	}
+	if got, want := stmt.String(), "[1, , 3];"; got != want {
+		t.Errorf("want=%s got=%s", want, got)
+	}
+}
+
+func TestUnmarshalVariableDeclarator_Pattern(t *testing.T) {
+	// const {a: [b, c]} = obj
+	id := func(name string) string {
+		return `{"type":"Identifier",` + loc + `,"name":"` + name + `"}`
+	}
+	s := `{"type":"VariableDeclaration",` + loc + `,"kind":"const","declarations":[{"type":"VariableDeclarator",` + loc +
+		`,"id":{"type":"ObjectPattern",` + loc + `,"properties":[{"type":"ObjectProperty",` + loc + `,"computed":false,"shorthand":false,"key":` + id("a") +
+		`,"value":{"type":"ArrayPattern",` + loc + `,"elements":[` + id("b") + `,` + id("c") + `]}}]},"init":` + id("obj") + `}]}`
+
+	stmt, err := UnmarshalStatement([]byte(s))
+	if err != nil {
+		t.Fatalf("unmarshal has error: %s", err)
+	}
+
+	if got, want := stmt.String(), "const { a: [b, c] } = obj;"; got != want {
+		t.Errorf("want=%s got=%s", want, got)
+	}
+	if got, want := DeclaredVariables(stmt), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("declared variables: want=%v got=%v", want, got)
+	}
+}
+
+func TestUnmarshalVariableDeclarator_UnsupportedBinding(t *testing.T) {
+	// const ...a = obj, which isn't valid JavaScript
+	s := `{"type":"VariableDeclaration",` + loc + `,"kind":"const","declarations":[{"type":"VariableDeclarator",` + loc +
+		`,"id":{"type":"RestElement",` + loc + `,"argument":{"type":"Identifier",` + loc + `,"name":"a"}},"init":{"type":"Identifier",` + loc + `,"name":"obj"}}]}`
+
+	if _, err := UnmarshalStatement([]byte(s)); err == nil || err.Error() != "ast: unsupported binding type RestElement" {
+		t.Errorf("want an unsupported binding error, got %v", err)
+	}
+}
+
+func TestUnmarshalRegExpLiteral(t *testing.T) {
+	// /a\/b/gi
+	s := `{"type":"ExpressionStatement",` + loc + `,"expression":{"type":"RegExpLiteral",` + loc +
+		`,"extra":{"raw":"/a\\/b/gi"},"pattern":"a\\/b","flags":"gi"}}`
+
+	stmt, err := UnmarshalStatement([]byte(s))
+	if err != nil {
+		t.Fatalf("unmarshal has error: %s", err)
+	}
+
+	r := stmt.(*ExpressionStatement).Expression.(*RegExpLiteral)
+	if r.Pattern != `a\/b` || r.Flags != "gi" {
+		t.Fatalf("want pattern a\\/b and flags gi, got %#v", r)
+	}
+	if got, want := stmt.String(), `/a\/b/gi;`; got != want {
+		t.Errorf("want=%s got=%s", want, got)
+	}
+}
+
+func TestUnmarshalForOfStatement(t *testing.T) {
+	// for (x of xs) {}
+	id := func(name string) string {
+		return `{"type":"Identifier",` + loc + `,"name":"` + name + `"}`
+	}
+	s := `{"type":"ForOfStatement",` + loc + `,"await":false,"left":` + id("x") + `,"right":` + id("xs") +
+		`,"body":{"type":"BlockStatement",` + loc + `,"body":[]}}`
+
+	stmt, err := UnmarshalStatement([]byte(s))
+	if err != nil {
+		t.Fatalf("unmarshal has error: %s", err)
+	}
+
+	fs := stmt.(*ForOfStatement)
+	if _, ok := fs.Left.(*Identifier); !ok {
+		t.Fatalf("want an identifier loop variable, got %#v", fs.Left)
+	}
+	if got, want := stmt.String(), "for (x of xs) {\n}"; got != want {
+		t.Errorf("want=%s got=%s", want, got)
+	}
+
+	// for await (x of xs) {}
+	stmt, err = UnmarshalStatement([]byte(strings.Replace(s, `"await":false`, `"await":true`, 1)))
+	if err != nil {
+		t.Fatalf("unmarshal has error: %s", err)
+	}
+	if got, want := stmt.String(), "for await (x of xs) {\n}"; got != want {
+		t.Errorf("want=%s got=%s", want, got)
+	}
+}
+
+func TestUnmarshalDoWhileStatement(t *testing.T) {
+	// do {} while (x)
+	s := `{"type":"DoWhileStatement",` + loc + `,"body":{"type":"BlockStatement",` + loc + `,"body":[]}` +
+		`,"test":{"type":"Identifier",` + loc + `,"name":"x"}}`
+
+	stmt, err := UnmarshalStatement([]byte(s))
+	if err != nil {
+		t.Fatalf("unmarshal has error: %s", err)
+	}
+
+	if got, want := stmt.String(), "do {\n} while (x);"; got != want {
+		t.Errorf("want=%s got=%s", want, got)
+	}
+}
+
+func TestUnmarshalArrowFunctionExpression(t *testing.T) {
+	// x => x
+	x := `{"type":"Identifier",` + loc + `,"name":"x"}`
+	s := `{"type":"ExpressionStatement",` + loc + `,"expression":{"type":"ArrowFunctionExpression",` + loc +
+		`,"id":null,"generator":false,"async":false,"expression":true,"params":[` + x + `],"body":` + x + `}}`
+
+	stmt, err := UnmarshalStatement([]byte(s))
+	if err != nil {
+		t.Fatalf("unmarshal has error: %s", err)
+	}
+
+	af := stmt.(*ExpressionStatement).Expression.(*ArrowFunctionExpression)
+	if _, ok := af.Body.(*Identifier); !ok {
+		t.Fatalf("want a concise body, got %#v", af.Body)
+	}
+	if got, want := stmt.String(), "(x) => x;"; got != want {
+		t.Errorf("want=%s got=%s", want, got)
+	}
+}
+
+func TestUnmarshalIdentifier_TypeAnnotation(t *testing.T) {
+	// let a: Array<number>, b: string[], c: number | null, d
+	node := func(typ, fields string) string {
+		return `{"type":"` + typ + `",` + loc + fields + `}`
+	}
+	annotated := func(name, typ string) string {
+		annotation := ""
+		if typ != "" {
+			annotation = `,"typeAnnotation":` + node("TSTypeAnnotation", `,"typeAnnotation":`+typ)
+		}
+		return node("VariableDeclarator", `,"id":`+node("Identifier", `,"name":"`+name+`"`+annotation)+`,"init":null`)
+	}
+	s := node("VariableDeclaration", `,"kind":"let","declarations":[`+
+		annotated("a", node("TSTypeReference", `,"typeName":`+node("Identifier", `,"name":"Array"`)+
+			`,"typeParameters":`+node("TSTypeParameterInstantiation", `,"params":[`+node("TSNumberKeyword", "")+`]`)))+`,`+
+		annotated("b", node("TSArrayType", `,"elementType":`+node("TSStringKeyword", "")))+`,`+
+		annotated("c", node("TSUnionType", `,"types":[`+node("TSNumberKeyword", "")+`,`+node("TSNullKeyword", "")+`]`))+`,`+
+		annotated("d", "")+`]`)
+
+	stmt, err := UnmarshalStatement([]byte(s))
+	if err != nil {
+		t.Fatalf("unmarshal has error: %s", err)
+	}
+
+	want := []string{"Array<number>", "string[]", "TSUnionType", ""}
+	for i, d := range stmt.(*VariableDeclaration).Declarations {
+		id := d.ID.(*Identifier)
+		got := ""
+		if id.TypeAnnotation != nil {
+			got = id.TypeAnnotation.String()
+		}
+		if got != want[i] {
+			t.Errorf("type annotation of %s: want=%q got=%q", id.Name, want[i], got)
+		}
+	}
+}
+
+func TestNode_String(t *testing.T) {
+	id := func(name string) *Identifier { return &Identifier{Name: name} }
+	bin := func(op string, l, r Expression) *BinaryExpression {
+		return &BinaryExpression{Operator: BinaryOperator(op), Left: l, Right: r}
+	}
+	logical := func(op string, l, r Expression) *LogicalExpression {
+		return &LogicalExpression{Operator: LogicalOperator(op), Left: l, Right: r}
+	}
+	seq := &SequenceExpression{Expressions: []Expression{id("a"), id("b")}}
+	iife := &CallExpression{Callee: &FunctionExpression{Body: &BlockStatement{}}}
+
+	tests := []struct {
+		node Node
+		want string
+	}{
+		{bin("-", id("a"), bin("-", id("b"), id("c"))), "a - (b - c)"},
+		{bin("-", bin("-", id("a"), id("b")), id("c")), "a - b - c"},
+		{bin("*", bin("+", id("a"), id("b")), id("c")), "(a + b) * c"},
+		{bin("**", id("a"), bin("**", id("b"), id("c"))), "a ** b ** c"},
+		{bin("**", bin("**", id("a"), id("b")), id("c")), "(a ** b) ** c"},
+		{&AssignmentExpression{Operator: "=", Left: id("x"), Right: seq}, "x = (a, b)"},
+		{&CallExpression{Callee: id("f"), Arguments: []Expression{seq, id("c")}}, "f((a, b), c)"},
+		{&CallExpression{Callee: id("f"), Arguments: []Expression{id("a"), &SpreadElement{Argument: id("b")}}}, "f(a, ...b)"},
+		{&MemberExpression{Object: bin("+", id("a"), id("b")), Property: id("c")}, "(a + b).c"},
+		{&MemberExpression{Object: &NumericLiteral{Value: 1}, Property: id("toString")}, "(1).toString"},
+		{&UpdateExpression{Operator: "++", Argument: &MemberExpression{Object: id("a"), Property: id("b")}}, "a.b++"},
+		{&ArrayExpression{Elements: []Expression{id("a"), nil}}, "[a, , ]"},
+		{&ArrayPattern{Elements: []Expression{nil, &AssignmentPattern{Left: id("b"), Right: seq}}}, "[, b = (a, b)]"},
+		{&ArrayExpression{Elements: []Expression{&SpreadElement{Argument: seq}, id("b")}}, "[...(a, b), b]"},
+		{&NumericLiteral{Value: 0.30000000000000004}, "0.30000000000000004"},
+		{&NumericLiteral{Value: 1e21}, "1e21"},
+		{&NumericLiteral{Value: 1e-7}, "1e-7"},
+		{&MemberExpression{Object: &RegExpLiteral{Pattern: `\d+`, Flags: "g"}, Property: id("test")}, `/\d+/g.test`},
+		{&LabeledStatement{Label: id("a"), Body: &BreakStatement{Label: id("a")}}, "a: break a;"},
+		{&ContinueStatement{}, "continue;"},
+		{&SwitchStatement{Discriminant: id("x"), Cases: []*SwitchCase{
+			{Test: id("a")},
+			{Consequent: []Statement{&BreakStatement{}}},
+		}}, "switch (x) {\ncase a:\ndefault:\nbreak;\n}"},
+		{&OptionalCallExpression{Callee: &OptionalMemberExpression{Object: id("a"), Property: id("f"), Optional: true}}, "a?.f()"},
+		{&OptionalCallExpression{Callee: &MemberExpression{Object: id("a"), Property: id("f")}, Arguments: []Expression{id("b")}, Optional: true}, "a.f?.(b)"},
+		{&UnaryExpression{Operator: "typeof", Argument: id("x")}, "typeof x"},
+		{&UnaryExpression{Operator: "-", Argument: &UnaryExpression{Operator: "-", Argument: id("x")}}, "- -x"},
+		{&UnaryExpression{Operator: "!", Argument: bin("+", id("a"), id("b"))}, "!(a + b)"},
+		{bin("**", &UnaryExpression{Operator: "-", Argument: id("a")}, id("b")), "(-a) ** b"},
+		{logical("??", logical("??", id("a"), id("b")), id("c")), "a ?? b ?? c"},
+		{logical("??", logical("&&", id("a"), id("b")), id("c")), "(a && b) ?? c"},
+		{logical("??", id("a"), logical("||", id("b"), id("c"))), "a ?? (b || c)"},
+		{logical("||", id("a"), logical("&&", id("b"), id("c"))), "a || b && c"},
+		{logical("&&", id("a"), bin("|", id("b"), id("c"))), "a && b | c"},
+		{bin("+", logical("??", id("a"), id("b")), id("c")), "(a ?? b) + c"},
+		{&ArrowFunctionExpression{Params: []Expression{id("x")}, Body: bin("+", id("x"), &NumericLiteral{Value: 1})}, "(x) => x + 1"},
+		{&ArrowFunctionExpression{Body: &SequenceExpression{Expressions: []Expression{id("a"), id("b")}}}, "() => (a, b)"},
+		{&ArrowFunctionExpression{Params: []Expression{id("a"), id("b")}, Body: &BlockStatement{}}, "(a, b) => {\n}"},
+		{&ConditionalExpression{Test: id("a"), Consequent: id("b"), Alternate: &ConditionalExpression{Test: id("c"), Consequent: id("d"), Alternate: id("e")}}, "a ? b : c ? d : e"},
+		{&ConditionalExpression{Test: &ConditionalExpression{Test: id("a"), Consequent: id("b"), Alternate: id("c")}, Consequent: id("d"), Alternate: id("e")}, "(a ? b : c) ? d : e"},
+		{&ConditionalExpression{Test: logical("||", id("a"), id("b")), Consequent: id("c"), Alternate: id("d")}, "a || b ? c : d"},
+		{&ExpressionStatement{Expression: iife}, "(function () {\n})();"},
+		{&ExpressionStatement{Expression: &StringLiteral{Value: "use strict"}}, `("use strict");`},
+		{&ExpressionStatement{Expression: &AssignmentExpression{
+			Operator: "=",
+			Left:     &ObjectPattern{Properties: []Node{&ObjectProperty{Key: id("a"), Value: id("a"), Shorthand: true}}},
+			Right:    id("o"),
+		}}, "({ a } = o);"},
+		{&Program{Body: []Statement{
+			&VariableDeclaration{Kind: "let", Declarations: []*VariableDeclarator{
+				{ID: id("a"), Init: &NumericLiteral{Value: 1}},
+				{ID: id("b")},
+			}},
+			&ForStatement{
+				Init: &VariableDeclaration{Kind: "let", Declarations: []*VariableDeclarator{{ID: id("i")}}},
+				Body: &BlockStatement{Body: []Statement{&ReturnStatement{}}},
+			},
+		}}, "let a = 1, b;\nfor (let i; ; ) {\nreturn;\n}\n"},
+		{&ForOfStatement{
+			Left:  &VariableDeclaration{Kind: "const", Declarations: []*VariableDeclarator{{ID: id("x")}}},
+			Right: &CallExpression{Callee: id("f")},
+			Body:  &BlockStatement{},
+		}, "for (const x of f()) {\n}"},
+		{&DoWhileStatement{
+			Body: &ExpressionStatement{Expression: &CallExpression{Callee: id("f")}},
+			Test: id("x"),
+		}, "do f(); while (x);"},
+	}
+
+	for _, test := range tests {
+		if got := test.node.String(); got != test.want {
+			t.Errorf("want=%q got=%q", test.want, got)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	call := func(attr *Attr, arg Expression) *CallExpression {
+		return &CallExpression{Attr: attr, Callee: &Identifier{Attr: attr, Name: "f"}, Arguments: []Expression{arg}}
+	}
+	a := call(&Attr{Type: "CallExpression", Start: 0}, &NumericLiteral{Value: 16, Extra: &Extra{Raw: "0x10"}})
+	b := call(&Attr{Type: "CallExpression", Start: 10}, &NumericLiteral{Value: 16, Extra: &Extra{Raw: "16"}})
+	c := call(nil, &StringLiteral{Value: "16"})
+
+	if !Equal(a, b) {
+		t.Errorf("%s and %s at different locations aren't equal", a, b)
+	}
+	if Equal(a, c) {
+		t.Errorf("%s and %s are equal", a, c)
+	}
+}
+
+func TestClone(t *testing.T) {
+	// function f(a) {
+	//   switch (a) {
+	//   case 1:
+	//     return [a, , g(a)];
+	//   }
+	// }
+	a := &Identifier{Attr: &Attr{Type: "Identifier", Start: 11}, Name: "a"}
+	ret := &ReturnStatement{Argument: &ArrayExpression{Elements: []Expression{
+		a, nil, &CallExpression{Callee: &Identifier{Name: "g"}, Arguments: []Expression{a}},
+	}}}
+	sc := &SwitchCase{Test: &NumericLiteral{Value: 1, Extra: &Extra{Raw: "1"}}, Consequent: []Statement{ret}}
+	f := &FunctionDeclaration{
+		ID:     &Identifier{Name: "f"},
+		Params: []Expression{a},
+		Body:   &BlockStatement{Body: []Statement{&SwitchStatement{Discriminant: a, Cases: []*SwitchCase{sc}}}},
+	}
+	want := f.String()
+
+	c := Clone(f).(*FunctionDeclaration)
+	if !Equal(f, c) {
+		t.Fatalf("clone %s isn't equal to %s", c, f)
+	}
+
+	c.Params[0].(*Identifier).Name = "b"
+	c.Params[0].(*Identifier).Attr.Start = 0
+	cases := c.Body.Body[0].(*SwitchStatement).Cases
+	cases[0].Test.(*NumericLiteral).Extra.Raw = "2"
+	cases[0].Consequent[0].(*ReturnStatement).Argument.(*ArrayExpression).Elements[1] = &Identifier{Name: "h"}
+	c.Body.Body = append(c.Body.Body, &ReturnStatement{})
+
+	if got := f.String(); got != want {
+		t.Errorf("mutating the clone changed the original: want=%q got=%q", want, got)
+	}
+	if a.Name != "a" || a.Attr.Start != 11 || sc.Test.(*NumericLiteral).Extra.Raw != "1" {
+		t.Errorf("mutating the clone changed the original nodes")
+	}
+	if Clone(nil) != nil {
+		t.Errorf("clone of nil isn't nil")
+	}
+}
+
+func TestFeatureStats(t *testing.T) {
+	// import m from "m"
+	// let n: number = 1
+	// ({ a } = o?.b)
+	// f?.(a).c?.d
+	// export default { get x() { return [...a] } }
+	id := func(name string) *Identifier { return &Identifier{Name: name} }
+	p := &Program{Body: []Statement{
+		&ImportDeclaration{
+			Specifiers: []Node{&ImportDefaultSpecifier{Local: id("m")}},
+			Source:     &StringLiteral{Value: "m"},
+		},
+		&VariableDeclaration{Kind: "let", Declarations: []*VariableDeclarator{{
+			ID:   &Identifier{Name: "n", TypeAnnotation: &TSKeywordType{Keyword: "number"}},
+			Init: &NumericLiteral{Value: 1},
+		}}},
+		&ExpressionStatement{Expression: &AssignmentExpression{
+			Operator: "=",
+			Left:     &ObjectPattern{Properties: []Node{&ObjectProperty{Key: id("a"), Value: id("a"), Shorthand: true}}},
+			Right:    &OptionalMemberExpression{Object: id("o"), Property: id("b"), Optional: true},
+		}},
+		&ExpressionStatement{Expression: &OptionalMemberExpression{
+			Object: &OptionalMemberExpression{
+				Object:   &OptionalCallExpression{Callee: id("f"), Arguments: []Expression{id("a")}, Optional: true},
+				Property: id("c"),
+			},
+			Property: id("d"),
+			Optional: true,
+		}},
+		&ExportDefaultDeclaration{Declaration: &ObjectExpression{Properties: []Node{
+			&ObjectMethod{Kind: "get", Key: id("x"), Body: &BlockStatement{Body: []Statement{
+				&ReturnStatement{Argument: &ArrayExpression{Elements: []Expression{&SpreadElement{Argument: id("a")}}}},
+			}}},
+		}}},
+	}}
+
+	want := map[string]int{
+		"Program":                  1,
+		"ImportDeclaration":        1,
+		"VariableDeclarator":       1,
+		"ExpressionStatement":      2,
+		"ObjectPattern":            1,
+		"OptionalMemberExpression": 3,
+		"OptionalCallExpression":   1,
+		"ObjectMethod":             1,
+		"SpreadElement":            1,
+		"OptionalChaining":         3,
+		"Destructuring":            1,
+		"TypeAnnotations":          1,
+		"Modules":                  2,
+		"Accessors":                1,
+		"ClassDeclaration":         0,
+	}
+	stats := FeatureStats(p)
+	for name, n := range want {
+		if stats[name] != n {
+			t.Errorf("%s: want=%d got=%d", name, n, stats[name])
+		}
+	}
+}
+
+func TestNodeCountAndMaxDepth(t *testing.T) {
+	// function f(a) { return a + 1 }
+	// f(2)
+	id := func(name string) *Identifier { return &Identifier{Name: name} }
+	p := &Program{Body: []Statement{
+		&FunctionDeclaration{ID: id("f"), Params: []Expression{id("a")}, Body: &BlockStatement{Body: []Statement{
+			&ReturnStatement{Argument: &BinaryExpression{Operator: "+", Left: id("a"), Right: &NumericLiteral{Value: 1}}},
+		}}},
+		&ExpressionStatement{Expression: &CallExpression{Callee: id("f"), Arguments: []Expression{&NumericLiteral{Value: 2}}}},
+	}}
+
+	if got, want := NodeCount(p), 13; got != want {
+		t.Errorf("node count: want=%d got=%d", want, got)
+	}
+	// Program > FunctionDeclaration > BlockStatement > ReturnStatement >
+	// BinaryExpression > Identifier
+	if got, want := MaxDepth(p), 6; got != want {
+		t.Errorf("max depth: want=%d got=%d", want, got)
+	}
+	if got := MaxDepth(id("a")); got != 1 {
+		t.Errorf("max depth of a leaf: want=1 got=%d", got)
+	}
+}
@@ -1,8 +1,66 @@
 package ast
 
+import "fmt"
+
+// unmarshalError is a malformed AST JSON, which the unmarshal helpers panic
+// with and UnmarshalJSON and UnmarshalStatement return.
+type unmarshalError struct {
+	msg string
+}
+
+func (e *unmarshalError) Error() string {
+	return "ast: " + e.msg
+}
+
+func unmarshalErrorf(format string, args ...interface{}) *unmarshalError {
+	return &unmarshalError{fmt.Sprintf(format, args...)}
+}
+
+// recoverUnmarshal stores the unmarshal error the unmarshal helpers panicked
+// with in err, if any.
+func recoverUnmarshal(err *error) {
+	if r := recover(); r != nil {
+		ue, ok := r.(*unmarshalError)
+		if !ok {
+			panic(r)
+		}
+
+		*err = ue
+	}
+}
+
+// jsonType returns the JSON type of a decoded JSON value
+func jsonType(i interface{}) string {
+	switch i.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+
+	return fmt.Sprintf("%T", i)
+}
+
+func convertSlice(i interface{}) []interface{} {
+	s, ok := i.([]interface{})
+	if !ok {
+		panic(unmarshalErrorf("expected array, got %s", jsonType(i)))
+	}
+
+	return s
+}
+
 func convertSliceMap(i interface{}) []m {
 	var m []m
-	for _, mm := range i.([]interface{}) {
+	for _, mm := range convertSlice(i) {
 		m = append(m, convertMap(mm))
 	}
 
@@ -10,21 +68,41 @@ func convertSliceMap(i interface{}) []m {
 }
 
 func convertMap(i interface{}) m {
-	return m(i.(map[string]interface{}))
+	mm, ok := i.(map[string]interface{})
+	if !ok {
+		panic(unmarshalErrorf("expected object, got %s", jsonType(i)))
+	}
+
+	return m(mm)
 }
 
 func convertString(i interface{}) string {
-	return i.(string)
+	s, ok := i.(string)
+	if !ok {
+		panic(unmarshalErrorf("expected string, got %s", jsonType(i)))
+	}
+
+	return s
 }
 
 func convertInt(i interface{}) int {
-	return int(i.(float64))
+	return int(convertFloat(i))
 }
 
 func convertFloat(i interface{}) float64 {
-	return i.(float64)
+	f, ok := i.(float64)
+	if !ok {
+		panic(unmarshalErrorf("expected number, got %s", jsonType(i)))
+	}
+
+	return f
 }
 
 func convertBool(i interface{}) bool {
-	return i.(bool)
+	b, ok := i.(bool)
+	if !ok {
+		panic(unmarshalErrorf("expected boolean, got %s", jsonType(i)))
+	}
+
+	return b
 }
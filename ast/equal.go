@@ -0,0 +1,55 @@
+package ast
+
+import (
+	"reflect"
+)
+
+var (
+	attrType  = reflect.TypeOf(&Attr{})
+	extraType = reflect.TypeOf(&Extra{})
+)
+
+// Equal tells whether the nodes a and b have the same structure and values,
+// regardless of their location and raw source.
+func Equal(a, b Node) bool {
+	return equalValues(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+func equalValues(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equalValues(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if t := a.Type().Field(i).Type; t == attrType || t == extraType {
+				continue
+			}
+			if !equalValues(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalValues(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a.Interface() == b.Interface()
+}
@@ -0,0 +1,80 @@
+package ast
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// fuzzSeeds are the AST JSON of valid files, for
+// `console.log("hi")`, `import a from "./m"` and `[1, , 3]`
+func fuzzSeeds() []string {
+	id := func(name string) string {
+		return `{"type":"Identifier",` + loc + `,"name":"` + name + `"}`
+	}
+	str := func(value string) string {
+		return `{"type":"StringLiteral",` + loc + `,"extra":{"rawValue":"` + value + `","raw":"'` + value + `'"},"value":"` + value + `"}`
+	}
+	num := func(value string) string {
+		return `{"type":"NumericLiteral",` + loc + `,"extra":{"rawValue":` + value + `,"raw":"` + value + `"},"value":` + value + `}`
+	}
+	file := func(body string) string {
+		return `{"type":"File",` + loc + `,"program":{"type":"Program",` + loc + `,"sourceType":"module","body":[` + body + `],"directives":[]}}`
+	}
+
+	return []string{
+		file(``),
+		file(`{"type":"ExpressionStatement",` + loc + `,"expression":{"type":"CallExpression",` + loc +
+			`,"callee":{"type":"MemberExpression",` + loc + `,"object":` + id("console") + `,"property":` + id("log") + `,"computed":false}` +
+			`,"arguments":[` + str("hi") + `]}}`),
+		file(`{"type":"ImportDeclaration",` + loc + `,"specifiers":[{"type":"ImportDefaultSpecifier",` + loc + `,"local":` + id("a") + `}],"source":` + str("./m") + `}`),
+		file(`{"type":"ExpressionStatement",` + loc + `,"expression":{"type":"ArrayExpression",` + loc +
+			`,"elements":[` + num("1") + `,null,` + num("3") + `]}}`),
+	}
+}
+
+func TestUnmarshalJSON_Malformed(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`{"type":"File"}`, "ast: expected number, got null"},
+		{`{"type":"File",` + loc + `,"program":[]}`, "ast: expected object, got array"},
+		{`[]`, "json: cannot unmarshal array into Go value of type map[string]interface {}"},
+	}
+
+	for _, test := range tests {
+		err := json.Unmarshal([]byte(test.json), &File{})
+		if err == nil || err.Error() != test.want {
+			t.Errorf("%s: want error %q, got %v", test.json, test.want, err)
+		}
+	}
+
+	if _, err := UnmarshalStatement([]byte(`{"type":"Nope",` + loc + `}`)); err == nil || err.Error() != "ast: unsupported statement type Nope" {
+		t.Errorf("want an unsupported statement error, got %v", err)
+	}
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// malformed JSON must be an error, not a panic
+		json.Unmarshal(data, &File{})
+		UnmarshalStatement(data)
+	})
+}
+
+func TestClone_Unmarshaled(t *testing.T) {
+	for _, seed := range fuzzSeeds() {
+		f := &File{}
+		if err := json.Unmarshal([]byte(seed), f); err != nil {
+			t.Fatalf("unmarshal has error: %s", err)
+		}
+
+		if c := Clone(f); !Equal(f, c) || c.String() != f.String() {
+			t.Errorf("clone %q isn't equal to %q", c, f)
+		}
+	}
+}
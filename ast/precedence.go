@@ -0,0 +1,114 @@
+package ast
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// precedences of the expressions which aren't binary, from the loosest to
+// the tightest binding
+const (
+	sequencePrecedence    = 0
+	assignmentPrecedence  = 1
+	conditionalPrecedence = 2
+	// logical and binary operators range in between
+	prefixPrecedence  = 14
+	postfixPrecedence = 15
+	callPrecedence    = 16
+	primaryPrecedence = 17
+)
+
+var logicalPrecedence = map[LogicalOperator]int{
+	"??": 3,
+	"||": 3,
+	"&&": 4,
+}
+
+var binaryPrecedence = map[BinaryOperator]int{
+	"|":          5,
+	"^":          6,
+	"&":          7,
+	"==":         8,
+	"!=":         8,
+	"===":        8,
+	"!==":        8,
+	"<":          9,
+	"<=":         9,
+	">":          9,
+	">=":         9,
+	"in":         9,
+	"instanceof": 9,
+	"<<":         10,
+	">>":         10,
+	">>>":        10,
+	"+":          11,
+	"-":          11,
+	"*":          12,
+	"/":          12,
+	"%":          12,
+	"**":         13,
+}
+
+// precedence returns how tightly e binds as the operand of another
+// expression
+func precedence(e Expression) int {
+	switch v := e.(type) {
+	case *SequenceExpression:
+		return sequencePrecedence
+	case *AssignmentExpression, *AssignmentPattern, *ArrowFunctionExpression:
+		return assignmentPrecedence
+	case *ConditionalExpression:
+		return conditionalPrecedence
+	case *LogicalExpression:
+		return logicalPrecedence[v.Operator]
+	case *BinaryExpression:
+		return binaryPrecedence[v.Operator]
+	case *UnaryExpression:
+		return prefixPrecedence
+	case *UpdateExpression:
+		if v.Prefix {
+			return prefixPrecedence
+		}
+		return postfixPrecedence
+	case *CallExpression, *MemberExpression, *OptionalMemberExpression, *OptionalCallExpression:
+		return callPrecedence
+	}
+
+	return primaryPrecedence
+}
+
+// operand returns the source of e as an operand which must bind at least as
+// tightly as min, parenthesized when it doesn't
+func operand(e Expression, min int) string {
+	if precedence(e) < min {
+		return "(" + e.String() + ")"
+	}
+
+	return e.String()
+}
+
+// calleeOperand returns the source of e as a callee or the object of a
+// member, parenthesizing function expressions and numbers which would
+// otherwise read as a declaration or a decimal point
+func calleeOperand(e Expression) string {
+	switch e.(type) {
+	case *FunctionExpression, *NumericLiteral:
+		return "(" + e.String() + ")"
+	}
+
+	return operand(e, callPrecedence)
+}
+
+// formatNumber returns the shortest JavaScript literal of f, which reads
+// back as f.
+func formatNumber(f float64) string {
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		s := strconv.FormatFloat(f, 'e', -1, 64)
+		i := strings.IndexByte(s, 'e')
+		exp, _ := strconv.Atoi(s[i+1:])
+		return s[:i] + "e" + strconv.Itoa(exp)
+	}
+
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
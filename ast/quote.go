@@ -0,0 +1,175 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// quote returns s as a double-quoted JavaScript string literal.
+func quote(s string) string {
+	var out bytes.Buffer
+
+	out.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '\b':
+			out.WriteString(`\b`)
+		case '\f':
+			out.WriteString(`\f`)
+		case '\v':
+			out.WriteString(`\v`)
+		case '\u2028', '\u2029':
+			// line terminators in JavaScript
+			out.WriteString(fmt.Sprintf(`\u%04x`, r))
+		default:
+			if r < 0x20 || r == 0x7f {
+				out.WriteString(fmt.Sprintf(`\x%02x`, r))
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	}
+	out.WriteByte('"')
+
+	return out.String()
+}
+
+// unquote decodes the raw source of a JavaScript string literal. Unlike the
+// value decoded from JSON, which can't represent lone surrogates, it
+// combines surrogate pairs into a single rune and keeps lone surrogates as
+// their WTF-8 bytes.
+func unquote(raw string) (string, error) {
+	if len(raw) < 2 || (raw[0] != '"' && raw[0] != '\'') || raw[len(raw)-1] != raw[0] {
+		return "", fmt.Errorf("invalid string literal %s", raw)
+	}
+
+	rs := []rune(raw[1 : len(raw)-1])
+	var units []rune
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		if r != '\\' {
+			units = append(units, r)
+			continue
+		}
+
+		i++
+		if i == len(rs) {
+			return "", fmt.Errorf("invalid escape in string literal %s", raw)
+		}
+
+		switch r = rs[i]; r {
+		case 'n':
+			units = append(units, '\n')
+		case 'r':
+			units = append(units, '\r')
+		case 't':
+			units = append(units, '\t')
+		case 'b':
+			units = append(units, '\b')
+		case 'f':
+			units = append(units, '\f')
+		case 'v':
+			units = append(units, '\v')
+		case '\r':
+			// line continuation
+			if i+1 < len(rs) && rs[i+1] == '\n' {
+				i++
+			}
+		case '\n', '\u2028', '\u2029':
+			// line continuation
+		case 'x':
+			v, n, err := parseHex(rs[i+1:], 2)
+			if err != nil {
+				return "", err
+			}
+			units = append(units, v)
+			i += n
+		case 'u':
+			if i+1 < len(rs) && rs[i+1] == '{' {
+				end := i + 2
+				for end < len(rs) && rs[end] != '}' {
+					end++
+				}
+				if end == len(rs) {
+					return "", fmt.Errorf("invalid unicode escape in string literal %s", raw)
+				}
+				v, _, err := parseHex(rs[i+2:end], end-i-2)
+				if err != nil {
+					return "", err
+				}
+				units = append(units, v)
+				i = end
+			} else {
+				v, n, err := parseHex(rs[i+1:], 4)
+				if err != nil {
+					return "", err
+				}
+				units = append(units, v)
+				i += n
+			}
+		default:
+			if r >= '0' && r <= '7' {
+				// \0 and legacy octal escapes
+				v := r - '0'
+				for n := 1; n < 3 && i+1 < len(rs) && rs[i+1] >= '0' && rs[i+1] <= '7' && v*8+rs[i+1]-'0' <= 0377; n++ {
+					i++
+					v = v*8 + rs[i] - '0'
+				}
+				units = append(units, v)
+			} else {
+				units = append(units, r)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	for i := 0; i < len(units); i++ {
+		r := units[i]
+		if utf16.IsSurrogate(r) {
+			if i+1 < len(units) {
+				if rr := utf16.DecodeRune(r, units[i+1]); rr != utf8.RuneError {
+					out.WriteRune(rr)
+					i++
+					continue
+				}
+			}
+
+			// WTF-8 encoding of the lone surrogate
+			out.WriteByte(byte(0xe0 | r>>12))
+			out.WriteByte(byte(0x80 | (r>>6)&0x3f))
+			out.WriteByte(byte(0x80 | r&0x3f))
+			continue
+		}
+
+		out.WriteRune(r)
+	}
+
+	return out.String(), nil
+}
+
+func parseHex(rs []rune, n int) (rune, int, error) {
+	if n == 0 || len(rs) < n {
+		return 0, 0, fmt.Errorf("invalid hex escape %s", string(rs))
+	}
+
+	v, err := strconv.ParseUint(string(rs[:n]), 16, 32)
+	if err != nil || v > unicode.MaxRune {
+		return 0, 0, fmt.Errorf("invalid hex escape %s", string(rs[:n]))
+	}
+
+	return rune(v), n, nil
+}
@@ -0,0 +1,508 @@
+package ast
+
+// DeclaredVariables returns the names bound in the scope node creates, in
+// declaration order. Functions bind their parameters, the vars declared
+// anywhere in their body and the let, const, function and class
+// declarations at the top of their body, and function expressions their
+// name too. Blocks only bind their let, const, function and class
+// declarations, switch statements the ones of their cases, for loops the
+// let and const declarations of their init and for...of loops the one of
+// their loop variable. For a variable declaration, the declared names are returned.
+func DeclaredVariables(node Node) []string {
+	var names nameList
+
+	switch n := node.(type) {
+	case *File:
+		if n.Program != nil {
+			return DeclaredVariables(n.Program)
+		}
+	case *Program:
+		names.addFunctionScope(nil, n.Body)
+	case *FunctionDeclaration:
+		names.addFunctionScope(n.Params, n.Body.Body)
+	case *FunctionExpression:
+		if n.ID != nil {
+			names.add(n.ID.Name)
+		}
+		names.addFunctionScope(n.Params, n.Body.Body)
+	case *ObjectMethod:
+		names.addFunctionScope(n.Params, n.Body.Body)
+	case *ArrowFunctionExpression:
+		names.addFunctionScope(n.Params, arrowBody(n))
+	case *BlockStatement:
+		names.addBlockScope(n.Body)
+	case *SwitchStatement:
+		for _, c := range n.Cases {
+			names.addBlockScope(c.Consequent)
+		}
+	case *ForStatement:
+		if vd, ok := n.Init.(*VariableDeclaration); ok && vd.Kind != "var" {
+			names.addDeclaration(vd)
+		}
+	case *ForOfStatement:
+		if vd, ok := n.Left.(*VariableDeclaration); ok && vd.Kind != "var" {
+			names.addDeclaration(vd)
+		}
+	case *VariableDeclaration:
+		names.addDeclaration(n)
+	}
+
+	return names
+}
+
+// FreeVariables returns the names referenced in node that aren't bound by
+// any scope within node, in order of first reference.
+func FreeVariables(node Node) []string {
+	r := &resolver{seen: make(map[string]bool)}
+	Walk(r, node)
+
+	return r.free
+}
+
+// TDZReferences returns the references made by the statements of body, a
+// block, to the let, const and class declarations of the block ahead of
+// them, e.g. `x` in `f(x); let x = 1`, which throw a ReferenceError as the
+// variables are in their temporal dead zone. The bodies of nested functions
+// and the initializers of instance fields aren't evaluated ahead of the
+// declarations, their references are left out.
+func TDZReferences(body []Statement) []*Identifier {
+	// pending are the lexical names declared by the statements left
+	pending := make(map[string]int)
+	declare := func(s Statement, n int) {
+		switch v := s.(type) {
+		case *VariableDeclaration:
+			if v.Kind != "var" {
+				for _, d := range v.Declarations {
+					for _, id := range BoundIdentifiers(d.ID) {
+						pending[id.Name] += n
+					}
+				}
+			}
+		case *ClassDeclaration:
+			if v.ID != nil {
+				pending[v.ID.Name] += n
+			}
+		}
+	}
+	for _, s := range body {
+		declare(s, 1)
+	}
+
+	var refs []*Identifier
+	collect := func(node Node) {
+		r := &resolver{seen: make(map[string]bool), evaluated: true}
+		Walk(r, node)
+		for _, id := range r.refs {
+			if pending[id.Name] > 0 {
+				refs = append(refs, id)
+			}
+		}
+	}
+	for _, s := range body {
+		switch v := s.(type) {
+		case *VariableDeclaration:
+			if v.Kind == "var" {
+				collect(v)
+				continue
+			}
+
+			// each declarator is initialized in turn
+			for _, d := range v.Declarations {
+				if d.Init != nil {
+					collect(d.Init)
+				}
+				for _, id := range BoundIdentifiers(d.ID) {
+					pending[id.Name]--
+				}
+			}
+		case *ClassDeclaration:
+			// the class is bound ahead of its static fields
+			declare(v, -1)
+			collect(v)
+		case *ExportNamedDeclaration:
+			// export specifiers don't evaluate the names they export
+			if v.Declaration != nil {
+				collect(v)
+			}
+		default:
+			collect(s)
+		}
+	}
+
+	return refs
+}
+
+// BoundIdentifiers returns the identifiers target, the binding of a
+// declarator or a parameter, binds: target itself when it's an identifier,
+// or the targets of the elements and properties of a destructuring pattern,
+// in order, e.g. b and c for `{a: [b, c = 1]}`.
+func BoundIdentifiers(target Expression) []*Identifier {
+	var ids []*Identifier
+	switch t := target.(type) {
+	case *Identifier:
+		ids = append(ids, t)
+	case *ArrayPattern:
+		for _, e := range t.Elements {
+			if e != nil {
+				ids = append(ids, BoundIdentifiers(e)...)
+			}
+		}
+	case *ObjectPattern:
+		for _, p := range t.Properties {
+			if op, ok := p.(*ObjectProperty); ok {
+				ids = append(ids, BoundIdentifiers(op.Value)...)
+			}
+		}
+	case *AssignmentPattern:
+		ids = append(ids, BoundIdentifiers(t.Left)...)
+	}
+
+	return ids
+}
+
+// HoistedVariables returns the names of the vars declared in node, outside of
+// nested functions, which JavaScript hoists to the top of the enclosing
+// function.
+func HoistedVariables(node Node) []string {
+	var names nameList
+	names.addHoistedVars(node)
+
+	return names
+}
+
+type nameList []string
+
+func (l *nameList) add(name string) {
+	for _, n := range *l {
+		if n == name {
+			return
+		}
+	}
+
+	*l = append(*l, name)
+}
+
+func (l *nameList) addDeclaration(vd *VariableDeclaration) {
+	for _, d := range vd.Declarations {
+		l.addBinding(d.ID)
+	}
+}
+
+// addBinding adds the names target binds
+func (l *nameList) addBinding(target Expression) {
+	for _, id := range BoundIdentifiers(target) {
+		l.add(id.Name)
+	}
+}
+
+func (l *nameList) addFunctionScope(params []Expression, body []Statement) {
+	for _, p := range params {
+		l.addBinding(p)
+	}
+
+	for _, s := range body {
+		switch v := s.(type) {
+		case *ImportDeclaration:
+			for _, spec := range v.Specifiers {
+				switch sv := spec.(type) {
+				case *ImportDefaultSpecifier:
+					l.add(sv.Local.Name)
+				case *ImportSpecifier:
+					l.add(sv.Local.Name)
+				case *ImportNamespaceSpecifier:
+					l.add(sv.Local.Name)
+				}
+			}
+		case *ExportNamedDeclaration:
+			if v.Declaration != nil {
+				l.addFunctionScope(nil, []Statement{v.Declaration})
+			}
+		case *ExportDefaultDeclaration:
+			switch d := v.Declaration.(type) {
+			case *FunctionDeclaration:
+				if d.ID != nil {
+					l.add(d.ID.Name)
+				}
+			case *ClassDeclaration:
+				if d.ID != nil {
+					l.add(d.ID.Name)
+				}
+			}
+		case *VariableDeclaration:
+			l.addDeclaration(v)
+		case *FunctionDeclaration:
+			l.add(v.ID.Name)
+		case *ClassDeclaration:
+			l.add(v.ID.Name)
+		default:
+			l.addHoistedVars(s)
+		}
+	}
+}
+
+func (l *nameList) addBlockScope(body []Statement) {
+	for _, s := range body {
+		switch v := s.(type) {
+		case *VariableDeclaration:
+			if v.Kind != "var" {
+				l.addDeclaration(v)
+			}
+		case *FunctionDeclaration:
+			l.add(v.ID.Name)
+		case *ClassDeclaration:
+			l.add(v.ID.Name)
+		}
+	}
+}
+
+// addHoistedVars adds the vars declared in node, outside of nested
+// functions
+func (l *nameList) addHoistedVars(node Node) {
+	Inspect(node, func(n Node) bool {
+		switch v := n.(type) {
+		case *FunctionDeclaration, *FunctionExpression, *ObjectMethod, *ArrowFunctionExpression:
+			return false
+		case *VariableDeclaration:
+			if v.Kind == "var" {
+				l.addDeclaration(v)
+			}
+		}
+
+		return true
+	})
+}
+
+// arrowBody returns the statements of the body of a, none for a concise body
+func arrowBody(a *ArrowFunctionExpression) []Statement {
+	if bs, ok := a.Body.(*BlockStatement); ok {
+		return bs.Body
+	}
+
+	return nil
+}
+
+type varScope struct {
+	parent *varScope
+	names  map[string]bool
+}
+
+// resolver resolves identifier references against the scopes they appear
+// in, collecting the unresolved ones
+type resolver struct {
+	scope *varScope
+	free  []string
+	seen  map[string]bool
+	// refs are the unresolved references
+	refs []*Identifier
+	// evaluated skips the code which isn't evaluated along with the node
+	// walked: the bodies of functions and the values of instance fields
+	evaluated bool
+}
+
+func (r *resolver) Visit(node Node) Visitor {
+	if r.evaluated {
+		switch n := node.(type) {
+		case *FunctionDeclaration, *FunctionExpression, *ArrowFunctionExpression:
+			return nil
+		case *ObjectMethod:
+			if n.Computed {
+				Walk(r, n.Key)
+			}
+			return nil
+		case *ClassProperty:
+			if n.Computed {
+				Walk(r, n.Key)
+			}
+			if n.Value != nil && n.Static {
+				Walk(r, n.Value)
+			}
+			return nil
+		}
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		r.walkScope(n, func() { walkStatements(r, n.Body) })
+		return nil
+	case *FunctionDeclaration:
+		r.walkScope(n, func() {
+			r.walkBindings(n.Params)
+			walkStatements(r, n.Body.Body)
+		})
+		return nil
+	case *FunctionExpression:
+		r.walkScope(n, func() {
+			r.walkBindings(n.Params)
+			walkStatements(r, n.Body.Body)
+		})
+		return nil
+	case *ArrowFunctionExpression:
+		r.walkScope(n, func() {
+			r.walkBindings(n.Params)
+			if body, ok := n.Body.(Expression); ok {
+				Walk(r, body)
+			} else {
+				walkStatements(r, arrowBody(n))
+			}
+		})
+		return nil
+	case *BlockStatement:
+		r.walkScope(n, func() { walkStatements(r, n.Body) })
+		return nil
+	case *SwitchStatement:
+		Walk(r, n.Discriminant)
+		r.walkScope(n, func() {
+			for _, c := range n.Cases {
+				Walk(r, c)
+			}
+		})
+		return nil
+	case *ForStatement:
+		r.walkScope(n, func() {
+			for _, c := range []Node{n.Init, n.Test, n.Update} {
+				if c != nil {
+					Walk(r, c)
+				}
+			}
+			Walk(r, n.Body)
+		})
+		return nil
+	case *ForOfStatement:
+		// the iterable is evaluated in the scope of the loop variable, where
+		// it's in its temporal dead zone
+		r.walkScope(n, func() {
+			Walk(r, n.Left)
+			Walk(r, n.Right)
+			Walk(r, n.Body)
+		})
+		return nil
+	case *VariableDeclarator:
+		r.walkBinding(n.ID)
+		if n.Init != nil {
+			Walk(r, n.Init)
+		}
+		return nil
+	case *ExportSpecifier:
+		Walk(r, n.Local)
+		return nil
+	case *ExportNamedDeclaration:
+		// re-exported names aren't references to the module's names
+		if n.Source != nil {
+			return nil
+		}
+	case *ImportDeclaration:
+		return nil
+	case *LabeledStatement:
+		// labels aren't variable references
+		Walk(r, n.Body)
+		return nil
+	case *BreakStatement, *ContinueStatement:
+		return nil
+	case *MemberExpression:
+		Walk(r, n.Object)
+		if n.Computed {
+			Walk(r, n.Property)
+		}
+		return nil
+	case *ObjectProperty:
+		if n.Computed {
+			Walk(r, n.Key)
+		}
+		Walk(r, n.Value)
+		return nil
+	case *ObjectMethod:
+		if n.Computed {
+			Walk(r, n.Key)
+		}
+		r.walkScope(n, func() {
+			r.walkBindings(n.Params)
+			walkStatements(r, n.Body.Body)
+		})
+		return nil
+	case *ClassDeclaration:
+		if n.SuperClass != nil {
+			Walk(r, n.SuperClass)
+		}
+		Walk(r, n.Body)
+		return nil
+	case *ClassProperty:
+		if n.Computed {
+			Walk(r, n.Key)
+		}
+		if n.Value != nil {
+			Walk(r, n.Value)
+		}
+		return nil
+	case *OptionalMemberExpression:
+		Walk(r, n.Object)
+		if n.Computed {
+			Walk(r, n.Property)
+		}
+		return nil
+	case *Identifier:
+		if r.isBound(n.Name) {
+			return nil
+		}
+		r.refs = append(r.refs, n)
+		if !r.seen[n.Name] {
+			r.seen[n.Name] = true
+			r.free = append(r.free, n.Name)
+		}
+		return nil
+	}
+
+	return r
+}
+
+// walkBinding walks the defaults and the computed keys of target, the
+// binding of a declarator or a parameter, leaving out the names it binds
+func (r *resolver) walkBinding(target Expression) {
+	switch t := target.(type) {
+	case *ArrayPattern:
+		for _, e := range t.Elements {
+			if e != nil {
+				r.walkBinding(e)
+			}
+		}
+	case *ObjectPattern:
+		for _, p := range t.Properties {
+			if op, ok := p.(*ObjectProperty); ok {
+				if op.Computed {
+					Walk(r, op.Key)
+				}
+				r.walkBinding(op.Value)
+			}
+		}
+	case *AssignmentPattern:
+		r.walkBinding(t.Left)
+		Walk(r, t.Right)
+	}
+}
+
+func (r *resolver) walkBindings(targets []Expression) {
+	for _, t := range targets {
+		r.walkBinding(t)
+	}
+}
+
+// walkScope walks the children of node with walk, in the scope node creates
+func (r *resolver) walkScope(node Node, walk func()) {
+	s := &varScope{parent: r.scope, names: make(map[string]bool)}
+	for _, name := range DeclaredVariables(node) {
+		s.names[name] = true
+	}
+
+	r.scope = s
+	walk()
+	r.scope = s.parent
+}
+
+func (r *resolver) isBound(name string) bool {
+	for s := r.scope; s != nil; s = s.parent {
+		if s.names[name] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,246 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFreeVariables_Closure(t *testing.T) {
+	// function outer(a) {
+	//   let x = 1
+	//   function inner() {
+	//     return console.log(x, a, y)
+	//   }
+	// }
+	inner := &FunctionDeclaration{
+		ID: &Identifier{Name: "inner"},
+		Body: &BlockStatement{Body: []Statement{
+			&ReturnStatement{Argument: &CallExpression{
+				Callee: &MemberExpression{
+					Object:   &Identifier{Name: "console"},
+					Property: &Identifier{Name: "log"},
+				},
+				Arguments: []Expression{
+					&Identifier{Name: "x"},
+					&Identifier{Name: "a"},
+					&Identifier{Name: "y"},
+				},
+			}},
+		}},
+	}
+	outer := &FunctionDeclaration{
+		ID:     &Identifier{Name: "outer"},
+		Params: []Expression{&Identifier{Name: "a"}},
+		Body: &BlockStatement{Body: []Statement{
+			&VariableDeclaration{
+				Kind: "let",
+				Declarations: []*VariableDeclarator{
+					{ID: &Identifier{Name: "x"}, Init: &NumericLiteral{Value: 1}},
+				},
+			},
+			inner,
+		}},
+	}
+
+	if want, got := []string{"console", "x", "a", "y"}, FreeVariables(inner); !reflect.DeepEqual(want, got) {
+		t.Fatalf("free variables of inner: want=%v got=%v", want, got)
+	}
+	if want, got := []string{"console", "y"}, FreeVariables(outer); !reflect.DeepEqual(want, got) {
+		t.Fatalf("free variables of outer: want=%v got=%v", want, got)
+	}
+	if want, got := []string{"a", "x", "inner"}, DeclaredVariables(outer); !reflect.DeepEqual(want, got) {
+		t.Fatalf("declared variables of outer: want=%v got=%v", want, got)
+	}
+	if got := DeclaredVariables(inner); len(got) != 0 {
+		t.Fatalf("declared variables of inner: want none got=%v", got)
+	}
+}
+
+func TestFreeVariables_BlockScoping(t *testing.T) {
+	// {
+	//   let l = 1
+	//   var v = 2
+	// }
+	// l + v
+	p := &Program{Body: []Statement{
+		&BlockStatement{Body: []Statement{
+			&VariableDeclaration{
+				Kind:         "let",
+				Declarations: []*VariableDeclarator{{ID: &Identifier{Name: "l"}, Init: &NumericLiteral{Value: 1}}},
+			},
+			&VariableDeclaration{
+				Kind:         "var",
+				Declarations: []*VariableDeclarator{{ID: &Identifier{Name: "v"}, Init: &NumericLiteral{Value: 2}}},
+			},
+		}},
+		&ExpressionStatement{Expression: &BinaryExpression{
+			Operator: "+",
+			Left:     &Identifier{Name: "l"},
+			Right:    &Identifier{Name: "v"},
+		}},
+	}}
+
+	if want, got := []string{"v"}, DeclaredVariables(p); !reflect.DeepEqual(want, got) {
+		t.Fatalf("declared variables: want=%v got=%v", want, got)
+	}
+	if want, got := []string{"l"}, FreeVariables(p); !reflect.DeepEqual(want, got) {
+		t.Fatalf("free variables: want=%v got=%v", want, got)
+	}
+}
+
+func TestFreeVariables_Switch(t *testing.T) {
+	// switch (x) {
+	// case 1:
+	//   let x = 2
+	// default:
+	//   x
+	// }
+	x := &Identifier{Name: "x"}
+	s := &SwitchStatement{Discriminant: x, Cases: []*SwitchCase{
+		{Test: &NumericLiteral{Value: 1}, Consequent: []Statement{
+			&VariableDeclaration{Kind: "let", Declarations: []*VariableDeclarator{{ID: x, Init: &NumericLiteral{Value: 2}}}},
+		}},
+		{Consequent: []Statement{&ExpressionStatement{Expression: x}}},
+	}}
+
+	if want, got := []string{"x"}, DeclaredVariables(s); !reflect.DeepEqual(want, got) {
+		t.Fatalf("declared variables: want=%v got=%v", want, got)
+	}
+	// the discriminant is outside of the scope of the cases
+	if want, got := []string{"x"}, FreeVariables(s); !reflect.DeepEqual(want, got) {
+		t.Fatalf("free variables: want=%v got=%v", want, got)
+	}
+	s.Discriminant = &Identifier{Name: "y"}
+	if want, got := []string{"y"}, FreeVariables(s); !reflect.DeepEqual(want, got) {
+		t.Fatalf("free variables: want=%v got=%v", want, got)
+	}
+}
+
+func TestHoistedVariables(t *testing.T) {
+	// for (var i = 0; ; ) {
+	//   let x
+	//   var y
+	//   function f() { var z }
+	// }
+	decl := func(kind, name string) *VariableDeclaration {
+		return &VariableDeclaration{Kind: kind, Declarations: []*VariableDeclarator{{ID: &Identifier{Name: name}}}}
+	}
+	loop := &ForStatement{
+		Init: decl("var", "i"),
+		Body: &BlockStatement{Body: []Statement{
+			decl("let", "x"),
+			decl("var", "y"),
+			&FunctionDeclaration{
+				ID:   &Identifier{Name: "f"},
+				Body: &BlockStatement{Body: []Statement{decl("var", "z")}},
+			},
+		}},
+	}
+
+	if want, got := []string{"i", "y"}, HoistedVariables(loop); !reflect.DeepEqual(want, got) {
+		t.Fatalf("hoisted variables of loop: want=%v got=%v", want, got)
+	}
+}
+
+func TestFreeVariables_FunctionExpression(t *testing.T) {
+	// (function f() {
+	//   var x
+	//   return f(x, y)
+	// })()
+	fe := &FunctionExpression{
+		ID: &Identifier{Name: "f"},
+		Body: &BlockStatement{Body: []Statement{
+			&VariableDeclaration{Kind: "var", Declarations: []*VariableDeclarator{{ID: &Identifier{Name: "x"}}}},
+			&ReturnStatement{Argument: &CallExpression{
+				Callee:    &Identifier{Name: "f"},
+				Arguments: []Expression{&Identifier{Name: "x"}, &Identifier{Name: "y"}},
+			}},
+		}},
+	}
+	s := &ExpressionStatement{Expression: &CallExpression{Callee: fe}}
+
+	if want, got := []string{"y"}, FreeVariables(s); !reflect.DeepEqual(want, got) {
+		t.Fatalf("free variables: want=%v got=%v", want, got)
+	}
+	if got := HoistedVariables(s); len(got) != 0 {
+		t.Fatalf("hoisted variables: want none got=%v", got)
+	}
+}
+
+func TestFreeVariables_Patterns(t *testing.T) {
+	// function f({a: [b, c = d]}, [e = b]) {
+	//   const {[k]: g} = h
+	//   return c + e + g
+	// }
+	id := func(name string) *Identifier { return &Identifier{Name: name} }
+	fd := &FunctionDeclaration{
+		ID: id("f"),
+		Params: []Expression{
+			&ObjectPattern{Properties: []Node{&ObjectProperty{Key: id("a"), Value: &ArrayPattern{Elements: []Expression{
+				id("b"), &AssignmentPattern{Left: id("c"), Right: id("d")},
+			}}}}},
+			&ArrayPattern{Elements: []Expression{&AssignmentPattern{Left: id("e"), Right: id("b")}}},
+		},
+		Body: &BlockStatement{Body: []Statement{
+			&VariableDeclaration{Kind: "const", Declarations: []*VariableDeclarator{{
+				ID:   &ObjectPattern{Properties: []Node{&ObjectProperty{Key: id("k"), Value: id("g"), Computed: true}}},
+				Init: id("h"),
+			}}},
+			&ReturnStatement{Argument: &BinaryExpression{
+				Operator: "+",
+				Left:     &BinaryExpression{Operator: "+", Left: id("c"), Right: id("e")},
+				Right:    id("g"),
+			}},
+		}},
+	}
+
+	if want, got := []string{"b", "c", "e", "g"}, DeclaredVariables(fd); !reflect.DeepEqual(want, got) {
+		t.Fatalf("declared variables: want=%v got=%v", want, got)
+	}
+	if want, got := []string{"d", "k", "h"}, FreeVariables(fd); !reflect.DeepEqual(want, got) {
+		t.Fatalf("free variables: want=%v got=%v", want, got)
+	}
+}
+
+func TestTDZReferences(t *testing.T) {
+	// export { x }
+	// f(x, y)
+	// let x = 1, z = z
+	// function g() { return x }
+	// {
+	//   let y
+	//   y
+	// }
+	// const y = x
+	x, y, z := &Identifier{Name: "x"}, &Identifier{Name: "y"}, &Identifier{Name: "z"}
+	body := []Statement{
+		&ExportNamedDeclaration{Specifiers: []*ExportSpecifier{
+			{Local: &Identifier{Name: "x"}, Exported: &Identifier{Name: "x"}},
+		}},
+		&ExpressionStatement{Expression: &CallExpression{
+			Callee:    &Identifier{Name: "f"},
+			Arguments: []Expression{x, y},
+		}},
+		&VariableDeclaration{Kind: "let", Declarations: []*VariableDeclarator{
+			{ID: &Identifier{Name: "x"}, Init: &NumericLiteral{Value: 1}},
+			{ID: &Identifier{Name: "z"}, Init: z},
+		}},
+		&FunctionDeclaration{
+			ID: &Identifier{Name: "g"},
+			Body: &BlockStatement{Body: []Statement{
+				&ReturnStatement{Argument: &Identifier{Name: "x"}},
+			}},
+		},
+		&BlockStatement{Body: []Statement{
+			&VariableDeclaration{Kind: "let", Declarations: []*VariableDeclarator{{ID: &Identifier{Name: "y"}}}},
+			&ExpressionStatement{Expression: &Identifier{Name: "y"}},
+		}},
+		&VariableDeclaration{Kind: "const", Declarations: []*VariableDeclarator{
+			{ID: &Identifier{Name: "y"}, Init: &Identifier{Name: "x"}},
+		}},
+	}
+
+	if want, got := []*Identifier{x, y, z}, TDZReferences(body); !reflect.DeepEqual(want, got) {
+		t.Fatalf("want=%v got=%v", want, got)
+	}
+}
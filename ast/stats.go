@@ -0,0 +1,97 @@
+package ast
+
+import "reflect"
+
+// FeatureStats counts the nodes of node and its descendants by node type,
+// e.g. "CallExpression", along with the JavaScript features spanning node
+// types:
+//
+//   - "OptionalChaining": the optional links of member and call chains
+//   - "Destructuring": the array and object patterns
+//   - "TypeAnnotations": the identifiers annotated with TypeScript types
+//   - "Modules": the import and export declarations
+//   - "Accessors": the getters and setters of object literals
+func FeatureStats(node Node) map[string]int {
+	stats := make(map[string]int)
+	Inspect(node, func(n Node) bool {
+		if n == nil {
+			return false
+		}
+
+		stats[reflect.TypeOf(n).Elem().Name()]++
+		if f := feature(n); f != "" {
+			stats[f]++
+		}
+		return true
+	})
+
+	return stats
+}
+
+// feature returns the feature n is a use of, or "" when it isn't one
+func feature(n Node) string {
+	switch v := n.(type) {
+	case *OptionalMemberExpression:
+		if v.Optional {
+			return "OptionalChaining"
+		}
+	case *OptionalCallExpression:
+		if v.Optional {
+			return "OptionalChaining"
+		}
+	case *ArrayPattern, *ObjectPattern:
+		return "Destructuring"
+	case *Identifier:
+		if v.TypeAnnotation != nil {
+			return "TypeAnnotations"
+		}
+	case *ImportDeclaration, *ExportNamedDeclaration, *ExportDefaultDeclaration:
+		return "Modules"
+	case *ObjectMethod:
+		if v.Kind == "get" || v.Kind == "set" {
+			return "Accessors"
+		}
+	}
+
+	return ""
+}
+
+// NodeCount returns the number of nodes of node and its descendants, a
+// rough estimate of the cost of compiling it.
+func NodeCount(node Node) int {
+	n := 0
+	Inspect(node, func(node Node) bool {
+		if node != nil {
+			n++
+		}
+		return true
+	})
+
+	return n
+}
+
+// MaxDepth returns the depth of the deepest node of node and its
+// descendants, node itself being at depth 1.
+func MaxDepth(node Node) int {
+	d := &depthVisitor{}
+	Walk(d, node)
+
+	return d.max
+}
+
+type depthVisitor struct {
+	depth, max int
+}
+
+func (d *depthVisitor) Visit(node Node) Visitor {
+	if node == nil {
+		d.depth--
+		return nil
+	}
+
+	d.depth++
+	if d.depth > d.max {
+		d.max = d.depth
+	}
+	return d
+}
@@ -1,20 +1,54 @@
 package ast
 
+import "strings"
+
 func unmarshalProgram(m m) *Program {
 	p := &Program{}
 	p.Attr = unmarshalAttr(m)
 	p.SourceType = convertString(m["sourceType"])
+	if interpreter := m["interpreter"]; interpreter != nil {
+		p.Interpreter = convertString(convertMap(interpreter)["value"])
+	}
 	p.Body = unmarshalStatements(convertSliceMap(m["body"]))
 
 	return p
 }
 
+func unmarshalComments(ms []m) []*Comment {
+	var comments []*Comment
+	for _, m := range ms {
+		c := &Comment{}
+		c.Attr = unmarshalAttr(m)
+		c.Value = convertString(m["value"])
+		comments = append(comments, c)
+	}
+
+	return comments
+}
+
+// leadingComments returns the comments ending before the first statement of
+// p, all of them when p is empty
+func leadingComments(comments []*Comment, p *Program) []*Comment {
+	n := 0
+	for _, c := range comments {
+		if len(p.Body) > 0 && c.End > p.Body[0].GetAttr().Start {
+			break
+		}
+		n++
+	}
+
+	return comments[:n]
+}
+
 func unmarshalAttr(m m) *Attr {
 	a := &Attr{}
 	a.Type = convertString(m["type"])
 	a.Start = convertInt(m["start"])
 	a.End = convertInt(m["end"])
 	a.Loc = unmarshalSourceLocation(convertMap(m["loc"]))
+	if comments, ok := m["leadingComments"]; ok && comments != nil {
+		a.LeadingComments = unmarshalComments(convertSliceMap(comments))
+	}
 
 	return a
 }
@@ -60,10 +94,42 @@ func unmarshalStatement(m m) Statement {
 	switch t {
 	case "VariableDeclaration":
 		s = unmarshalVariableDeclaration(m)
+	case "FunctionDeclaration":
+		s = unmarshalFunctionDeclaration(m)
+	case "ClassDeclaration":
+		s = unmarshalClassDeclaration(m)
 	case "ExpressionStatement":
 		s = unmarshalExpressionStatement(m)
+	case "BlockStatement":
+		s = unmarshalBlockStatement(m)
+	case "ReturnStatement":
+		s = unmarshalReturnStatement(m)
+	case "ForStatement":
+		s = unmarshalForStatement(m)
+	case "ForOfStatement":
+		s = unmarshalForOfStatement(m)
+	case "DoWhileStatement":
+		s = unmarshalDoWhileStatement(m)
+	case "SwitchStatement":
+		s = unmarshalSwitchStatement(m)
+	case "WithStatement":
+		s = unmarshalWithStatement(m)
+	case "LabeledStatement":
+		s = unmarshalLabeledStatement(m)
+	case "BreakStatement":
+		s = &BreakStatement{Attr: unmarshalAttr(m), Label: unmarshalLabel(m)}
+	case "ContinueStatement":
+		s = &ContinueStatement{Attr: unmarshalAttr(m), Label: unmarshalLabel(m)}
+	case "DebuggerStatement":
+		s = &DebuggerStatement{Attr: unmarshalAttr(m)}
+	case "ImportDeclaration":
+		s = unmarshalImportDeclaration(m)
+	case "ExportNamedDeclaration":
+		s = unmarshalExportNamedDeclaration(m)
+	case "ExportDefaultDeclaration":
+		s = unmarshalExportDefaultDeclaration(m)
 	default:
-		panic("unsupport statement type " + t)
+		panic(unmarshalErrorf("unsupported statement type %s", t))
 	}
 
 	return s
@@ -77,6 +143,169 @@ func unmarshalExpressionStatement(m m) *ExpressionStatement {
 	return e
 }
 
+func unmarshalBlockStatement(m m) *BlockStatement {
+	b := &BlockStatement{}
+	b.Attr = unmarshalAttr(m)
+	b.Body = unmarshalStatements(convertSliceMap(m["body"]))
+
+	return b
+}
+
+func unmarshalReturnStatement(m m) *ReturnStatement {
+	r := &ReturnStatement{}
+	r.Attr = unmarshalAttr(m)
+	if arg := m["argument"]; arg != nil {
+		r.Argument = unmarshalExpression(convertMap(arg))
+	}
+
+	return r
+}
+
+func unmarshalSwitchStatement(m m) *SwitchStatement {
+	s := &SwitchStatement{}
+	s.Attr = unmarshalAttr(m)
+	s.Discriminant = unmarshalExpression(convertMap(m["discriminant"]))
+	for _, mm := range convertSliceMap(m["cases"]) {
+		c := &SwitchCase{}
+		c.Attr = unmarshalAttr(mm)
+		if test := mm["test"]; test != nil {
+			c.Test = unmarshalExpression(convertMap(test))
+		}
+		c.Consequent = unmarshalStatements(convertSliceMap(mm["consequent"]))
+		s.Cases = append(s.Cases, c)
+	}
+
+	return s
+}
+
+func unmarshalForStatement(m m) *ForStatement {
+	f := &ForStatement{}
+	f.Attr = unmarshalAttr(m)
+	if init := m["init"]; init != nil {
+		if init := convertMap(init); convertString(init["type"]) == "VariableDeclaration" {
+			f.Init = unmarshalVariableDeclaration(init)
+		} else {
+			f.Init = unmarshalExpression(init)
+		}
+	}
+	if test := m["test"]; test != nil {
+		f.Test = unmarshalExpression(convertMap(test))
+	}
+	if update := m["update"]; update != nil {
+		f.Update = unmarshalExpression(convertMap(update))
+	}
+	f.Body = unmarshalStatement(convertMap(m["body"]))
+
+	return f
+}
+
+func unmarshalForOfStatement(m m) *ForOfStatement {
+	f := &ForOfStatement{}
+	f.Attr = unmarshalAttr(m)
+	if await, ok := m["await"]; ok && await != nil {
+		f.Await = convertBool(await)
+	}
+	if left := convertMap(m["left"]); convertString(left["type"]) == "VariableDeclaration" {
+		f.Left = unmarshalVariableDeclaration(left)
+	} else {
+		f.Left = unmarshalExpression(left)
+	}
+	f.Right = unmarshalExpression(convertMap(m["right"]))
+	f.Body = unmarshalStatement(convertMap(m["body"]))
+
+	return f
+}
+
+func unmarshalDoWhileStatement(m m) *DoWhileStatement {
+	d := &DoWhileStatement{}
+	d.Attr = unmarshalAttr(m)
+	d.Body = unmarshalStatement(convertMap(m["body"]))
+	d.Test = unmarshalExpression(convertMap(m["test"]))
+
+	return d
+}
+
+func unmarshalWithStatement(m m) *WithStatement {
+	w := &WithStatement{}
+	w.Attr = unmarshalAttr(m)
+	w.Object = unmarshalExpression(convertMap(m["object"]))
+	w.Body = unmarshalStatement(convertMap(m["body"]))
+
+	return w
+}
+
+func unmarshalLabeledStatement(m m) *LabeledStatement {
+	l := &LabeledStatement{}
+	l.Attr = unmarshalAttr(m)
+	l.Label = unmarshalIdentifier(convertMap(m["label"]))
+	l.Body = unmarshalStatement(convertMap(m["body"]))
+
+	return l
+}
+
+// unmarshalLabel unmarshals the optional label of a break or continue
+func unmarshalLabel(m m) *Identifier {
+	if label := m["label"]; label != nil {
+		return unmarshalIdentifier(convertMap(label))
+	}
+
+	return nil
+}
+
+func unmarshalFunctionDeclaration(m m) *FunctionDeclaration {
+	f := &FunctionDeclaration{}
+	f.Attr = unmarshalAttr(m)
+	if id := m["id"]; id != nil {
+		f.ID = unmarshalIdentifier(convertMap(id))
+	}
+	f.Params = unmarshalBindings(convertSliceMap(m["params"]))
+	f.Body = unmarshalBlockStatement(convertMap(m["body"]))
+
+	return f
+}
+
+func unmarshalClassDeclaration(m m) *ClassDeclaration {
+	c := &ClassDeclaration{}
+	c.Attr = unmarshalAttr(m)
+	if id := m["id"]; id != nil {
+		c.ID = unmarshalIdentifier(convertMap(id))
+	}
+	if super := m["superClass"]; super != nil {
+		c.SuperClass = unmarshalExpression(convertMap(super))
+	}
+
+	body := convertMap(m["body"])
+	c.Body = &ClassBody{Attr: unmarshalAttr(body)}
+	for _, mm := range convertSliceMap(body["body"]) {
+		c.Body.Body = append(c.Body.Body, unmarshalClassMember(mm))
+	}
+
+	return c
+}
+
+func unmarshalClassMember(m m) Node {
+	t := convertString(m["type"])
+	switch t {
+	case "ClassProperty":
+		return unmarshalClassProperty(m)
+	default:
+		panic(unmarshalErrorf("unsupported class member type %s", t))
+	}
+}
+
+func unmarshalClassProperty(m m) *ClassProperty {
+	c := &ClassProperty{}
+	c.Attr = unmarshalAttr(m)
+	c.Key = unmarshalExpression(convertMap(m["key"]))
+	if v := m["value"]; v != nil {
+		c.Value = unmarshalExpression(convertMap(v))
+	}
+	c.Computed = convertBool(m["computed"])
+	c.Static = convertBool(m["static"])
+
+	return c
+}
+
 func unmarshalVariableDeclaration(m m) *VariableDeclaration {
 	v := &VariableDeclaration{}
 	v.Attr = unmarshalAttr(m)
@@ -86,6 +315,83 @@ func unmarshalVariableDeclaration(m m) *VariableDeclaration {
 	return v
 }
 
+// modules
+
+func unmarshalImportDeclaration(m m) *ImportDeclaration {
+	i := &ImportDeclaration{}
+	i.Attr = unmarshalAttr(m)
+	for _, mm := range convertSliceMap(m["specifiers"]) {
+		i.Specifiers = append(i.Specifiers, unmarshalImportSpecifier(mm))
+	}
+	i.Source = unmarshalStringLiteral(convertMap(m["source"]))
+
+	return i
+}
+
+func unmarshalImportSpecifier(m m) Node {
+	t := convertString(m["type"])
+	switch t {
+	case "ImportDefaultSpecifier":
+		return &ImportDefaultSpecifier{
+			Attr:  unmarshalAttr(m),
+			Local: unmarshalIdentifier(convertMap(m["local"])),
+		}
+	case "ImportSpecifier":
+		return &ImportSpecifier{
+			Attr:     unmarshalAttr(m),
+			Local:    unmarshalIdentifier(convertMap(m["local"])),
+			Imported: unmarshalIdentifier(convertMap(m["imported"])),
+		}
+	case "ImportNamespaceSpecifier":
+		return &ImportNamespaceSpecifier{
+			Attr:  unmarshalAttr(m),
+			Local: unmarshalIdentifier(convertMap(m["local"])),
+		}
+	default:
+		panic(unmarshalErrorf("unsupported import specifier type %s", t))
+	}
+}
+
+func unmarshalExportNamedDeclaration(m m) *ExportNamedDeclaration {
+	e := &ExportNamedDeclaration{}
+	e.Attr = unmarshalAttr(m)
+	if decl := m["declaration"]; decl != nil {
+		e.Declaration = unmarshalStatement(convertMap(decl))
+	}
+	for _, mm := range convertSliceMap(m["specifiers"]) {
+		e.Specifiers = append(e.Specifiers, unmarshalExportSpecifier(mm))
+	}
+	if source := m["source"]; source != nil {
+		e.Source = unmarshalStringLiteral(convertMap(source))
+	}
+
+	return e
+}
+
+func unmarshalExportSpecifier(m m) *ExportSpecifier {
+	e := &ExportSpecifier{}
+	e.Attr = unmarshalAttr(m)
+	e.Local = unmarshalIdentifier(convertMap(m["local"]))
+	e.Exported = unmarshalIdentifier(convertMap(m["exported"]))
+
+	return e
+}
+
+func unmarshalExportDefaultDeclaration(m m) *ExportDefaultDeclaration {
+	e := &ExportDefaultDeclaration{}
+	e.Attr = unmarshalAttr(m)
+	switch decl := convertMap(m["declaration"]); convertString(decl["type"]) {
+	case "FunctionDeclaration":
+		e.Declaration = unmarshalFunctionDeclaration(decl)
+	case "ClassDeclaration":
+		e.Declaration = unmarshalClassDeclaration(decl)
+	default:
+		e.Declaration = unmarshalExpression(decl)
+	}
+
+	return e
+}
+
 // expressions
 
 func unmarshalExpressions(m []m) []Expression {
@@ -109,36 +415,158 @@ func unmarshalExpression(m m) Expression {
 		e = unmarshalNumericLiteral(m)
 	case "CallExpression":
 		e = unmarshalCallExpression(m)
+	case "NewExpression":
+		e = unmarshalNewExpression(m)
+	case "FunctionExpression":
+		e = unmarshalFunctionExpression(m)
+	case "ArrowFunctionExpression":
+		e = unmarshalArrowFunctionExpression(m)
+	case "NullLiteral":
+		e = unmarshalNullLiteral(m)
+	case "BooleanLiteral":
+		e = unmarshalBooleanLiteral(m)
+	case "RegExpLiteral":
+		e = unmarshalRegExpLiteral(m)
 	case "MemberExpression":
 		e = unmarshalMemberExpression(m)
+	case "OptionalMemberExpression":
+		e = unmarshalOptionalMemberExpression(m)
+	case "OptionalCallExpression":
+		e = unmarshalOptionalCallExpression(m)
+	case "TemplateLiteral":
+		e = unmarshalTemplateLiteral(m)
+	case "ArrayExpression":
+		e = unmarshalArrayExpression(m)
+	case "ObjectExpression":
+		e = unmarshalObjectExpression(m)
+	case "ArrayPattern":
+		e = unmarshalArrayPattern(m)
+	case "ObjectPattern":
+		e = unmarshalObjectPattern(m)
+	case "AssignmentPattern":
+		e = unmarshalAssignmentPattern(m)
 	case "AssignmentExpression":
 		e = unmarshalAssignmentExpression(m)
 	case "BinaryExpression":
 		e = unmarshalBinaryExpression(m)
+	case "LogicalExpression":
+		e = unmarshalLogicalExpression(m)
+	case "ConditionalExpression":
+		e = unmarshalConditionalExpression(m)
+	case "UnaryExpression":
+		e = unmarshalUnaryExpression(m)
+	case "UpdateExpression":
+		e = unmarshalUpdateExpression(m)
+	case "SequenceExpression":
+		e = unmarshalSequenceExpression(m)
 	default:
-		panic("unsupport expression type " + t)
+		panic(unmarshalErrorf("unsupported expression type %s", t))
 	}
 
 	return e
 }
 
+func unmarshalBindings(m []m) []Expression {
+	var b []Expression
+	for _, mm := range m {
+		b = append(b, unmarshalBinding(mm))
+	}
+
+	return b
+}
+
+// unmarshalBinding unmarshals the target a declaration or a parameter
+// binds: an identifier, or a destructuring pattern whose elements are
+// targets themselves, e.g. `{a: [b, c]}`, or a parameter with a default.
+func unmarshalBinding(m m) Expression {
+	switch t, _ := m["type"].(string); t {
+	case "", "Identifier":
+		return unmarshalIdentifier(m)
+	case "ArrayPattern", "ObjectPattern", "AssignmentPattern":
+		return unmarshalExpression(m)
+	default:
+		panic(unmarshalErrorf("unsupported binding type %s", t))
+	}
+}
+
 func unmarshalIdentifier(m m) *Identifier {
 	i := &Identifier{}
 	i.Attr = unmarshalAttr(m)
 	i.Name = convertString(m["name"])
+	if ta, ok := m["typeAnnotation"]; ok && ta != nil {
+		i.TypeAnnotation = unmarshalTSType(convertMap(convertMap(ta)["typeAnnotation"]))
+	}
 
 	return i
 }
 
+// unmarshalTSType unmarshals a TypeScript type. The types which aren't
+// keywords, arrays or references to named types are opaque.
+func unmarshalTSType(m m) TSType {
+	attr := unmarshalAttr(m)
+	switch {
+	case strings.HasPrefix(attr.Type, "TS") && strings.HasSuffix(attr.Type, "Keyword"):
+		keyword := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(attr.Type, "TS"), "Keyword"))
+		return &TSKeywordType{Attr: attr, Keyword: keyword}
+	case attr.Type == "TSArrayType":
+		return &TSArrayType{Attr: attr, ElementType: unmarshalTSType(convertMap(m["elementType"]))}
+	case attr.Type == "TSTypeReference" && convertMap(m["typeName"])["type"] == "Identifier":
+		r := &TSTypeReference{Attr: attr, TypeName: unmarshalIdentifier(convertMap(m["typeName"]))}
+		if tp, ok := m["typeParameters"]; ok && tp != nil {
+			for _, p := range convertSliceMap(convertMap(tp)["params"]) {
+				r.TypeParameters = append(r.TypeParameters, unmarshalTSType(p))
+			}
+		}
+		return r
+	}
+
+	return &TSOpaqueType{Attr: attr}
+}
+
+func unmarshalFunctionExpression(m m) *FunctionExpression {
+	fd := unmarshalFunctionDeclaration(m)
+
+	return &FunctionExpression{Attr: fd.Attr, ID: fd.ID, Params: fd.Params, Body: fd.Body}
+}
+
+func unmarshalArrowFunctionExpression(m m) *ArrowFunctionExpression {
+	a := &ArrowFunctionExpression{}
+	a.Attr = unmarshalAttr(m)
+	a.Params = unmarshalBindings(convertSliceMap(m["params"]))
+	if body := convertMap(m["body"]); convertString(body["type"]) == "BlockStatement" {
+		a.Body = unmarshalBlockStatement(body)
+	} else {
+		a.Body = unmarshalExpression(body)
+	}
+
+	return a
+}
+
+func unmarshalNewExpression(m m) *NewExpression {
+	ce := unmarshalCallExpression(m)
+
+	return &NewExpression{Attr: ce.Attr, Callee: ce.Callee, Arguments: ce.Arguments}
+}
+
 func unmarshalCallExpression(m m) *CallExpression {
 	c := &CallExpression{}
 	c.Attr = unmarshalAttr(m)
 	c.Callee = unmarshalExpression(convertMap(m["callee"]))
-	c.Arguments = unmarshalExpressions(convertSliceMap(m["arguments"]))
+	c.Arguments = unmarshalArguments(convertSliceMap(m["arguments"]))
 
 	return c
 }
 
+func unmarshalOptionalCallExpression(m m) *OptionalCallExpression {
+	o := &OptionalCallExpression{}
+	o.Attr = unmarshalAttr(m)
+	o.Callee = unmarshalExpression(convertMap(m["callee"]))
+	o.Arguments = unmarshalArguments(convertSliceMap(m["arguments"]))
+	o.Optional = convertBool(m["optional"])
+
+	return o
+}
+
 func unmarshalMemberExpression(m m) *MemberExpression {
 	e := &MemberExpression{}
 	e.Attr = unmarshalAttr(m)
@@ -149,6 +577,150 @@ func unmarshalMemberExpression(m m) *MemberExpression {
 	return e
 }
 
+func unmarshalOptionalMemberExpression(m m) *OptionalMemberExpression {
+	o := &OptionalMemberExpression{}
+	o.Attr = unmarshalAttr(m)
+	o.Object = unmarshalExpression(convertMap(m["object"]))
+	o.Property = unmarshalExpression(convertMap(m["property"]))
+	o.Computed = convertBool(m["computed"])
+	o.Optional = convertBool(m["optional"])
+
+	return o
+}
+
+func unmarshalTemplateLiteral(m m) *TemplateLiteral {
+	t := &TemplateLiteral{}
+	t.Attr = unmarshalAttr(m)
+	for _, mm := range convertSliceMap(m["quasis"]) {
+		value := convertMap(mm["value"])
+		e := &TemplateElement{
+			Attr: unmarshalAttr(mm),
+			Raw:  convertString(value["raw"]),
+			Tail: convertBool(mm["tail"]),
+		}
+		// cooked is null for invalid escapes, only allowed in tagged templates
+		if cooked, ok := value["cooked"].(string); ok {
+			e.Cooked = cooked
+		}
+
+		t.Quasis = append(t.Quasis, e)
+	}
+	t.Expressions = unmarshalExpressions(convertSliceMap(m["expressions"]))
+
+	return t
+}
+
+func unmarshalArrayExpression(m m) *ArrayExpression {
+	a := &ArrayExpression{}
+	a.Attr = unmarshalAttr(m)
+	a.Elements = unmarshalElements(m["elements"])
+
+	return a
+}
+
+// unmarshalArguments unmarshals the arguments of a call, which may be spread
+func unmarshalArguments(ms []m) []Expression {
+	var args []Expression
+	for _, m := range ms {
+		if m["type"] == "SpreadElement" {
+			args = append(args, unmarshalSpreadElement(m))
+		} else {
+			args = append(args, unmarshalExpression(m))
+		}
+	}
+
+	return args
+}
+
+// unmarshalElements unmarshals the elements of an array literal or pattern,
+// whose holes are nil
+func unmarshalElements(elements interface{}) []Expression {
+	var e []Expression
+	for _, mm := range convertSlice(elements) {
+		if mm == nil {
+			e = append(e, nil)
+		} else if m := convertMap(mm); m["type"] == "SpreadElement" {
+			e = append(e, unmarshalSpreadElement(m))
+		} else {
+			e = append(e, unmarshalExpression(convertMap(mm)))
+		}
+	}
+
+	return e
+}
+
+func unmarshalObjectExpression(m m) *ObjectExpression {
+	o := &ObjectExpression{}
+	o.Attr = unmarshalAttr(m)
+	for _, mm := range convertSliceMap(m["properties"]) {
+		o.Properties = append(o.Properties, unmarshalObjectMember(mm))
+	}
+
+	return o
+}
+
+func unmarshalObjectMember(m m) Node {
+	t := convertString(m["type"])
+	switch t {
+	case "ObjectProperty":
+		return &ObjectProperty{
+			Attr:      unmarshalAttr(m),
+			Key:       unmarshalExpression(convertMap(m["key"])),
+			Value:     unmarshalExpression(convertMap(m["value"])),
+			Computed:  convertBool(m["computed"]),
+			Shorthand: convertBool(m["shorthand"]),
+		}
+	case "ObjectMethod":
+		return &ObjectMethod{
+			Attr:     unmarshalAttr(m),
+			Kind:     convertString(m["kind"]),
+			Key:      unmarshalExpression(convertMap(m["key"])),
+			Computed: convertBool(m["computed"]),
+			Params:   unmarshalBindings(convertSliceMap(m["params"])),
+			Body:     unmarshalBlockStatement(convertMap(m["body"])),
+		}
+	case "SpreadElement":
+		return unmarshalSpreadElement(m)
+	default:
+		panic(unmarshalErrorf("unsupported object member type %s", t))
+	}
+}
+
+func unmarshalArrayPattern(m m) *ArrayPattern {
+	a := &ArrayPattern{}
+	a.Attr = unmarshalAttr(m)
+	a.Elements = unmarshalElements(m["elements"])
+
+	return a
+}
+
+func unmarshalObjectPattern(m m) *ObjectPattern {
+	o := &ObjectPattern{}
+	o.Attr = unmarshalAttr(m)
+	for _, mm := range convertSliceMap(m["properties"]) {
+		o.Properties = append(o.Properties, unmarshalObjectMember(mm))
+	}
+
+	return o
+}
+
+func unmarshalAssignmentPattern(m m) *AssignmentPattern {
+	a := &AssignmentPattern{}
+	a.Attr = unmarshalAttr(m)
+	a.Left = unmarshalExpression(convertMap(m["left"]))
+	a.Right = unmarshalExpression(convertMap(m["right"]))
+
+	return a
+}
+
+func unmarshalSpreadElement(m m) *SpreadElement {
+	s := &SpreadElement{}
+	s.Attr = unmarshalAttr(m)
+	s.Argument = unmarshalExpression(convertMap(m["argument"]))
+
+	return s
+}
+
 func unmarshalAssignmentExpression(m m) *AssignmentExpression {
 	a := &AssignmentExpression{}
 	a.Attr = unmarshalAttr(m)
@@ -169,12 +741,59 @@ func unmarshalBinaryExpression(m m) *BinaryExpression {
 	return b
 }
 
+func unmarshalLogicalExpression(m m) *LogicalExpression {
+	l := &LogicalExpression{}
+	l.Attr = unmarshalAttr(m)
+	l.Left = unmarshalExpression(convertMap(m["left"]))
+	l.Right = unmarshalExpression(convertMap(m["right"]))
+	l.Operator = LogicalOperator(convertString(m["operator"]))
+
+	return l
+}
+
+func unmarshalConditionalExpression(m m) *ConditionalExpression {
+	c := &ConditionalExpression{}
+	c.Attr = unmarshalAttr(m)
+	c.Test = unmarshalExpression(convertMap(m["test"]))
+	c.Consequent = unmarshalExpression(convertMap(m["consequent"]))
+	c.Alternate = unmarshalExpression(convertMap(m["alternate"]))
+
+	return c
+}
+
+func unmarshalUnaryExpression(m m) *UnaryExpression {
+	u := &UnaryExpression{}
+	u.Attr = unmarshalAttr(m)
+	u.Operator = UnaryOperator(convertString(m["operator"]))
+	u.Argument = unmarshalExpression(convertMap(m["argument"]))
+
+	return u
+}
+
+func unmarshalUpdateExpression(m m) *UpdateExpression {
+	u := &UpdateExpression{}
+	u.Attr = unmarshalAttr(m)
+	u.Operator = UpdateOperator(convertString(m["operator"]))
+	u.Prefix = convertBool(m["prefix"])
+	u.Argument = unmarshalExpression(convertMap(m["argument"]))
+
+	return u
+}
+
+func unmarshalSequenceExpression(m m) *SequenceExpression {
+	s := &SequenceExpression{}
+	s.Attr = unmarshalAttr(m)
+	s.Expressions = unmarshalExpressions(convertSliceMap(m["expressions"]))
+
+	return s
+}
+
 func unmarshalVariableDeclarator(m []m) []*VariableDeclarator {
 	var d []*VariableDeclarator
 	for _, mm := range m {
 		dd := &VariableDeclarator{}
 		dd.Attr = unmarshalAttr(mm)
-		dd.ID = unmarshalIdentifier(convertMap(mm["id"]))
+		dd.ID = unmarshalBinding(convertMap(mm["id"]))
 		if init := mm["init"]; init != nil {
 			dd.Init = unmarshalExpression(convertMap(init))
 		}
@@ -190,10 +809,39 @@ func unmarshalStringLiteral(m m) *StringLiteral {
 	s.Attr = unmarshalAttr(m)
 	s.Value = convertString(m["value"])
 	s.Extra = unmarshalExtra(convertMap(m["extra"]))
+	if raw, ok := s.Extra.Raw.(string); ok {
+		if v, err := unquote(raw); err == nil {
+			s.Value = v
+		}
+	}
 
 	return s
 }
 
+func unmarshalNullLiteral(m m) *NullLiteral {
+	n := &NullLiteral{}
+	n.Attr = unmarshalAttr(m)
+
+	return n
+}
+
+func unmarshalBooleanLiteral(m m) *BooleanLiteral {
+	b := &BooleanLiteral{}
+	b.Attr = unmarshalAttr(m)
+	b.Value = convertBool(m["value"])
+
+	return b
+}
+
+func unmarshalRegExpLiteral(m m) *RegExpLiteral {
+	r := &RegExpLiteral{}
+	r.Attr = unmarshalAttr(m)
+	r.Pattern = convertString(m["pattern"])
+	r.Flags = convertString(m["flags"])
+
+	return r
+}
+
 func unmarshalNumericLiteral(m m) *NumericLiteral {
 	n := &NumericLiteral{}
 	n.Attr = unmarshalAttr(m)
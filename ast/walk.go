@@ -0,0 +1,282 @@
+package ast
+
+import "github.com/jingweno/godzilla/utils"
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children of
+// node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order, the same way go/ast does:
+// It starts by calling v.Visit(node); node must not be nil. If the visitor
+// w returned by v.Visit(node) is not nil, Walk is invoked recursively with
+// visitor w for each of the non-nil children of node, followed by a call of
+// w.Visit(nil).
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *File:
+		if n.Program != nil {
+			Walk(v, n.Program)
+		}
+	case *Program:
+		walkStatements(v, n.Body)
+
+	// statements
+	case *ExpressionStatement:
+		Walk(v, n.Expression)
+	case *BlockStatement:
+		walkStatements(v, n.Body)
+	case *ReturnStatement:
+		if n.Argument != nil {
+			Walk(v, n.Argument)
+		}
+	case *ForStatement:
+		if n.Init != nil {
+			Walk(v, n.Init)
+		}
+		if n.Test != nil {
+			Walk(v, n.Test)
+		}
+		if n.Update != nil {
+			Walk(v, n.Update)
+		}
+		Walk(v, n.Body)
+	case *ForOfStatement:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+		Walk(v, n.Body)
+	case *DoWhileStatement:
+		Walk(v, n.Body)
+		Walk(v, n.Test)
+	case *SwitchStatement:
+		Walk(v, n.Discriminant)
+		for _, c := range n.Cases {
+			Walk(v, c)
+		}
+	case *SwitchCase:
+		if n.Test != nil {
+			Walk(v, n.Test)
+		}
+		walkStatements(v, n.Consequent)
+	case *WithStatement:
+		Walk(v, n.Object)
+		Walk(v, n.Body)
+	case *LabeledStatement:
+		Walk(v, n.Label)
+		Walk(v, n.Body)
+	case *BreakStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+	case *ContinueStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+	case *DebuggerStatement:
+		// nothing to do
+
+	// declarations
+	case *VariableDeclaration:
+		for _, d := range n.Declarations {
+			Walk(v, d)
+		}
+	case *VariableDeclarator:
+		Walk(v, n.ID)
+		if n.Init != nil {
+			Walk(v, n.Init)
+		}
+	case *FunctionDeclaration:
+		if n.ID != nil {
+			Walk(v, n.ID)
+		}
+		for _, p := range n.Params {
+			Walk(v, p)
+		}
+		Walk(v, n.Body)
+	case *ClassDeclaration:
+		if n.ID != nil {
+			Walk(v, n.ID)
+		}
+		if n.SuperClass != nil {
+			Walk(v, n.SuperClass)
+		}
+		Walk(v, n.Body)
+	case *ClassBody:
+		for _, m := range n.Body {
+			Walk(v, m)
+		}
+	case *ClassProperty:
+		Walk(v, n.Key)
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+
+	// modules
+	case *ImportDeclaration:
+		for _, s := range n.Specifiers {
+			Walk(v, s)
+		}
+		Walk(v, n.Source)
+	case *ImportDefaultSpecifier:
+		Walk(v, n.Local)
+	case *ImportSpecifier:
+		Walk(v, n.Imported)
+		Walk(v, n.Local)
+	case *ImportNamespaceSpecifier:
+		Walk(v, n.Local)
+	case *ExportNamedDeclaration:
+		if n.Declaration != nil {
+			Walk(v, n.Declaration)
+		}
+		for _, s := range n.Specifiers {
+			Walk(v, s)
+		}
+		if n.Source != nil {
+			Walk(v, n.Source)
+		}
+	case *ExportSpecifier:
+		Walk(v, n.Local)
+		Walk(v, n.Exported)
+	case *ExportDefaultDeclaration:
+		Walk(v, n.Declaration)
+
+	// expressions
+	case *Identifier:
+		// nothing to do
+	case *NewExpression:
+		Walk(v, n.Callee)
+		walkExpressions(v, n.Arguments)
+	case *FunctionExpression:
+		if n.ID != nil {
+			Walk(v, n.ID)
+		}
+		for _, p := range n.Params {
+			Walk(v, p)
+		}
+		Walk(v, n.Body)
+	case *ArrowFunctionExpression:
+		for _, p := range n.Params {
+			Walk(v, p)
+		}
+		Walk(v, n.Body)
+	case *CallExpression:
+		Walk(v, n.Callee)
+		walkExpressions(v, n.Arguments)
+	case *MemberExpression:
+		Walk(v, n.Object)
+		Walk(v, n.Property)
+	case *OptionalMemberExpression:
+		Walk(v, n.Object)
+		Walk(v, n.Property)
+	case *OptionalCallExpression:
+		Walk(v, n.Callee)
+		walkExpressions(v, n.Arguments)
+	case *TemplateLiteral:
+		for i, q := range n.Quasis {
+			Walk(v, q)
+			if i < len(n.Expressions) {
+				Walk(v, n.Expressions[i])
+			}
+		}
+	case *TemplateElement:
+		// nothing to do
+	case *ArrayExpression:
+		for _, e := range n.Elements {
+			if e != nil {
+				Walk(v, e)
+			}
+		}
+	case *ObjectExpression:
+		for _, p := range n.Properties {
+			Walk(v, p)
+		}
+	case *ArrayPattern:
+		for _, e := range n.Elements {
+			if e != nil {
+				Walk(v, e)
+			}
+		}
+	case *ObjectPattern:
+		for _, p := range n.Properties {
+			Walk(v, p)
+		}
+	case *AssignmentPattern:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *ObjectProperty:
+		Walk(v, n.Key)
+		Walk(v, n.Value)
+	case *ObjectMethod:
+		Walk(v, n.Key)
+		for _, p := range n.Params {
+			Walk(v, p)
+		}
+		Walk(v, n.Body)
+	case *SpreadElement:
+		Walk(v, n.Argument)
+	case *AssignmentExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *BinaryExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *LogicalExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *ConditionalExpression:
+		Walk(v, n.Test)
+		Walk(v, n.Consequent)
+		Walk(v, n.Alternate)
+	case *UnaryExpression:
+		Walk(v, n.Argument)
+	case *UpdateExpression:
+		Walk(v, n.Argument)
+	case *SequenceExpression:
+		walkExpressions(v, n.Expressions)
+
+	// literals
+	case *StringLiteral, *NumericLiteral, *NullLiteral, *BooleanLiteral, *RegExpLiteral:
+		// nothing to do
+
+	default:
+		panic("ast.Walk: unexpected node type " + utils.TypeOf(n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, list []Statement) {
+	for _, s := range list {
+		Walk(v, s)
+	}
+}
+
+func walkExpressions(v Visitor, list []Expression) {
+	for _, e := range list {
+		Walk(v, e)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a call
+// of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -1,8 +1,6 @@
 package build
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,13 +8,27 @@ import (
 	"os/exec"
 	"path/filepath"
 
-	"github.com/jingweno/godzilla/ast"
 	"github.com/jingweno/godzilla/compiler"
-	"github.com/jingweno/godzilla/source"
 )
 
+// Run compiles the JavaScript program of r, parsed with the godzilla-parser
+// at parserPath, to a Go main file and returns its path.
 func Run(parserPath string, r io.Reader) (string, error) {
-	source, err := compileSource(parserPath, r)
+	return Builder{Parser: CommandParser{Path: parserPath}}.Build(r)
+}
+
+// Builder compiles JavaScript programs to Go main files.
+type Builder struct {
+	Parser  Parser
+	Options compiler.CompileOptions
+}
+
+// Build compiles the JavaScript program of r to a Go main file, formatted
+// with go fmt, and returns its path. The file isn't formatted when the
+// options preserve the line numbers, as gofmt collapses the blank lines
+// keeping statements on their JavaScript lines.
+func (b Builder) Build(r io.Reader) (string, error) {
+	source, err := b.compileSource(r)
 	if err != nil {
 		return "", err
 	}
@@ -26,30 +38,30 @@ func Run(parserPath string, r io.Reader) (string, error) {
 		return "", err
 	}
 
-	if err := goFmt(main); err != nil {
-		return "", err
+	if !b.Options.PreserveLineNumbers {
+		if err := goFmt(main); err != nil {
+			return "", err
+		}
 	}
 
 	return main, nil
 }
 
-func compileSource(parserPath string, r io.Reader) (*source.Code, error) {
-	c := exec.Command(parserPath)
-	c.Stdin = r
-	stdoutStderr, err := c.CombinedOutput()
+func (b Builder) compileSource(r io.Reader) (string, error) {
+	f, err := b.Parser.Parse(r)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing JavaScript %s: %s", err, stdoutStderr)
+		return "", err
 	}
 
-	f := &ast.File{}
-	if err := json.NewDecoder(bytes.NewBuffer(stdoutStderr)).Decode(f); err != nil {
-		return nil, err
+	res, err := compiler.Compile(f, b.Options)
+	if err != nil {
+		return "", err
 	}
 
-	return compiler.Compile(f), nil
+	return res.Source, nil
 }
 
-func writeMainFile(code *source.Code) (string, error) {
+func writeMainFile(source string) (string, error) {
 	mainDir, err := ioutil.TempDir("", "main")
 	if err != nil {
 		return "", err
@@ -60,7 +72,7 @@ func writeMainFile(code *source.Code) (string, error) {
 		return "", err
 	}
 
-	if err := code.WriteTo(mainFile); err != nil {
+	if _, err := io.WriteString(mainFile, source); err != nil {
 		return "", err
 	}
 
@@ -75,7 +87,7 @@ func goFmt(mainFile string) error {
 	cmd := exec.Command("go", "fmt", mainFile)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		fmt.Errorf("error running `go fmt %s`: error=%s out=%s", err, out)
+		return fmt.Errorf("error running `go fmt %s`: error=%s out=%s", mainFile, err, out)
 	}
 
 	return nil
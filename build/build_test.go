@@ -0,0 +1,59 @@
+package build
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+	"github.com/jingweno/godzilla/compiler"
+)
+
+// stubParser parses any source to its program
+type stubParser struct {
+	program *ast.Program
+}
+
+func (p stubParser) Parse(r io.Reader) (*ast.File, error) {
+	return &ast.File{Program: p.program}, nil
+}
+
+func TestBuilder_PreserveLineNumbers(t *testing.T) {
+	// console.log("a") on line 20
+	loc := func(line int) *ast.Attr {
+		return &ast.Attr{Loc: &ast.SourceLocation{Start: &ast.Position{Line: line}, End: &ast.Position{Line: line}}}
+	}
+	program := &ast.Program{Attr: loc(1), SourceType: "script", Body: []ast.Statement{
+		&ast.ExpressionStatement{Attr: loc(20), Expression: &ast.CallExpression{
+			Attr: loc(20),
+			Callee: &ast.MemberExpression{
+				Attr:     loc(20),
+				Object:   &ast.Identifier{Attr: loc(20), Name: "console"},
+				Property: &ast.Identifier{Attr: loc(20), Name: "log"},
+			},
+			Arguments: []ast.Expression{&ast.StringLiteral{Attr: loc(20), Value: "a"}},
+		}},
+	}}
+
+	for _, preserve := range []bool{true, false} {
+		b := Builder{Parser: stubParser{program}, Options: compiler.CompileOptions{PreserveLineNumbers: preserve}}
+		main, err := b.Build(strings.NewReader(""))
+		if err != nil {
+			t.Fatalf("error building: %s", err)
+		}
+		defer os.RemoveAll(filepath.Dir(main))
+
+		src, err := ioutil.ReadFile(main)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(string(src), "\n")
+		onLine := len(lines) >= 20 && strings.TrimSpace(lines[19]) == `Console_Log([]Object{JSString("a")})`
+		if onLine != preserve {
+			t.Errorf("preserving line numbers %v, want the statement on line 20 %v:\n%s", preserve, preserve, src)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// Parser parses JavaScript source to its AST.
+type Parser interface {
+	Parse(r io.Reader) (*ast.File, error)
+}
+
+// CommandParser parses JavaScript with an executable, such as
+// godzilla-parser, reading the source on stdin and writing its AST as JSON.
+type CommandParser struct {
+	Path string
+}
+
+func (p CommandParser) Parse(r io.Reader) (*ast.File, error) {
+	c := exec.Command(p.Path)
+	c.Stdin = r
+	stdoutStderr, err := c.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JavaScript %s: %s", err, stdoutStderr)
+	}
+
+	f := &ast.File{}
+	if err := json.NewDecoder(bytes.NewBuffer(stdoutStderr)).Decode(f); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
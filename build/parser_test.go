@@ -0,0 +1,143 @@
+package build
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// TestParser_RoundTrip checks that the source printed from random programs
+// parses back to the same programs. It needs godzilla-parser, built with
+// `make build-godzilla-parser` or found at $GODZILLA_PARSER.
+func TestParser_RoundTrip(t *testing.T) {
+	path := os.Getenv("GODZILLA_PARSER")
+	if path == "" {
+		path = filepath.Join("..", "bin", "godzilla-parser")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Skipf("godzilla-parser isn't available: %s", err)
+	}
+	parser := CommandParser{Path: path}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		g := &programGen{r: r}
+		want := &ast.Program{SourceType: "script"}
+		for n := r.Intn(5) + 1; n > 0; n-- {
+			want.Body = append(want.Body, g.statement(3))
+		}
+
+		src := want.String()
+		f, err := parser.Parse(strings.NewReader(src))
+		if err != nil {
+			t.Fatalf("program %d doesn't parse: %s\n%s", i, err, src)
+		}
+		if !ast.Equal(want, f.Program) {
+			t.Fatalf("program %d doesn't round-trip:\n%s\nparsed as:\n%s", i, src, f.Program)
+		}
+	}
+}
+
+// programGen generates random programs of the nodes the compiler supports
+type programGen struct {
+	r *rand.Rand
+	// fn tells whether statements are generated in a function body
+	fn bool
+}
+
+var (
+	genNames     = []string{"a", "b", "c", "x", "y"}
+	genStrings   = []string{"", "hi", `say "hi"`, "a\nb", `C:\dir`, "héllo"}
+	genNumbers   = []float64{0, 1, 2.5, 0.1, 1e21, 1e-7}
+	genBinaryOps = []ast.BinaryOperator{"+", "-", "*", "/", "%", "**", "<", "<=", ">", ">=", "==", "!=", "===", "!==", "&", "|", "^", "<<", ">>", ">>>"}
+	genAssignOps = []ast.AssignmentOperator{"=", "+=", "-=", "*="}
+)
+
+func (g *programGen) statement(depth int) ast.Statement {
+	switch n := g.r.Intn(6); {
+	case depth > 0 && n == 0:
+		b := &ast.BlockStatement{}
+		for i := g.r.Intn(3); i > 0; i-- {
+			b.Body = append(b.Body, g.statement(depth-1))
+		}
+		return b
+	case depth > 0 && n == 1:
+		return &ast.ForStatement{
+			Init: &ast.VariableDeclaration{Kind: "var", Declarations: []*ast.VariableDeclarator{{ID: g.ident(), Init: g.expression(1)}}},
+			Test: g.expression(1),
+			Body: g.statement(depth - 1),
+		}
+	case n == 2:
+		return &ast.VariableDeclaration{Kind: "var", Declarations: []*ast.VariableDeclarator{
+			{ID: g.ident(), Init: g.expression(depth)},
+			{ID: g.ident()},
+		}}
+	case g.fn && n == 3:
+		return &ast.ReturnStatement{Argument: g.expression(depth)}
+	}
+
+	return &ast.ExpressionStatement{Expression: g.expression(depth)}
+}
+
+func (g *programGen) expression(depth int) ast.Expression {
+	if depth == 0 {
+		switch g.r.Intn(4) {
+		case 0:
+			return &ast.StringLiteral{Value: genStrings[g.r.Intn(len(genStrings))]}
+		case 1:
+			return &ast.NumericLiteral{Value: genNumbers[g.r.Intn(len(genNumbers))]}
+		case 2:
+			return &ast.BooleanLiteral{Value: g.r.Intn(2) == 0}
+		}
+		return g.ident()
+	}
+
+	d := depth - 1
+	switch g.r.Intn(10) {
+	case 0:
+		return &ast.BinaryExpression{Operator: genBinaryOps[g.r.Intn(len(genBinaryOps))], Left: g.expression(d), Right: g.expression(d)}
+	case 1:
+		return &ast.AssignmentExpression{Operator: genAssignOps[g.r.Intn(len(genAssignOps))], Left: g.target(d), Right: g.expression(d)}
+	case 2:
+		return &ast.UpdateExpression{Operator: "++", Prefix: g.r.Intn(2) == 0, Argument: g.target(d)}
+	case 3:
+		return &ast.CallExpression{Callee: g.expression(d), Arguments: []ast.Expression{g.expression(d), g.expression(d)}}
+	case 4:
+		return &ast.MemberExpression{Object: g.expression(d), Property: g.expression(d), Computed: true}
+	case 5:
+		return &ast.SequenceExpression{Expressions: []ast.Expression{g.expression(d), g.expression(d)}}
+	case 6:
+		return &ast.ArrayExpression{Elements: []ast.Expression{g.expression(d), g.expression(d)}}
+	case 7:
+		name := g.ident()
+		return &ast.ObjectExpression{Properties: []ast.Node{
+			&ast.ObjectProperty{Key: g.ident(), Value: g.expression(d)},
+			&ast.ObjectProperty{Key: name, Value: name, Shorthand: true},
+		}}
+	case 8:
+		fn := &programGen{r: g.r, fn: true}
+		return &ast.FunctionExpression{
+			Params: []ast.Expression{g.ident()},
+			Body:   &ast.BlockStatement{Body: []ast.Statement{fn.statement(d)}},
+		}
+	}
+
+	return g.target(d)
+}
+
+// target returns an assignable expression
+func (g *programGen) target(depth int) ast.Expression {
+	if depth > 0 && g.r.Intn(2) == 0 {
+		return &ast.MemberExpression{Object: g.expression(depth - 1), Property: g.ident()}
+	}
+
+	return g.ident()
+}
+
+func (g *programGen) ident() *ast.Identifier {
+	return &ast.Identifier{Name: genNames[g.r.Intn(len(genNames))]}
+}
@@ -0,0 +1,116 @@
+package compiler
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// DefaultCacheSize is the number of files a Compiler caches by default.
+const DefaultCacheSize = 128
+
+// Compiler compiles files from their AST JSON, caching the Go source of the
+// files it compiled most recently so that compiling an unchanged file again
+// doesn't redo the work. It's safe for concurrent use.
+type Compiler struct {
+	opts CompileOptions
+	size int
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	// lru orders the cache entries from the most to the least recently used
+	lru    *list.List
+	hits   int
+	misses int
+}
+
+type cacheEntry struct {
+	key    [sha256.Size]byte
+	source string
+}
+
+// NewCompiler returns a Compiler compiling with opts and caching up to size
+// files, or DefaultCacheSize when size isn't positive.
+func NewCompiler(opts CompileOptions, size int) *Compiler {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+
+	return &Compiler{
+		opts:    opts,
+		size:    size,
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Compile returns the Go source of the file whose AST JSON is astJSON,
+// compiling it unless identical input was compiled before. Errors aren't
+// cached, and diagnostics are only reported when the input is compiled.
+func (c *Compiler) Compile(astJSON []byte) (string, error) {
+	key := sha256.Sum256(astJSON)
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		c.hits++
+		c.mu.Unlock()
+
+		return e.Value.(*cacheEntry).source, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	f := &ast.File{}
+	if err := json.Unmarshal(astJSON, f); err != nil {
+		return "", err
+	}
+	res, err := Compile(f, c.opts)
+	if err != nil {
+		return "", err
+	}
+	source := res.Source
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, source: source})
+		for c.lru.Len() > c.size {
+			oldest := c.lru.Back()
+			c.lru.Remove(oldest)
+			delete(c.entries, oldest.Value.(*cacheEntry).key)
+		}
+	}
+
+	return source, nil
+}
+
+// Stats returns the number of compilations served from the cache and the
+// number of inputs compiled.
+func (c *Compiler) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hits, c.misses
+}
+
+// Len returns the number of cached files.
+func (c *Compiler) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// Reset empties the cache and its stats.
+func (c *Compiler) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[[sha256.Size]byte]*list.Element)
+	c.lru.Init()
+	c.hits, c.misses = 0, 0
+}
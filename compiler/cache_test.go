@@ -0,0 +1,78 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// logJSON returns the AST JSON of `console.log(msg)`
+func logJSON(msg string) []byte {
+	id := func(name string) string {
+		return fmt.Sprintf(`{"type":"Identifier",%s,"name":%q}`, loc, name)
+	}
+	arg := fmt.Sprintf(`{"type":"StringLiteral",%s,"extra":{"rawValue":%q,"raw":"'%s'"},"value":%q}`, loc, msg, msg, msg)
+	callee := fmt.Sprintf(`{"type":"MemberExpression",%s,"object":%s,"property":%s,"computed":false}`, loc, id("console"), id("log"))
+	stmt := fmt.Sprintf(`{"type":"ExpressionStatement",%s,"expression":{"type":"CallExpression",%s,"callee":%s,"arguments":[%s]}}`, loc, loc, callee, arg)
+
+	return []byte(fmt.Sprintf(`{"type":"File",%s,"program":{"type":"Program",%s,"sourceType":"script","body":[%s],"directives":[]}}`, loc, loc, stmt))
+}
+
+func TestCompiler_Cache(t *testing.T) {
+	c := NewCompiler(CompileOptions{}, 0)
+
+	first, err := c.Compile(logJSON("hello"))
+	if err != nil {
+		t.Fatalf("error compiling: %s", err)
+	}
+	if want := `Console_Log([]Object{JSString("hello")})`; !strings.Contains(first, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, first)
+	}
+
+	second, err := c.Compile(logJSON("hello"))
+	if err != nil {
+		t.Fatalf("error compiling: %s", err)
+	}
+	if second != first {
+		t.Fatalf("cached code differs:\n%s\n%s", first, second)
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 1 {
+		t.Fatalf("want 1 hit and 1 miss, got %d hits and %d misses", hits, misses)
+	}
+
+	other, err := c.Compile(logJSON("bye"))
+	if err != nil {
+		t.Fatalf("error compiling: %s", err)
+	}
+	if !strings.Contains(other, `JSString("bye")`) {
+		t.Fatalf("different input isn't recompiled:\n%s", other)
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 2 {
+		t.Fatalf("want 1 hit and 2 misses, got %d hits and %d misses", hits, misses)
+	}
+
+	c.Reset()
+	if hits, misses := c.Stats(); hits != 0 || misses != 0 || c.Len() != 0 {
+		t.Fatalf("want an empty cache after reset, got %d entries, %d hits and %d misses", c.Len(), hits, misses)
+	}
+}
+
+func TestCompiler_CacheSize(t *testing.T) {
+	c := NewCompiler(CompileOptions{}, 2)
+	for _, msg := range []string{"a", "b", "a", "c"} {
+		if _, err := c.Compile(logJSON(msg)); err != nil {
+			t.Fatalf("error compiling: %s", err)
+		}
+	}
+
+	if c.Len() != 2 {
+		t.Fatalf("want 2 cached files, got %d", c.Len())
+	}
+
+	// b is the least recently used, evicted when c was cached
+	c.Compile(logJSON("a"))
+	c.Compile(logJSON("b"))
+	if hits, misses := c.Stats(); hits != 2 || misses != 4 {
+		t.Fatalf("want 2 hits and 4 misses, got %d hits and %d misses", hits, misses)
+	}
+}
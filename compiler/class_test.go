@@ -0,0 +1,84 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_ClassProperties(t *testing.T) {
+	// class Counter {
+	//   count = 1;
+	//   label;
+	//   static instances = 0;
+	// }
+	// let c = new Counter()
+	f := file(
+		class("Counter",
+			field(ident("count"), num(1), false),
+			field(ident("label"), nil, false),
+			field(ident("instances"), num(0), true),
+		),
+		varDecl("let", "c", newExpr(ident("Counter"))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		`Counter_ = NewClass("Counter", func(this *JSObject) {`,
+		`Set(this, JSString("count"), JSNumber(1))`,
+		`Set(this, JSString("label"), nil)`,
+		`Set(Counter_, JSString("instances"), JSNumber(0))`,
+		`c = New(Counter_, []Object{})`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+	if strings.Index(code, "Set(this, JSString(\"count\")") > strings.Index(code, "Set(Counter_") {
+		t.Fatalf("static fields should be defined after the class:\n%s", code)
+	}
+}
+
+func TestCompile_ClassComputedFieldKey(t *testing.T) {
+	// class C { [k] = 1 }
+	f := file(class("C", &ast.ClassProperty{
+		Attr:     attr("ClassProperty"),
+		Key:      ident("k"),
+		Value:    num(1),
+		Computed: true,
+	}))
+
+	code := compile(t, f, CompileOptions{})
+	// the key is evaluated once, when the class is defined
+	if want := "k1 := global.Resolve(\"k\")\nC_ = NewClass(\"C\", func(this *JSObject) {\nSet(this, k1, "; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_ClassInheritance(t *testing.T) {
+	cd := class("B")
+	cd.SuperClass = ident("A")
+
+	_, err := Compile(file(cd), CompileOptions{})
+	if err == nil || !strings.Contains(err.Error(), "class inheritance is not supported") {
+		t.Fatalf("expected an inheritance error, got %v", err)
+	}
+}
+
+func class(name string, fields ...*ast.ClassProperty) *ast.ClassDeclaration {
+	body := &ast.ClassBody{Attr: attr("ClassBody")}
+	for _, f := range fields {
+		body.Body = append(body.Body, f)
+	}
+
+	return &ast.ClassDeclaration{Attr: attr("ClassDeclaration"), ID: ident(name), Body: body}
+}
+
+func field(key, value ast.Expression, static bool) *ast.ClassProperty {
+	return &ast.ClassProperty{Attr: attr("ClassProperty"), Key: key, Value: value, Static: static}
+}
+
+func newExpr(callee ast.Expression, args ...ast.Expression) *ast.NewExpression {
+	return &ast.NewExpression{Attr: attr("NewExpression"), Callee: callee, Arguments: args}
+}
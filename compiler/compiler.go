@@ -2,6 +2,11 @@ package compiler
 
 import (
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/jingweno/godzilla/ast"
 	"github.com/jingweno/godzilla/runtime"
@@ -9,176 +14,2359 @@ import (
 	"github.com/jingweno/godzilla/utils"
 )
 
-func Compile(f *ast.File) *source.Code {
+// CompileOptions configures how a file is compiled.
+type CompileOptions struct {
+	// Mangler maps JavaScript identifiers to Go identifiers.
+	// DefaultMangler is used when it's nil.
+	Mangler NameMangler
+	// Resolver maps the modules imported by the file to Go packages.
+	// A BaseResolver without base package is used when it's nil.
+	Resolver ModuleResolver
+	// Package is the name of the Go package the files are compiled to,
+	// main when it's empty. The files of a module other modules import,
+	// e.g. one with exports, must be compiled to another package, as Go
+	// can't import main: their top-level declarations are package level
+	// vars, the exported ones being importable, and their top-level
+	// statements run in an init function.
+	Package string
+	// InferTypes declares variables with concrete Go types instead of
+	// Object when their type can be inferred. With a GoVersion having
+	// generics, the map and filter calls over arrays of literals of one type
+	// compile to generic helpers over Go slices of that type.
+	InferTypes bool
+	// TypeAnnotations declares the let and const variables and the
+	// parameters annotated with TypeScript types, e.g. `let x: number`,
+	// with Go types instead of Object. Values assigned to them are
+	// converted to their type.
+	TypeAnnotations bool
+	// JSDocTypes declares the parameters of the function declarations
+	// documented with JSDoc types, e.g. `@param {number} x`, with Go types
+	// instead of Object, as TypeAnnotations does. The values returned by
+	// the ones documented with `@returns {string}` are converted to it.
+	JSDocTypes bool
+	// InlineIIFEs compiles the immediately-invoked function expressions at
+	// the top level to blocks of their body, when that doesn't change what
+	// the program does.
+	InlineIIFEs bool
+	// FileHeader is a comment, e.g. a provenance notice, written at the top
+	// of the generated files. Its lines are turned into line comments if
+	// they aren't already.
+	FileHeader string
+	// BuildTags are build constraint expressions, e.g. "linux" or
+	// "!windows", the generated files are constrained to. They're combined
+	// into a single //go:build line.
+	BuildTags []string
+	// EmitBuildInfo writes a comment after the build constraint of the
+	// generated files stating the Version of godzilla and a hash of the
+	// AST they're compiled from, e.g. to tell whether they're up to date.
+	EmitBuildInfo bool
+	// WrapMain declares the top-level variables and functions of a file
+	// compiled with Compile at package level, leaving only its top-level
+	// statements to run in main. Function declarations are assigned in an
+	// init function, ahead of main as JavaScript hoists them.
+	WrapMain bool
+	// Diagnostics collects the errors, warnings and notes about compiling,
+	// so that callers can tell whether to use the output despite warnings.
+	// They're discarded when it's nil.
+	Diagnostics *Diagnostics
+	// GoVersion is the Go version, e.g. "1.21", the generated code is
+	// built with. Output forms only available in newer Go versions, such
+	// as the min and max builtins, are used when it has them. The ones
+	// every Go version has are used when it's empty.
+	GoVersion string
+	// FeatureStats collects the counts of the node types and features
+	// used by the compiled files, including the ones failing to compile,
+	// e.g. to tell which features to support first. They're discarded when
+	// it's nil.
+	FeatureStats *FeatureStats
+	// PreserveLineNumbers pads the generated code with blank lines so that
+	// the code of each statement is on the line of its JavaScript source,
+	// when the code before it doesn't take more lines than the source. The
+	// package clause, the imports and the declarations ahead of the
+	// compiled statements take the first lines of a file, so the statements
+	// on them are offset. gofmt collapses the padding, so the generated
+	// files must not be formatted, which a build.Builder doesn't.
+	PreserveLineNumbers bool
+	// Enums compiles the consts of frozen objects of literals of a single
+	// type, e.g. `const Color = Object.freeze({RED: 0, GREEN: 1})`, to Go
+	// constants of a named type, e.g. `Color_RED Color_ = 0.0`, when only
+	// their members are ever read.
+	Enums bool
+	// TestFuncs compiles the top-level `test("name", fn)` and
+	// `it("name", fn)` calls of Jest and Mocha test files to Go test
+	// functions calling fn, e.g. `func TestAdds(_ *testing.T)` for
+	// `test("adds", fn)`, to be written to a _test.go file. Compile wraps
+	// main when set, so that the tests see the top-level declarations, and
+	// runs the top-level statements in an init function, which go test runs
+	// ahead of the tests, leaving main empty.
+	TestFuncs bool
+	// Trace is written a line for each node compiled, e.g.
+	// "1:0: CallExpression", in the order they're compiled, and for each
+	// diagnostic reported, e.g. "1:0: warning: debugger statement is
+	// ignored", to debug the generated code. Compilations sharing it must
+	// not run concurrently. Nothing is traced when it's nil.
+	Trace io.Writer
+}
+
+// CompileResult is a compiled file.
+type CompileResult struct {
+	// Source is the Go source of the file.
+	Source string
+	// Imports are the import specs of the Go file, e.g. `"regexp"`.
+	Imports []string
+	// Symbols maps the JavaScript names of the top-level declarations of
+	// the file to the Go identifiers they're compiled to, which are package
+	// level when main is wrapped.
+	Symbols map[string]string
+}
+
+// Compile compiles f to the Go source of a main package, or of the Package
+// of opts.
+func Compile(f *ast.File, opts CompileOptions) (*CompileResult, error) {
+	opts.FeatureStats.add(f)
+	header, err := fileHeader(opts, f)
+	if err != nil {
+		return nil, err
+	}
+	if opts.InlineIIFEs {
+		f = newIIFEInliner(f).inline(f)
+	}
+	if opts.GoVersion, err = goVersion(opts); err != nil {
+		return nil, err
+	}
+	if opts.Package, err = packageName(opts); err != nil {
+		return nil, err
+	}
+
+	// go test runs the init functions of a package, but not its main
+	initCode := opts.Package != "main" || opts.TestFuncs
 	code := source.NewCode()
+	if initCode {
+		code = source.NewInitCode()
+	}
+	c := newCompiler(code, newScope(nil), opts)
+	c.code.SetHeader(header)
+	if initCode || opts.WrapMain {
+		c.pkgLevel, c.wrapMain = true, true
+		if initCode {
+			c.writeGlobal()
+		} else {
+			c.code.WriteDecl("var global = NewDefaultContext().Global")
+		}
+		if err := c.declareTopLevel(f.Program); err != nil {
+			return nil, err
+		}
+	} else {
+		c.code.WriteLine("global := NewDefaultContext().Global")
+		c.code.WriteLine("_ = global")
+	}
+	if err := c.compile(f); err != nil {
+		return nil, err
+	}
+
+	return &CompileResult{
+		Source:  c.code.String(),
+		Imports: c.code.Imports(),
+		Symbols: c.module.symbols(),
+	}, nil
+}
+
+// CompileProgram compiles files into the same Go package and returns the Go
+// source of each file keyed by its name. Files share a module scope: their
+// top-level declarations become package level vars, while their top-level
+// statements run in the init function of each file, in name order.
+func CompileProgram(files map[string]*ast.File, opts CompileOptions) (map[string]string, error) {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		opts.FeatureStats.add(files[name])
+	}
+
+	if opts.InlineIIFEs {
+		var all []*ast.File
+		for _, name := range names {
+			all = append(all, files[name])
+		}
+
+		in := newIIFEInliner(all...)
+		inlined := make(map[string]*ast.File)
+		for _, name := range names {
+			inlined[name] = in.inline(files[name])
+		}
+		files = inlined
+	}
+
+	var err error
+	if opts.GoVersion, err = goVersion(opts); err != nil {
+		return nil, err
+	}
+	if opts.Package, err = packageName(opts); err != nil {
+		return nil, err
+	}
+
+	module := newScope(nil)
+	compilers := make(map[string]*compiler)
+	for _, name := range names {
+		header, err := fileHeader(opts, files[name])
+		if err != nil {
+			return nil, err
+		}
+
+		c := newCompiler(source.NewInitCode(), module, opts)
+		c.code.SetHeader(header)
+		c.pkgLevel = true
+		if err := c.declareTopLevel(files[name].Program); err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+
+		compilers[name] = c
+	}
+
+	out := make(map[string]string)
+	for i, name := range names {
+		c := compilers[name]
+		if i == 0 {
+			c.writeGlobal()
+		}
+		if err := c.compile(files[name]); err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+
+		out[name] = c.code.String()
+	}
+
+	return out, nil
+}
 
+func newCompiler(code *source.Code, module *scope, opts CompileOptions) *compiler {
 	c := &compiler{
-		code: code,
-		ctx:  runtime.NewDefaultContext(),
+		code:      code,
+		ctx:       runtime.NewDefaultContext(),
+		mangler:   opts.Mangler,
+		resolver:  opts.Resolver,
+		infer:     opts.InferTypes,
+		annotate:  opts.TypeAnnotations,
+		jsdoc:     opts.JSDocTypes,
+		enums:     opts.Enums,
+		diags:     opts.Diagnostics,
+		goVersion: opts.GoVersion,
+		testFuncs: opts.TestFuncs,
+		pkg:       opts.Package,
+		testNames: make(map[string]bool),
+		trace:     opts.Trace,
+		module:    module,
+		scope:     module,
+		imports:   newScope(nil),
+	}
+	if c.mangler == nil {
+		c.mangler = DefaultMangler{}
+	}
+	if c.resolver == nil {
+		c.resolver = BaseResolver{}
+	}
+	if c.diags == nil {
+		c.diags = &Diagnostics{}
+	}
+	if c.pkg == "" {
+		c.pkg = "main"
 	}
-	c.compile(f)
+	if opts.PreserveLineNumbers {
+		c.code.PreserveLineNumbers()
+	}
+	c.code.SetPackage(c.pkg)
+	c.code.Import(source.RuntimeImport)
 
-	return code
+	return c
 }
 
 type compiler struct {
-	code *source.Code
-	ctx  *runtime.Context
+	code     *source.Code
+	ctx      *runtime.Context
+	mangler  NameMangler
+	resolver ModuleResolver
+	infer    bool
+	annotate bool
+	jsdoc    bool
+	enums    bool
+	types    map[*ast.VariableDeclarator]*inferredType
+	// paramTypes and returnTypes are the Go types of the parameters and of
+	// the values returned by the functions, keyed by their bodies, which
+	// JSDoc documents
+	paramTypes  map[*ast.Identifier]string
+	returnTypes map[*ast.BlockStatement]string
+	// returnType is the Go type the values returned by the function being
+	// compiled are converted to, if any
+	returnType string
+	diags      *Diagnostics
+	// goVersion is the Go version, e.g. "go1.21", the generated code targets
+	goVersion string
+
+	module *scope
+	scope  *scope
+	// imports holds the import bindings of the file, which aren't shared
+	// with the other files of the module scope
+	imports *scope
+	modules []*moduleImport
+	// exports maps the names of the file exported by export specifiers to
+	// the names they're exported as
+	exports map[string]string
+	temps   int
+	labels  *labels
+	// loopCopies are the per-iteration copies of the loops being compiled
+	// which are copied back to their loop variables
+	loopCopies []*loopCopy
+	// orderedReads are the variables the statement being compiled reads in
+	// calls, as it updates them in expressions, see orderedReads
+	orderedReads map[string]bool
+	// loc is the location of the last node tracked
+	loc *ast.SourceLocation
+	// pkgLevel tells whether module scope vars are declared at package
+	// level rather than in the body
+	pkgLevel bool
+	// wrapMain tells whether module scope functions are assigned in an init
+	// function rather than in the body
+	wrapMain bool
+	// testFuncs tells whether top-level test calls are compiled to Go test
+	// functions, whose names are in testNames
+	testFuncs bool
+	testNames map[string]bool
+	// pkg is the name of the Go package the file is compiled to
+	pkg string
+	// trace is written the trace of the compilation, if it's traced
+	trace io.Writer
 }
 
-func (c *compiler) compile(f *ast.File) {
+func (c *compiler) compile(f *ast.File) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ce, ok := r.(*CompileError)
+			if !ok {
+				ce = c.internalError(r)
+			}
+
+			c.diags.Errorf(ce.Loc, "%s", ce.Msg)
+			c.traceDiagnostic(SeverityError, ce.Loc, ce.Msg)
+			err = ce
+		}
+	}()
+
+	if c.infer || c.annotate || c.enums {
+		c.types = inferTypes(f.Program, c.infer, c.annotate, c.enums)
+	}
+	if c.jsdoc {
+		c.paramTypes, c.returnTypes = jsdocTypes(f.Program)
+	}
 	c.compileProgram(f.Program)
+	c.writeImports()
+
+	return nil
+}
+
+func (c *compiler) compileProgram(p *ast.Program) {
+	c.exports = specifierExports(p)
+	c.hoistVars(p.Body)
+	c.compileStatements(p.Body)
+}
+
+// declareTopLevel declares the top-level names of p in the module scope
+// ahead of compiling, so that files can reference each other's
+// declarations regardless of the order they're compiled in.
+func (c *compiler) declareTopLevel(p *ast.Program) error {
+	c.exports = specifierExports(p)
+	declare := func(name, kind string, exported bool) error {
+		if b := c.module.lookupLocal(name); b != nil {
+			if isLexical(b.kind) || isLexical(kind) {
+				return fmt.Errorf("identifier %q has already been declared", name)
+			}
+
+			return nil
+		}
+
+		switch {
+		case name == "default":
+			c.declareDefaultExport()
+		case exported:
+			c.declareExportedVar(name, kind)
+		default:
+			c.declareVar(name, kind)
+		}
+		return nil
+	}
+
+	var declareStatement func(s ast.Statement, exported bool) error
+	declareStatement = func(s ast.Statement, exported bool) error {
+		switch v := s.(type) {
+		case *ast.VariableDeclaration:
+			for _, d := range v.Declarations {
+				for _, id := range ast.BoundIdentifiers(d.ID) {
+					if err := declare(id.Name, v.Kind, exported); err != nil {
+						return err
+					}
+				}
+			}
+		case *ast.FunctionDeclaration:
+			if err := declare(v.ID.Name, "function", exported); err != nil {
+				return err
+			}
+		case *ast.ClassDeclaration:
+			if err := declare(v.ID.Name, "class", exported); err != nil {
+				return err
+			}
+		case *ast.ExportNamedDeclaration:
+			if v.Declaration != nil {
+				return declareStatement(v.Declaration, true)
+			}
+		case *ast.ExportDefaultDeclaration:
+			if name, kind := defaultExportName(v); name != "" {
+				if err := declare(name, kind, true); err != nil {
+					return err
+				}
+			}
+			return declare("default", "const", true)
+		default:
+			for _, name := range ast.HoistedVariables(s) {
+				if err := declare(name, "var", exported); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	for _, s := range p.Body {
+		if err := declareStatement(s, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isLexical(kind string) bool {
+	return kind == "let" || kind == "const" || kind == "class"
+}
+
+// declareVar declares name in the current scope and writes its Go var
+func (c *compiler) declareVar(name, kind string) *binding {
+	return c.declareGoVar(name, c.localName(name), kind)
+}
+
+// localName returns the Go name of a variable declared in the current
+// scope, which is exported when it's a module scope name an export
+// specifier exports
+func (c *compiler) localName(name string) string {
+	if exported, ok := c.exports[name]; ok && c.scope == c.module {
+		return c.importedName(exported)
+	}
+
+	return c.mangler.Mangle(name)
+}
+
+// declareExportedVar declares an exported name, whose Go var is exported
+func (c *compiler) declareExportedVar(name, kind string) *binding {
+	return c.declareGoVar(name, exportName(c.mangler, name), kind)
+}
+
+func (c *compiler) declareGoVar(name, goName, kind string) *binding {
+	b := c.scope.declare(name, goName, kind)
+	if c.pkgLevel && c.scope == c.module {
+		c.code.WriteDecl(fmt.Sprintf("var %s Object", b.goName))
+	} else {
+		c.code.WriteLine(fmt.Sprintf("var %s Object", b.goName))
+		c.code.WriteLine(fmt.Sprintf("_ = %s", b.goName))
+	}
+
+	return b
+}
+
+// hoistVars declares the vars of a function or program body ahead of its
+// statements, as JavaScript hoists them to the top of the function. Their
+// declarations are compiled to assignments in place.
+func (c *compiler) hoistVars(body []ast.Statement) {
+	for _, s := range body {
+		c.track(s)
+		exported := false
+		if en, ok := s.(*ast.ExportNamedDeclaration); ok && en.Declaration != nil {
+			s, exported = en.Declaration, true
+		}
+
+		for _, name := range ast.HoistedVariables(s) {
+			if c.scope.lookupLocal(name) != nil {
+				continue
+			}
+
+			if exported {
+				c.declareExportedVar(name, "var")
+			} else {
+				c.declareVar(name, "var")
+			}
+		}
+	}
+}
+
+// defineGlobal makes a module scope var visible as a property of the global
+// object
+func (c *compiler) defineGlobal(name string, b *binding) {
+	if c.module.lookupLocal(name) == b {
+		c.code.WriteLine(fmt.Sprintf(`global.DefineProperty("%s", %s)`, name, b.value()))
+	}
+}
+
+// tempVar returns the name of a new Go var holding intermediate values,
+// which doesn't shadow any visible binding.
+func (c *compiler) tempVar(prefix string) string {
+	for {
+		c.temps++
+		name := fmt.Sprintf("%s%d", prefix, c.temps)
+		if !c.scope.isGoNameUsed(name) {
+			return name
+		}
+	}
+}
+
+// lookup finds the binding of name visible in the current scope, including
+// the import bindings of the file.
+func (c *compiler) lookup(name string) *binding {
+	if b := c.scope.lookup(name); b != nil {
+		return b
+	}
+
+	return c.imports.lookupLocal(name)
+}
+
+func (c *compiler) pushScope() {
+	c.scope = newScope(c.scope)
+}
+
+func (c *compiler) popScope() {
+	c.scope = c.scope.parent
+}
+
+// statements
+
+// compileStatements compiles a list of statements, hoisting import and
+// function declarations to the top as JavaScript does. The references to
+// let, const and class declarations ahead of them are warned about, as they
+// throw in JavaScript.
+func (c *compiler) compileStatements(body []ast.Statement) {
+	for _, id := range ast.TDZReferences(body) {
+		c.warnf(id, "%s is referenced before its declaration", id.Name)
+	}
+
+	var funcs []ast.Statement
+	var stmts []ast.Statement
+	for _, s := range body {
+		if id, ok := s.(*ast.ImportDeclaration); ok {
+			c.writeLineNo(s)
+			c.compileImportDeclaration(id)
+		} else if fd := hoistedFunction(s); fd != nil {
+			if c.scope.lookupLocal(fd.ID.Name) == nil {
+				if isExport(s) {
+					c.declareExportedVar(fd.ID.Name, "function")
+				} else {
+					c.declareVar(fd.ID.Name, "function")
+				}
+			}
+
+			funcs = append(funcs, s)
+		} else {
+			stmts = append(stmts, s)
+		}
+	}
+
+	c.declareCaptured(stmts, funcs)
+	compileFuncs := func() {
+		for _, s := range funcs {
+			c.writeLineNo(s)
+			c.compileStatement(s)
+			c.code.WriteLine("")
+		}
+	}
+	if c.wrapMain && c.scope == c.module && len(funcs) > 0 {
+		c.code.WriteDecl("func init() {\n" + strings.TrimSpace(c.code.Capture(compileFuncs)) + "\n}")
+	} else {
+		compileFuncs()
+	}
+
+	for _, s := range stmts {
+		if c.compileTestFunc(s) {
+			continue
+		}
+
+		c.writeLineNo(s)
+		c.compileStatement(s)
+		c.code.WriteLine("")
+	}
+}
+
+// hoistedFunction returns the named function s declares, if any
+func hoistedFunction(s ast.Statement) *ast.FunctionDeclaration {
+	var fd *ast.FunctionDeclaration
+	switch v := s.(type) {
+	case *ast.FunctionDeclaration:
+		fd = v
+	case *ast.ExportNamedDeclaration:
+		fd, _ = v.Declaration.(*ast.FunctionDeclaration)
+	case *ast.ExportDefaultDeclaration:
+		fd, _ = v.Declaration.(*ast.FunctionDeclaration)
+	}
+	if fd == nil || fd.ID == nil {
+		return nil
+	}
+
+	return fd
+}
+
+// declareCaptured declares the let, const and class names of stmts which
+// the hoisted funcs reference, ahead of the funcs, so that they capture the
+// Go vars the declarations later assign rather than resolving the names as
+// globals. Vars are already declared by hoistVars.
+func (c *compiler) declareCaptured(stmts, funcs []ast.Statement) {
+	captured := func(name string) bool {
+		for _, fd := range funcs {
+			if isCaptured(fd, name) {
+				return true
+			}
+		}
+		return false
+	}
+	declare := func(name, kind string, exported bool) {
+		if c.scope.lookupLocal(name) != nil || !captured(name) {
+			return
+		}
+		if exported {
+			c.declareExportedVar(name, kind)
+		} else {
+			c.declareVar(name, kind)
+		}
+	}
+
+	for _, s := range stmts {
+		exported := false
+		if en, ok := s.(*ast.ExportNamedDeclaration); ok && en.Declaration != nil {
+			s, exported = en.Declaration, true
+		}
+
+		switch v := s.(type) {
+		case *ast.VariableDeclaration:
+			if !isLexical(v.Kind) {
+				continue
+			}
+			for _, d := range v.Declarations {
+				for _, id := range ast.BoundIdentifiers(d.ID) {
+					declare(id.Name, v.Kind, exported)
+				}
+			}
+		case *ast.ClassDeclaration:
+			declare(v.ID.Name, "class", exported)
+		}
+	}
+}
+
+// isExport tells whether s is an export declaration
+func isExport(s ast.Statement) bool {
+	switch s.(type) {
+	case *ast.ExportNamedDeclaration, *ast.ExportDefaultDeclaration:
+		return true
+	}
+
+	return false
+}
+
+func (c *compiler) compileStatement(s ast.Statement) {
+	c.track(s)
+	if c.trace != nil {
+		c.traceNode(s)
+	}
+	reads := c.orderedReads
+	c.orderedReads = orderedReads(s)
+	defer func() { c.orderedReads = reads }()
+	switch v := s.(type) {
+	case *ast.ExpressionStatement:
+		c.compileExpressionStatement(v)
+	case *ast.VariableDeclaration:
+		c.compileVariableDeclaration(v)
+	case *ast.FunctionDeclaration:
+		c.compileFunctionDeclaration(v)
+	case *ast.ClassDeclaration:
+		c.compileClassDeclaration(v)
+	case *ast.BlockStatement:
+		c.compileBlockStatement(v)
+	case *ast.ReturnStatement:
+		c.compileReturnStatement(v)
+	case *ast.ForStatement:
+		c.compileForStatement(v)
+	case *ast.ForOfStatement:
+		c.compileForOfStatement(v)
+	case *ast.DoWhileStatement:
+		c.compileDoWhileStatement(v)
+	case *ast.SwitchStatement:
+		c.compileSwitchStatement(v)
+	case *ast.LabeledStatement:
+		c.compileLabeledStatement(v)
+	case *ast.BreakStatement:
+		c.compileBreakStatement(v)
+	case *ast.ContinueStatement:
+		c.compileContinueStatement(v)
+	case *ast.WithStatement:
+		c.errorf(v, "with statement is not supportable")
+	case *ast.DebuggerStatement:
+		c.warnf(v, "debugger statement is ignored")
+	case *ast.ImportDeclaration:
+		c.errorf(v, "import declarations may only appear at the top level of a module")
+	case *ast.ExportNamedDeclaration:
+		c.checkImportable(v)
+		c.compileExportNamedDeclaration(v)
+	case *ast.ExportDefaultDeclaration:
+		c.checkImportable(v)
+		c.compileExportDefaultDeclaration(v)
+	default:
+		panic("unknown statement type " + utils.TypeOf(v))
+	}
+}
+
+func (c *compiler) compileExpressionStatement(es *ast.ExpressionStatement) {
+	c.compileSideEffect(es.Expression)
+}
+
+// compileSideEffect compiles e, whose value is unused, to a Go statement:
+// an update compiles to the assignment of the variable rather than to a func
+// literal returning its value as well, e.g. `i = ToNumber(i) + 1` for `i++`.
+// Calls, assignments and logical expressions, which compile to calls of
+// Or, And and Coalesce, are Go statements themselves, the value of any
+// other expression is assigned to the blank identifier, e.g. `_ = x` for
+// `x`.
+func (c *compiler) compileSideEffect(e ast.Expression) {
+	ue, ok := e.(*ast.UpdateExpression)
+	if !ok {
+		switch e.(type) {
+		case *ast.CallExpression, *ast.NewExpression, *ast.OptionalCallExpression, *ast.AssignmentExpression, *ast.LogicalExpression:
+		default:
+			c.code.Write("_ = ")
+		}
+		c.compileExpression(e)
+		return
+	}
+
+	c.track(ue)
+	if c.trace != nil {
+		c.traceNode(ue)
+	}
+	c.compileUpdateStatement(ue)
+}
+
+func (c *compiler) compileBlockStatement(bs *ast.BlockStatement) {
+	c.pushScope()
+	defer c.popScope()
+
+	c.code.WriteLine("{")
+	c.compileStatements(bs.Body)
+	c.code.Write("}")
+}
+
+func (c *compiler) compileReturnStatement(rs *ast.ReturnStatement) {
+	if rs.Argument == nil {
+		c.code.Write("return nil")
+		return
+	}
+
+	c.compileReturn(rs.Argument)
+}
+
+// compileReturn compiles the return of the value of e. The leading
+// expressions of a sequence are evaluated as statements ahead of the return
+// of the last one, e.g. `return (log(), value)`.
+func (c *compiler) compileReturn(e ast.Expression) {
+	if seq, ok := e.(*ast.SequenceExpression); ok {
+		last := len(seq.Expressions) - 1
+		for _, e := range seq.Expressions[:last] {
+			c.compileSideEffect(e)
+			c.code.WriteLine("")
+		}
+		e = seq.Expressions[last]
+	}
+
+	if c.returnType != "" {
+		c.compileTypedReturn(c.returnType, e)
+		return
+	}
+
+	c.code.Write("return ")
+	c.compileExpression(e)
+}
+
+// compileSwitchStatement compiles a switch statement to a tagless Go switch
+// in its own block, comparing the discriminant, evaluated once, to the case
+// tests with strict equality. Stacked empty cases are grouped in a single
+// case clause listing their tests, and other cases fall through to the next
+// one unless they end with a jump. The let, const, function and class
+// declarations of the cases are declared ahead of the Go switch, as they're
+// shared by all the cases whereas each Go case clause is a scope of its own.
+// A typeof discriminant is a JSString, which string literal tests are
+// compared to with ==, e.g. `case s1 == "number":`.
+func (c *compiler) compileSwitchStatement(ss *ast.SwitchStatement) {
+	ue, ok := ss.Discriminant.(*ast.UnaryExpression)
+	typeOf := ok && ue.Operator == "typeof"
+
+	c.code.WriteLine("{")
+	d := c.tempVar("s")
+	c.code.Write(d + " := ")
+	c.compileExpression(ss.Discriminant)
+	c.code.WriteLine("")
+
+	c.pushScope()
+	defer c.popScope()
+	for _, sc := range ss.Cases {
+		for _, s := range sc.Consequent {
+			switch v := s.(type) {
+			case *ast.VariableDeclaration:
+				if isLexical(v.Kind) {
+					for _, vd := range v.Declarations {
+						for _, id := range ast.BoundIdentifiers(vd.ID) {
+							c.declareVar(id.Name, v.Kind)
+						}
+					}
+				}
+			case *ast.FunctionDeclaration:
+				c.declareVar(v.ID.Name, "function")
+			case *ast.ClassDeclaration:
+				c.declareVar(v.ID.Name, "class")
+			}
+		}
+	}
+
+	c.code.WriteLine("switch {")
+	var tests []string
+	for i, sc := range ss.Cases {
+		if sc.Test == nil {
+			c.code.WriteLine("default:")
+		} else {
+			if sl, ok := sc.Test.(*ast.StringLiteral); ok && typeOf {
+				tests = append(tests, fmt.Sprintf("%s == %s", d, strconv.Quote(sl.Value)))
+			} else {
+				tests = append(tests, c.code.Capture(func() {
+					c.code.Write(fmt.Sprintf("StrictEquals(%s, ", d))
+					c.compileExpression(sc.Test)
+					c.code.Write(")")
+				}))
+			}
+			// empty cases share the clause of the case after them, as in
+			// `case 1: case 2: ...`
+			if len(sc.Consequent) == 0 && i < len(ss.Cases)-1 && ss.Cases[i+1].Test != nil {
+				continue
+			}
+			c.code.WriteLine("case " + strings.Join(tests, ", ") + ":")
+			tests = nil
+		}
+
+		c.compileStatements(sc.Consequent)
+		if i < len(ss.Cases)-1 && !jumps(sc.Consequent) {
+			c.code.WriteLine("fallthrough")
+		}
+	}
+	c.code.WriteLine("}")
+	c.code.Write("}")
 }
 
-func (c *compiler) compileProgram(p *ast.Program) {
-	for _, s := range p.Body {
-		c.writeLineNo(s)
-		c.compileStatement(s)
-		c.code.WriteLine("")
+// jumps tells whether the last of stmts jumps elsewhere, so that the
+// statements after it are unreachable
+func jumps(stmts []ast.Statement) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+
+	switch v := stmts[len(stmts)-1].(type) {
+	case *ast.BreakStatement, *ast.ContinueStatement, *ast.ReturnStatement:
+		return true
+	case *ast.BlockStatement:
+		return jumps(v.Body)
+	}
+
+	return false
+}
+
+// compileForStatement compiles a for statement to a Go for loop in its own
+// block, with the init clause ahead of the loop. The let and const loop
+// variables the closures of the body capture are copied at the start of
+// each iteration, so that each closure sees the value of its iteration.
+func (c *compiler) compileForStatement(fs *ast.ForStatement) {
+	c.pushScope()
+	defer c.popScope()
+
+	c.code.WriteLine("{")
+	vd, _ := fs.Init.(*ast.VariableDeclaration)
+	switch v := fs.Init.(type) {
+	case *ast.VariableDeclaration:
+		c.compileVariableDeclaration(v)
+	case ast.Expression:
+		c.compileSideEffect(v)
+		c.code.WriteLine("")
+	}
+
+	c.loopLabel(func() {
+		// the loop clauses are written the way gofmt would, e.g. `for {`
+		// without a test nor an update
+		switch {
+		case fs.Update != nil:
+			c.code.Write("for ; ")
+			if fs.Test != nil {
+				c.compileCondition(fs.Test)
+			}
+			c.code.Write("; ")
+			c.compileForUpdate(fs.Update)
+			c.code.WriteLine(" {")
+		case fs.Test != nil:
+			c.code.Write("for ")
+			c.compileCondition(fs.Test)
+			c.code.WriteLine(" {")
+		default:
+			c.code.WriteLine("for {")
+		}
+
+		c.pushScope()
+		defer c.popScope()
+		var copies []*loopCopy
+		if vd != nil {
+			copies = c.declareLoopCopies(vd, fs.Body)
+		}
+		loopCopies := c.loopCopies
+		c.loopCopies = append(c.loopCopies, copies...)
+		defer func() { c.loopCopies = loopCopies }()
+
+		c.compileLoopBody(fs.Body)
+		for _, lc := range copies {
+			c.code.WriteLine(lc.copyBack())
+		}
+		c.code.WriteLine("}")
+	})
+	c.code.Write("}")
+}
+
+// compileForOfStatement compiles a for...of loop to a Go range loop over the
+// values of its iterable, which is evaluated once ahead of the first
+// iteration, e.g. `for _, x := range Iterate(Call(f, []Object{})) {` for
+// `for (const x of f())`. A let or const loop variable is the range variable,
+// other loop variables are assigned it. for await...of loops await promises,
+// which the runtime doesn't have.
+func (c *compiler) compileForOfStatement(fs *ast.ForOfStatement) {
+	if fs.Await {
+		c.errorf(fs, "for await...of is not supported, there are no promises to await")
+	}
+	iterable := c.code.Capture(func() { c.compileExpression(fs.Right) })
+
+	c.pushScope()
+	defer c.popScope()
+
+	c.loopLabel(func() {
+		var target ast.Expression
+		// lexical is the kind of the lexical declaration of a pattern loop
+		// variable, whose names are declared in each iteration
+		var lexical string
+		switch v := fs.Left.(type) {
+		case *ast.VariableDeclaration:
+			target = v.Declarations[0].ID
+			if !isLexical(v.Kind) {
+				break
+			}
+			id, ok := target.(*ast.Identifier)
+			if !ok {
+				lexical = v.Kind
+				break
+			}
+
+			target = nil
+			b := c.scope.declare(id.Name, c.mangler.Mangle(id.Name), v.Kind)
+			c.code.WriteLine(fmt.Sprintf("for _, %s := range Iterate(%s) {", b.goName, iterable))
+			c.code.WriteLine(fmt.Sprintf("_ = %s", b.goName))
+			// range variables are per-iteration since Go 1.22
+			if !c.targets("go1.22") && isCaptured(fs.Body, id.Name) {
+				c.code.WriteLine(fmt.Sprintf("%s := %s", b.goName, b.goName))
+				c.code.WriteLine(fmt.Sprintf("_ = %s", b.goName))
+			}
+		case ast.Expression:
+			target = v
+		}
+		if target != nil {
+			value := c.tempVar("v")
+			c.code.WriteLine(fmt.Sprintf("for _, %s := range Iterate(%s) {", value, iterable))
+			if lexical != "" {
+				for _, id := range ast.BoundIdentifiers(target) {
+					c.declareVar(id.Name, lexical)
+				}
+			}
+			c.compileAssignTarget(target, value)
+		}
+
+		c.compileLoopBody(fs.Body)
+		c.code.WriteLine("}")
+	})
+}
+
+// compileDoWhileStatement compiles a do...while loop to a Go for loop
+// whose condition is skipped on the first iteration, e.g.
+// `for first1 := true; first1 || Truthy(x); first1 = false {` for
+// `do { ... } while (x)`. Unlike checking the test at the end of the body, a
+// continue still evaluates it, jumping to the post statement.
+func (c *compiler) compileDoWhileStatement(ds *ast.DoWhileStatement) {
+	c.loopLabel(func() {
+		first := c.tempVar("first")
+		c.code.Write(fmt.Sprintf("for %s := true; %s || ", first, first))
+		c.compileCondition(ds.Test)
+		c.code.WriteLine(fmt.Sprintf("; %s = false {", first))
+		c.compileLoopBody(ds.Body)
+		c.code.WriteLine("}")
+	})
+}
+
+// compileCondition compiles the test of a loop to a Go bool, which is
+// whether its value is truthy
+func (c *compiler) compileCondition(test ast.Expression) {
+	c.compileBool(test, false)
+}
+
+// compileBool compiles e to a Go bool, whether its value is truthy or, when
+// negate is set, falsy. Negations are folded into the bool rather than
+// negating it twice, e.g. `!!x` compiles to `Truthy(x)` and `!(a === b)` to
+// `!StrictEquals(a, b)`.
+func (c *compiler) compileBool(e ast.Expression, negate bool) {
+	switch v := e.(type) {
+	case *ast.UnaryExpression:
+		if v.Operator == "!" {
+			c.compileBool(v.Argument, !negate)
+			return
+		}
+	case *ast.BinaryExpression:
+		if v.Operator == "===" || v.Operator == "!==" {
+			if negate != (v.Operator == "!==") {
+				c.code.Write("!")
+			}
+			c.code.Write("StrictEquals(")
+			c.compileExpression(v.Left)
+			c.code.Write(", ")
+			c.compileExpression(v.Right)
+			c.code.Write(")")
+			return
+		}
+	}
+
+	if negate {
+		c.code.Write("!")
+	}
+	c.code.Write("Truthy(")
+	c.compileExpression(e)
+	c.code.Write(")")
+}
+
+// compileForUpdate compiles the update clause of a for loop, which must be
+// a single Go simple statement. Comma separated updates are compiled to a
+// parallel assignment when they update distinct variables independently of
+// each other, e.g. `i++, j--`, and to a func literal call otherwise.
+func (c *compiler) compileForUpdate(e ast.Expression) {
+	seq, ok := e.(*ast.SequenceExpression)
+	if !ok {
+		c.compileSideEffect(e)
+		return
+	}
+
+	if targets, values, ok := c.parallelAssignments(seq.Expressions); ok {
+		c.code.Write(strings.Join(targets, ", "))
+		c.code.Write(" = ")
+		c.code.Write(strings.Join(values, ", "))
+		return
+	}
+
+	c.code.WriteLine("func() {")
+	for _, e := range seq.Expressions {
+		c.compileSideEffect(e)
+		c.code.WriteLine("")
+	}
+	c.code.Write("}()")
+}
+
+// parallelAssignments compiles exprs to the targets and values of one
+// parallel assignment, which is only possible when they assign to distinct
+// declared variables without reading the variables others assign to.
+func (c *compiler) parallelAssignments(exprs []ast.Expression) (targets, values []string, ok bool) {
+	var names []string
+	for _, e := range exprs {
+		var target *ast.Identifier
+		switch v := e.(type) {
+		case *ast.UpdateExpression:
+			target, _ = v.Argument.(*ast.Identifier)
+		case *ast.AssignmentExpression:
+			if v.Operator == "=" {
+				target, _ = v.Left.(*ast.Identifier)
+			}
+		}
+		if target == nil || c.scope.lookup(target.Name) == nil {
+			return nil, nil, false
+		}
+
+		for _, name := range names {
+			if name == target.Name {
+				return nil, nil, false
+			}
+		}
+		names = append(names, target.Name)
+	}
+
+	for i, e := range exprs {
+		for _, free := range ast.FreeVariables(e) {
+			for j, name := range names {
+				if i != j && free == name {
+					return nil, nil, false
+				}
+			}
+		}
+	}
+
+	for _, e := range exprs {
+		switch v := e.(type) {
+		case *ast.UpdateExpression:
+			targets = append(targets, c.scope.lookup(v.Argument.(*ast.Identifier).Name).goName)
+			values = append(values, c.code.Capture(func() { c.compileUpdateValue(v) }))
+		case *ast.AssignmentExpression:
+			b := c.scope.lookup(v.Left.(*ast.Identifier).Name)
+			targets = append(targets, b.goName)
+			if b.goType != "" {
+				values = append(values, c.code.Capture(func() { c.compileTypedValue(b.goType, v.Right) }))
+			} else {
+				values = append(values, c.code.Capture(func() { c.compileExpression(v.Right) }))
+			}
+		}
+	}
+
+	return targets, values, true
+}
+
+// compileLoopBody compiles the body of a loop, which is already in braces
+func (c *compiler) compileLoopBody(s ast.Statement) {
+	if bs, ok := s.(*ast.BlockStatement); ok {
+		c.pushScope()
+		defer c.popScope()
+
+		c.compileStatements(bs.Body)
+		return
+	}
+
+	c.writeLineNo(s)
+	c.compileStatement(s)
+	c.code.WriteLine("")
+}
+
+// TODO: ignore Kind for now
+func (c *compiler) compileVariableDeclaration(vd *ast.VariableDeclaration) {
+	for i := 0; i < len(vd.Declarations); i++ {
+		if consts := c.typedConsts(vd.Kind, vd.Declarations[i:]); len(consts) > 0 {
+			c.compileTypedConsts(consts)
+			i += len(consts) - 1
+			continue
+		}
+
+		c.compileVariableDeclarator(vd.Kind, vd.Declarations[i])
+	}
+}
+
+func (c *compiler) compileVariableDeclarator(kind string, vd *ast.VariableDeclarator) {
+	id, ok := vd.ID.(*ast.Identifier)
+	if !ok {
+		c.compilePatternDeclarator(kind, vd)
+		return
+	}
+	name := id.Name
+
+	var b *binding
+	if kind == "var" {
+		// vars are hoisted to the scope of the enclosing function
+		b = c.scope.lookup(name)
+	} else {
+		b = c.scope.lookupLocal(name)
+	}
+	if b == nil {
+		if t := c.types[vd]; t != nil {
+			if t.enum != nil {
+				c.compileEnum(kind, vd, t)
+			} else {
+				c.compileTypedDeclarator(kind, vd, t)
+			}
+			return
+		}
+
+		b = c.declareVar(name, kind)
+	}
+	if vd.Init != nil {
+		c.code.Write(fmt.Sprintf("%s = ", b.goName))
+		c.compileExpression(vd.Init)
+		c.code.WriteLine("")
+	}
+	c.defineGlobal(name, b)
+}
+
+// compilePatternDeclarator declares the names the destructuring pattern of
+// vd binds and assigns them the elements or properties of its initializer,
+// e.g. `d1 := obj` and `b = Get(Get(d1, JSString("a")), JSNumber(0))` for
+// `const {a: [b]} = obj`
+func (c *compiler) compilePatternDeclarator(kind string, vd *ast.VariableDeclarator) {
+	if vd.Init == nil {
+		c.errorf(vd, "destructuring declaration %s has no initializer", vd)
+	}
+
+	ids := ast.BoundIdentifiers(vd.ID)
+	bindings := make([]*binding, len(ids))
+	for i, id := range ids {
+		if kind == "var" {
+			bindings[i] = c.scope.lookup(id.Name)
+		} else {
+			bindings[i] = c.scope.lookupLocal(id.Name)
+		}
+		if bindings[i] == nil {
+			bindings[i] = c.declareVar(id.Name, kind)
+		}
+	}
+
+	v := c.tempVar("d")
+	c.code.Write(v + " := ")
+	c.compileExpression(vd.Init)
+	c.code.WriteLine("")
+	c.compilePatternElements(vd.ID, v)
+	for i, id := range ids {
+		c.defineGlobal(id.Name, bindings[i])
+	}
+}
+
+// declarations
+
+func (c *compiler) compileFunctionDeclaration(fd *ast.FunctionDeclaration) {
+	name := fd.ID.Name
+
+	b := c.scope.lookupLocal(name)
+	if b == nil {
+		b = c.declareVar(name, "function")
+	}
+	c.code.Write(fmt.Sprintf("%s = ", b.goName))
+	c.compileFunction(fd.Params, fd.Body)
+	c.code.WriteLine("")
+	c.defineGlobal(name, b)
+}
+
+// compileClassDeclaration compiles a class to a JSClass whose init func
+// defines the instance fields on every new instance. Static fields are
+// properties of the class itself. Computed keys are evaluated once, when the
+// class is defined.
+func (c *compiler) compileClassDeclaration(cd *ast.ClassDeclaration) {
+	name := cd.ID.Name
+	b := c.scope.lookupLocal(name)
+	if b == nil {
+		b = c.declareVar(name, "class")
+	}
+
+	c.compileClass(name, b, cd)
+	c.defineGlobal(name, b)
+}
+
+// compileClass assigns the class cd, named name, to the Go var of b
+func (c *compiler) compileClass(name string, b *binding, cd *ast.ClassDeclaration) {
+	if cd.SuperClass != nil {
+		c.errorf(cd.SuperClass, "class inheritance is not supported")
+	}
+
+	var fields, statics []*ast.ClassProperty
+	keys := make(map[*ast.ClassProperty]string)
+	for _, m := range cd.Body.Body {
+		cp := m.(*ast.ClassProperty)
+		if cp.Static {
+			statics = append(statics, cp)
+			continue
+		}
+
+		fields = append(fields, cp)
+		if _, constant := constantValue(cp.Key); cp.Computed && !constant {
+			if c.isSymbol(cp.Key) {
+				c.errorf(cp.Key, "symbol key %s is not supported", cp.Key)
+			}
+			keys[cp] = c.tempVar("k")
+			c.code.Write(keys[cp] + " := ")
+			c.compileExpression(cp.Key)
+			c.code.WriteLine("")
+		}
+	}
+
+	c.code.WriteLine(fmt.Sprintf("%s = NewClass(%s, func(this *JSObject) {", b.goName, strconv.Quote(name)))
+	for _, cp := range fields {
+		c.code.Write("Set(this, ")
+		if k, ok := keys[cp]; ok {
+			c.code.Write(k)
+		} else {
+			c.compileMemberKey(cp.Key, cp.Computed)
+		}
+		c.code.Write(", ")
+		c.compileFieldValue(cp)
+		c.code.WriteLine(")")
+	}
+	c.code.WriteLine("})")
+
+	for _, cp := range statics {
+		c.code.Write(fmt.Sprintf("Set(%s, ", b.goName))
+		c.compileMemberKey(cp.Key, cp.Computed)
+		c.code.Write(", ")
+		c.compileFieldValue(cp)
+		c.code.WriteLine(")")
 	}
 }
 
-// statements
-
-func (c *compiler) compileStatement(s ast.Statement) {
-	switch v := s.(type) {
-	case *ast.ExpressionStatement:
-		c.compileExpressionStatement(v)
-	case *ast.VariableDeclaration:
-		c.compileVariableDeclaration(v)
-	default:
-		panic("unknown statement type " + utils.TypeOf(v))
+// compileFieldValue compiles the initializer of a class field, which is
+// undefined when missing
+func (c *compiler) compileFieldValue(cp *ast.ClassProperty) {
+	if cp.Value == nil {
+		c.code.Write("nil")
+		return
 	}
+
+	c.compileExpression(cp.Value)
 }
 
-func (c *compiler) compileExpressionStatement(es *ast.ExpressionStatement) {
-	c.compileExpression(es.Expression)
+// compileFunction compiles a function to a JSFunction whose parameters are
+// bound from the passed arguments
+func (c *compiler) compileFunction(params []ast.Expression, body *ast.BlockStatement) {
+	c.compileFunctionBody(params, func() {
+		c.returnType = c.returnTypes[body]
+		c.hoistVars(body.Body)
+		c.compileStatements(body.Body)
+		c.code.WriteLine("return nil")
+	})
 }
 
-// TODO: ignore Kind for now
-func (c *compiler) compileVariableDeclaration(vd *ast.VariableDeclaration) {
-	for _, d := range vd.Declarations {
-		c.compileVariableDeclarator(d)
+// compileFunctionBody compiles a function to a JSFunction whose Go func
+// binds params and runs the body compileBody writes. The arguments passed
+// for destructuring patterns are destructured into the names they bind.
+func (c *compiler) compileFunctionBody(params []ast.Expression, compileBody func()) {
+	c.pushScope()
+	defer c.popScope()
+	// the function has labels and a return type of its own
+	labels, loopCopies, returnType, reads := c.labels, c.loopCopies, c.returnType, c.orderedReads
+	c.labels, c.loopCopies, c.returnType, c.orderedReads = nil, nil, "", nil
+	defer func() {
+		c.labels, c.loopCopies, c.returnType, c.orderedReads = labels, loopCopies, returnType, reads
+	}()
+
+	c.code.WriteLine("NewFunction(func(args []Object) Object {")
+	for i, param := range params {
+		arg := fmt.Sprintf("Arg(args, %d)", i)
+		p, ok := param.(*ast.Identifier)
+		if !ok {
+			for _, id := range ast.BoundIdentifiers(param) {
+				c.declareGoVar(id.Name, c.mangler.Mangle(id.Name), "param")
+			}
+			c.compileAssignTarget(param, arg)
+			continue
+		}
+
+		b := c.scope.declare(p.Name, c.mangler.Mangle(p.Name), "param")
+		if c.annotate {
+			b.goType = annotatedType(p)
+		}
+		if b.goType == "" {
+			b.goType = c.paramTypes[p]
+		}
+		arg = convertValue(b.goType, arg)
+		c.code.WriteLine(fmt.Sprintf("%s := %s", b.goName, arg))
+		c.code.WriteLine(fmt.Sprintf("_ = %s", b.goName))
 	}
+	compileBody()
+	c.code.Write("})")
 }
 
-func (c *compiler) compileVariableDeclarator(vd *ast.VariableDeclarator) {
-	name := vd.ID.Name
+// compileArrowFunction compiles an arrow function to a JSFunction. The Go
+// func of a concise body directly returns its expression, e.g.
+// `return Add(x, JSNumber(1))` for `x => x + 1`.
+func (c *compiler) compileArrowFunction(af *ast.ArrowFunctionExpression) {
+	bs, ok := af.Body.(*ast.BlockStatement)
+	if ok {
+		c.compileFunction(af.Params, bs)
+		return
+	}
 
-	c.code.WriteLine(fmt.Sprintf("var %s Object", name))
-	c.code.WriteLine(fmt.Sprintf("_ = %s", name))
-	if vd.Init != nil {
-		c.code.Write(fmt.Sprintf("%s = ", name))
-		c.compileExpression(vd.Init)
+	c.compileFunctionBody(af.Params, func() {
+		c.orderedReads = orderedReads(af.Body)
+		c.compileReturn(af.Body.(ast.Expression))
 		c.code.WriteLine("")
-	}
-	c.code.Write(fmt.Sprintf(`global.DefineProperty("%s", %s)`, name, name))
-	c.defineVar(name)
+	})
 }
 
 // expressions
 
 func (c *compiler) compileExpression(e ast.Expression) {
+	c.track(e)
+	if c.trace != nil {
+		c.traceNode(e)
+	}
 	switch v := e.(type) {
 	case *ast.CallExpression:
 		c.compileCallExpression(v)
+	case *ast.FunctionExpression:
+		c.compileFunctionExpression(v)
+	case *ast.ArrowFunctionExpression:
+		c.compileArrowFunction(v)
+	case *ast.NewExpression:
+		c.compileNewExpression(v)
 	case *ast.AssignmentExpression:
 		c.compileAssignmentExpression(v)
 	case *ast.BinaryExpression:
 		c.compileBinaryExpression(v)
+	case *ast.LogicalExpression:
+		c.compileLogicalExpression(v)
+	case *ast.ConditionalExpression:
+		c.compileConditionalExpression(v)
+	case *ast.UnaryExpression:
+		c.compileUnaryExpression(v)
+	case *ast.UpdateExpression:
+		c.compileUpdateExpression(v)
+	case *ast.SequenceExpression:
+		c.errorf(v, "sequence expression is not supported")
 	case *ast.MemberExpression:
 		c.compileMemberExpression(v)
+	case *ast.OptionalMemberExpression:
+		c.compileOptionalMemberExpression(v)
+	case *ast.OptionalCallExpression:
+		c.compileOptionalChain(v)
+	case *ast.TemplateLiteral:
+		c.compileTemplateLiteral(v)
+	case *ast.ArrayExpression:
+		c.compileArrayExpression(v)
+	case *ast.ObjectExpression:
+		c.compileObjectExpression(v)
 	case *ast.Identifier:
 		c.compileIdentifier(v)
 	case *ast.StringLiteral:
 		c.compileStringLiteral(v)
 	case *ast.NumericLiteral:
 		c.compileNumericLiteral(v)
+	case *ast.NullLiteral:
+		c.code.Write("Null")
+	case *ast.BooleanLiteral:
+		c.code.Write(fmt.Sprintf("JSBoolean(%t)", v.Value))
+	case *ast.RegExpLiteral:
+		c.compileRegExpLiteral(v)
 	default:
 		panic("unknown expression type " + utils.TypeOf(v))
 	}
 }
 
 func (c *compiler) compileCallExpression(ce *ast.CallExpression) {
-	c.compileExpression(ce.Callee)
-	c.code.Write("([]Object{")
-	for i, arg := range ce.Arguments {
+	if me, ok := ce.Callee.(*ast.MemberExpression); ok {
+		if d := dotted(me); d != me {
+			call := *ce
+			call.Callee = d
+			ce = &call
+		}
+	}
+	if c.compileMathBuiltin(ce) || c.compileRegExpCall(ce) || c.compileSliceCall(ce) {
+		return
+	}
+
+	if me, ok := ce.Callee.(*ast.MemberExpression); ok && !me.Computed && c.getBuiltinFunc(me.Object, me.Property) != "" {
+		c.compileExpression(ce.Callee)
+		c.code.Write("(")
+	} else {
+		c.code.Write("Call(")
+		c.compileExpression(ce.Callee)
+		c.code.Write(", ")
+	}
+
+	c.compileArguments(ce.Arguments)
+	c.code.Write(")")
+}
+
+// compileArguments compiles the arguments of a call to a []Object, built in
+// a func literal when they spread values, as in `f(a, ...b)`
+func (c *compiler) compileArguments(args []ast.Expression) {
+	if hasSpread(args) {
+		v := c.tempVar("e")
+		c.code.WriteLine("func() []Object {")
+		c.compileSpreadElements(v, "", args)
+		c.code.WriteLine("return " + v)
+		c.code.Write("}()")
+		return
+	}
+
+	c.code.Write("[]Object{")
+	for i, arg := range args {
 		c.compileExpression(arg)
-		if i != len(ce.Arguments)-1 {
+		if i != len(args)-1 {
 			c.code.Write(", ")
 		}
 	}
-	c.code.Write("})\n")
+	c.code.Write("}")
+}
+
+func (c *compiler) compileNewExpression(ne *ast.NewExpression) {
+	c.code.Write("New(")
+	c.compileExpression(ne.Callee)
+	c.code.Write(", ")
+	c.compileArguments(ne.Arguments)
+	c.code.Write(")")
+}
+
+// compileFunctionExpression compiles a function expression to a JSFunction.
+// A named one is assigned to a var only visible in its body.
+func (c *compiler) compileFunctionExpression(fe *ast.FunctionExpression) {
+	if fe.ID == nil {
+		c.compileFunction(fe.Params, fe.Body)
+		return
+	}
+
+	c.pushScope()
+	defer c.popScope()
+
+	c.code.WriteLine("func() Object {")
+	b := c.declareVar(fe.ID.Name, "function")
+	c.code.Write(b.goName + " = ")
+	c.compileFunction(fe.Params, fe.Body)
+	c.code.WriteLine("")
+	c.code.WriteLine("return " + b.goName)
+	c.code.Write("}()")
 }
 
-// TODO: ignoring computed value for now
 func (c *compiler) compileMemberExpression(me *ast.MemberExpression) {
-	if me.Computed {
-		panic("computed MemberExpression is not supported")
+	me = dotted(me)
+	if c.compileEnumMember(me) {
+		return
+	}
+	if c.compileNamespaceMember(me) {
+		return
 	}
 
-	if builtInFunc := c.getBuiltinFunc(me.Object, me.Property); builtInFunc == "" {
-		c.compileExpression(me.Object)
-		c.code.Write(".")
-		c.compileExpression(me.Property)
-	} else {
+	if builtInFunc := c.getBuiltinFunc(me.Object, me.Property); builtInFunc != "" && !me.Computed {
 		c.code.Write(builtInFunc)
+		return
+	}
+
+	// strings are indexed by their UTF-16 code units, not their bytes
+	fn := "Get("
+	if me.Computed && c.isString(me.Object) {
+		fn = "StringIndex("
+	}
+	c.code.Write(fn)
+	c.compileExpression(me.Object)
+	c.code.Write(", ")
+	c.compileMemberKey(me.Property, me.Computed)
+	c.code.Write(")")
+}
+
+// dotted returns the member expression me reading a string literal key
+// which is an identifier name, e.g. `obj["foo"]`, as the equivalent dotted
+// `obj.foo`, so that both compile alike. Other member expressions, e.g.
+// `obj["has-dash"]`, are returned as they are.
+func dotted(me *ast.MemberExpression) *ast.MemberExpression {
+	sl, ok := me.Property.(*ast.StringLiteral)
+	if !me.Computed || !ok || !isIdentifierName(sl.Value) {
+		return me
+	}
+
+	d := *me
+	d.Property = &ast.Identifier{Attr: sl.Attr, Name: sl.Value}
+	d.Computed = false
+	return &d
+}
+
+// isIdentifierName tells whether s is a JavaScript identifier name
+func isIdentifierName(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// compileMemberKey compiles the property of a member expression to the key
+// it reads. Keys computed from literals only, e.g. `["get" + "X"]`, are
+// folded to the name of the property. Properties are keyed by strings, so
+// symbol keys are errors rather than keys of their string.
+func (c *compiler) compileMemberKey(prop ast.Expression, computed bool) {
+	if id, ok := prop.(*ast.Identifier); ok && !computed {
+		c.code.Write(fmt.Sprintf("JSString(%s)", strconv.Quote(id.Name)))
+		return
+	}
+	if c.isSymbol(prop) {
+		c.errorf(prop, "symbol key %s is not supported", prop)
+	}
+	switch prop.(type) {
+	case *ast.BinaryExpression, *ast.TemplateLiteral:
+		if key, ok := constantValue(prop); ok {
+			c.code.Write(fmt.Sprintf("JSString(%s)", strconv.Quote(string(runtime.ToString(key)))))
+			return
+		}
+	}
+
+	c.compileExpression(prop)
+}
+
+// isSymbol tells whether e evaluates to a symbol of the undeclared Symbol
+// global, e.g. `Symbol.iterator`, `Symbol("id")` or `Symbol.for("id")`
+func (c *compiler) isSymbol(e ast.Expression) bool {
+	for {
+		switch v := e.(type) {
+		case *ast.MemberExpression:
+			e = v.Object
+		case *ast.CallExpression:
+			e = v.Callee
+		case *ast.Identifier:
+			return v.Name == "Symbol" && c.lookup(v.Name) == nil
+		default:
+			return false
+		}
+	}
+}
+
+// constantValue returns the value e always evaluates to when it's made of
+// literals only, e.g. the computed key `"get" + "X"`.
+func constantValue(e ast.Expression) (runtime.Object, bool) {
+	switch v := e.(type) {
+	case *ast.StringLiteral:
+		return runtime.JSString(v.Value), true
+	case *ast.NumericLiteral:
+		return runtime.JSNumber(v.Value), true
+	case *ast.BooleanLiteral:
+		return runtime.JSBoolean(v.Value), true
+	case *ast.NullLiteral:
+		return runtime.Null, true
+	case *ast.TemplateLiteral:
+		if len(v.Expressions) == 0 && len(v.Quasis) == 1 {
+			return runtime.JSString(v.Quasis[0].Cooked), true
+		}
+	case *ast.UnaryExpression:
+		if v.Operator == "-" {
+			if arg, ok := constantValue(v.Argument); ok {
+				return runtime.Neg(arg), true
+			}
+		}
+	case *ast.BinaryExpression:
+		left, ok := constantValue(v.Left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := constantValue(v.Right)
+		if !ok {
+			return nil, false
+		}
+
+		switch v.Operator {
+		case "+":
+			return runtime.Add(left, right), true
+		case "-":
+			return runtime.Sub(left, right), true
+		case "*":
+			return runtime.Mul(left, right), true
+		case "/":
+			return runtime.Divide(left, right), true
+		case "%":
+			return runtime.Modulo(left, right), true
+		}
+	}
+
+	return nil, false
+}
+
+// compileOptionalMemberExpression compiles a whole optional chain to a func
+// literal reading each link of the chain in turn, returning undefined as
+// soon as an optional link reads a nullish object. The links after it are
+// not evaluated then, as `a?.b.c` doesn't throw when a is nullish. A single
+// optional link whose key has no side effects, e.g. `a?.[0]`, compiles to
+// the runtime GetOptional instead.
+func (c *compiler) compileOptionalMemberExpression(ome *ast.OptionalMemberExpression) {
+	if !isOptionalChain(ome.Object) && ome.Optional && (!ome.Computed || c.isPure(ome.Property)) {
+		c.code.Write("GetOptional(")
+		c.compileExpression(ome.Object)
+		c.code.Write(", ")
+		c.compileMemberKey(ome.Property, ome.Computed)
+		c.code.Write(")")
+		return
+	}
+
+	c.compileOptionalChain(ome)
+}
+
+// compileOptionalChain compiles the links of an optional chain, members and
+// calls, from its base. An optional call, as in `a.f?.()`, is skipped when
+// the callee is nullish, whereas `a?.f()` is skipped when a is. The
+// arguments of a skipped call are not evaluated.
+func (c *compiler) compileOptionalChain(chain ast.Expression) {
+	var links []ast.Expression
+	base := chain
+	for isOptionalChain(base) {
+		if l, ok := base.(*ast.OptionalCallExpression); ok {
+			// builtin funcs are never nullish and are called directly
+			if me, ok := l.Callee.(*ast.MemberExpression); ok && c.getBuiltinFunc(me.Object, me.Property) != "" {
+				base = &ast.CallExpression{Attr: l.Attr, Callee: l.Callee, Arguments: l.Arguments}
+				break
+			}
+		}
+
+		links = append([]ast.Expression{base}, links...)
+		switch l := base.(type) {
+		case *ast.OptionalMemberExpression:
+			base = l.Object
+		case *ast.OptionalCallExpression:
+			base = l.Callee
+		}
+	}
+
+	v := c.tempVar("o")
+	guard := func(optional bool) {
+		if optional {
+			c.code.WriteLine(fmt.Sprintf("if IsNullish(%s) {", v))
+			c.code.WriteLine("return nil")
+			c.code.WriteLine("}")
+		}
+	}
+
+	c.code.WriteLine("func() Object {")
+	c.code.Write(v + " := ")
+	c.compileExpression(base)
+	c.code.WriteLine("")
+	for _, l := range links {
+		switch l := l.(type) {
+		case *ast.OptionalMemberExpression:
+			guard(l.Optional)
+			c.code.Write(fmt.Sprintf("%s = Get(%s, ", v, v))
+			c.compileMemberKey(l.Property, l.Computed)
+			c.code.WriteLine(")")
+		case *ast.OptionalCallExpression:
+			guard(l.Optional)
+			c.code.Write(fmt.Sprintf("%s = Call(%s, ", v, v))
+			c.compileArguments(l.Arguments)
+			c.code.WriteLine(")")
+		}
+	}
+	c.code.WriteLine("return " + v)
+	c.code.Write("}()")
+}
+
+// isOptionalChain tells whether e is a link of an optional chain
+func isOptionalChain(e ast.Expression) bool {
+	switch e.(type) {
+	case *ast.OptionalMemberExpression, *ast.OptionalCallExpression:
+		return true
+	}
+
+	return false
+}
+
+func (c *compiler) compileArrayExpression(ae *ast.ArrayExpression) {
+	if hasSpread(ae.Elements) {
+		c.compileArraySpread(ae)
+		return
+	}
+
+	var holes []string
+	for i, e := range ae.Elements {
+		if e == nil {
+			holes = append(holes, strconv.Itoa(i))
+		}
+	}
+
+	if holes == nil {
+		c.code.Write("NewArray([]Object{")
+	} else {
+		c.code.Write("NewArrayWithHoles([]Object{")
+	}
+	for i, e := range ae.Elements {
+		if e == nil {
+			// a hole, as in `[1, , 3]`
+			c.code.Write("nil")
+		} else {
+			c.compileExpression(e)
+		}
+		if i != len(ae.Elements)-1 {
+			c.code.Write(", ")
+		}
+	}
+	c.code.Write("}")
+	if holes != nil {
+		c.code.Write(", " + strings.Join(holes, ", "))
+	}
+	c.code.Write(")")
+}
+
+// compileArraySpread compiles an array literal spreading values, as in
+// `[a, ...b]`, to a func literal appending its elements in order
+func (c *compiler) compileArraySpread(ae *ast.ArrayExpression) {
+	v := c.tempVar("e")
+	c.code.WriteLine("func() Object {")
+	for _, e := range ae.Elements {
+		if e == nil {
+			holes := c.tempVar("h")
+			c.code.WriteLine(fmt.Sprintf("var %s []int", holes))
+			c.compileSpreadElements(v, holes, ae.Elements)
+			c.code.WriteLine(fmt.Sprintf("return NewArrayWithHoles(%s, %s...)", v, holes))
+			c.code.Write("}()")
+			return
+		}
+	}
+	c.compileSpreadElements(v, "", ae.Elements)
+	c.code.WriteLine(fmt.Sprintf("return NewArray(%s)", v))
+	c.code.Write("}()")
+}
+
+// compileSpreadElements declares the []Object var v and appends elements to
+// it in order, spreading the spread ones. The indices of the holes of array
+// literals are appended to the []int var holes.
+func (c *compiler) compileSpreadElements(v, holes string, elements []ast.Expression) {
+	c.code.WriteLine(fmt.Sprintf("var %s []Object", v))
+	for _, e := range elements {
+		switch e := e.(type) {
+		case nil:
+			c.code.WriteLine(fmt.Sprintf("%s = append(%s, len(%s))", holes, holes, v))
+			c.code.WriteLine(fmt.Sprintf("%s = append(%s, nil)", v, v))
+		case *ast.SpreadElement:
+			c.code.Write(fmt.Sprintf("%s = SpreadIterable(%s, ", v, v))
+			c.compileExpression(e.Argument)
+			c.code.WriteLine(")")
+		default:
+			c.code.Write(fmt.Sprintf("%s = append(%s, ", v, v))
+			c.compileExpression(e)
+			c.code.WriteLine(")")
+		}
+	}
+}
+
+// hasSpread tells whether elements, of an array literal or the arguments of
+// a call, spread values
+func hasSpread(elements []ast.Expression) bool {
+	for _, e := range elements {
+		if _, ok := e.(*ast.SpreadElement); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compileObjectExpression compiles an object literal to a func literal
+// building the object property by property, in order, so that later
+// properties override the ones spread before them as in `{...a, b: 1}`.
+func (c *compiler) compileObjectExpression(oe *ast.ObjectExpression) {
+	if len(oe.Properties) == 0 {
+		c.code.Write("NewObject()")
+		return
+	}
+
+	v := c.tempVar("o")
+	c.code.WriteLine("func() Object {")
+	c.code.WriteLine(v + " := NewObject()")
+	for _, p := range oe.Properties {
+		switch p := p.(type) {
+		case *ast.SpreadElement:
+			c.code.Write(fmt.Sprintf("SpreadObject(%s, ", v))
+			c.compileExpression(p.Argument)
+			c.code.WriteLine(")")
+		case *ast.ObjectProperty:
+			c.code.Write(fmt.Sprintf("Set(%s, ", v))
+			c.compileMemberKey(p.Key, p.Computed)
+			c.code.Write(", ")
+			c.compileExpression(p.Value)
+			c.code.WriteLine(")")
+		case *ast.ObjectMethod:
+			c.compileObjectMethod(v, p)
+		}
+	}
+	c.code.WriteLine("return " + v)
+	c.code.Write("}()")
+}
+
+// compileObjectMethod defines a method or getter of the object literal held
+// by the Go var v. A getter runs every time its property is read.
+func (c *compiler) compileObjectMethod(v string, om *ast.ObjectMethod) {
+	switch om.Kind {
+	case "get":
+		c.code.Write(fmt.Sprintf("DefineGetter(%s, ", v))
+	case "method":
+		c.code.Write(fmt.Sprintf("Set(%s, ", v))
+	default:
+		c.errorf(om, "%s accessor is not supported", om.Kind)
 	}
+
+	c.compileMemberKey(om.Key, om.Computed)
+	c.code.Write(", ")
+	c.compileFunction(om.Params, om.Body)
+	c.code.WriteLine(")")
 }
 
+// compileAssignmentExpression compiles an assignment to a variable, or to a
+// member with the runtime Set. Compound assignments apply the runtime
+// operator to the current value of the target.
 func (c *compiler) compileAssignmentExpression(ae *ast.AssignmentExpression) {
-	c.compileExpression(ae.Left)
-	c.code.Write(fmt.Sprintf(" %s ", ae.Operator))
-	c.compileExpression(ae.Right)
+	var op string
+	if ae.Operator != "=" {
+		fn, ok := binaryOperators[ast.BinaryOperator(strings.TrimSuffix(string(ae.Operator), "="))]
+		if !ok {
+			c.errorf(ae, "%s assignment is not supported", ae.Operator)
+		}
+		op = fn
+	}
+
+	switch v := ae.Left.(type) {
+	case *ast.Identifier:
+		if b := c.lookup(v.Name); b != nil && b.module != nil {
+			c.errorf(ae, "assignment to imported binding %s", v.Name)
+		}
+
+		if b := c.lookup(v.Name); b != nil && b.goType != "" {
+			c.compileTypedAssignment(b, ae)
+			return
+		}
+		if c.lookup(v.Name) == nil {
+			value := c.code.Capture(func() {
+				c.compileAssignedValue(op, func() { c.compileIdentifier(v) }, ae.Right)
+			})
+			c.code.Write(globalSet(v.Name, value))
+			return
+		}
+
+		c.compileIdentifier(v)
+		c.code.Write(" = ")
+		c.compileAssignedValue(op, func() { c.compileIdentifier(v) }, ae.Right)
+	case *ast.MemberExpression:
+		c.compileMemberAssignment(op, v, ae.Right)
+	case *ast.ArrayPattern, *ast.ObjectPattern:
+		c.compileDestructuringAssignment(v, ae.Right)
+	case *ast.OptionalMemberExpression:
+		c.errorf(ae, "invalid assignment to optional chain %s", ae.Left)
+	default:
+		c.errorf(ae, "assignment to %s is not supported", ae.Left)
+	}
+}
+
+// compileMemberAssignment compiles an assignment to a member. The object and
+// key of a compound assignment are evaluated once into temporaries, unless
+// evaluating them has no side effects, as `a[next()] += 1` calls next once.
+func (c *compiler) compileMemberAssignment(op string, me *ast.MemberExpression, right ast.Expression) {
+	obj := c.code.Capture(func() { c.compileExpression(me.Object) })
+	key := c.code.Capture(func() { c.compileMemberKey(me.Property, me.Computed) })
+	read := func() { c.code.Write(fmt.Sprintf("Get(%s, %s)", obj, key)) }
+
+	if op == "" || (c.isPure(me.Object) && (!me.Computed || c.isPure(me.Property))) {
+		c.code.Write(fmt.Sprintf("Set(%s, %s, ", obj, key))
+		c.compileAssignedValue(op, read, right)
+		c.code.Write(")")
+		return
+	}
+
+	o, k := c.tempVar("o"), c.tempVar("k")
+	c.code.WriteLine("func() Object {")
+	c.code.WriteLine(fmt.Sprintf("%s, %s := %s, %s", o, k, obj, key))
+	obj, key = o, k
+	c.code.Write(fmt.Sprintf("return Set(%s, %s, ", o, k))
+	c.compileAssignedValue(op, read, right)
+	c.code.WriteLine(")")
+	c.code.Write("}()")
+}
+
+// compileAssignedValue compiles the value an assignment assigns, applying
+// the runtime operator op of a compound assignment to the value read reads
+func (c *compiler) compileAssignedValue(op string, read func(), right ast.Expression) {
+	if op == "" {
+		c.compileExpression(right)
+		return
+	}
+
+	c.code.Write(op + "(")
+	read()
+	c.code.Write(", ")
+	c.compileExpression(right)
+	c.code.Write(")")
+}
+
+// isPure tells whether evaluating e has no side effects, so that it can be
+// evaluated more than once
+func (c *compiler) isPure(e ast.Expression) bool {
+	switch v := e.(type) {
+	case *ast.Identifier:
+		b := c.lookup(v.Name)
+		return b != nil && b.kind != "namespace"
+	}
+
+	_, ok := constantValue(e)
+	return ok
+}
+
+var unaryOperators = map[ast.UnaryOperator]string{
+	"typeof": "TypeOf",
+	"-":      "Neg",
+	"+":      "ToNumber",
+}
+
+var binaryOperators = map[ast.BinaryOperator]string{
+	"+":  "Add",
+	"-":  "Sub",
+	"*":  "Mul",
+	"/":  "Divide",
+	"%":  "Modulo",
+	"<":  "Less",
+	">":  "Greater",
+	"<=": "LessOrEqual",
+	">=": "GreaterOrEqual",
+	"in": "In",
 }
 
+// compileBinaryExpression compiles a binary expression to a call of the
+// runtime function of its operator. Strict equalities compile to a Go bool
+// as a JSBoolean. Divisions of constants are folded to the number they
+// compute, which may be infinite or NaN, e.g. `math.Inf(1)` for `1 / 0`.
 func (c *compiler) compileBinaryExpression(be *ast.BinaryExpression) {
+	if be.Operator == "===" || be.Operator == "!==" {
+		c.code.Write("JSBoolean(")
+		c.compileBool(be, false)
+		c.code.Write(")")
+		return
+	}
+	if be.Operator == "/" || be.Operator == "%" {
+		if v, ok := constantValue(be); ok {
+			c.compileNumber(float64(v.(runtime.JSNumber)))
+			return
+		}
+	}
+
+	if fn, ok := binaryOperators[be.Operator]; ok {
+		c.code.Write(fn + "(")
+		c.compileExpression(be.Left)
+		c.code.Write(", ")
+		c.compileExpression(be.Right)
+		c.code.Write(")")
+		return
+	}
+
 	c.compileExpression(be.Left)
 	c.code.Write(fmt.Sprintf(" %s ", be.Operator))
 	c.compileExpression(be.Right)
 }
 
-func (c *compiler) compileIdentifier(i *ast.Identifier) {
-	if c.isVarDefined(i.Name) {
-		c.code.Write(i.Name)
+// logicalHelpers are the runtime helpers of the logical operators, which
+// return the value of the operand deciding the result
+var logicalHelpers = map[ast.LogicalOperator]string{
+	"??": "Coalesce",
+	"||": "Or",
+	"&&": "And",
+}
+
+// compileLogicalExpression compiles a logical expression to the runtime
+// helper of its operator, e.g. `Or(a, func() Object { return b })` for
+// `a || b`, the right side being in a func literal so that it's only
+// evaluated when the left side doesn't decide the result.
+func (c *compiler) compileLogicalExpression(le *ast.LogicalExpression) {
+	helper, ok := logicalHelpers[le.Operator]
+	if !ok {
+		c.errorf(le, "logical operator %s is not supported", le.Operator)
+	}
+
+	c.code.Write(helper + "(")
+	c.compileExpression(le.Left)
+	c.code.Write(", func() Object { return ")
+	c.compileExpression(le.Right)
+	c.code.Write(" })")
+}
+
+// compileConditionalExpression compiles a conditional to a Ternary call on
+// the Go bool of its test, its branches being in func literals so that only
+// the one taken is evaluated, as in
+// `Ternary(Truthy(a), func() Object { return b }, func() Object { return c })`
+// for `a ? b : c`. A nested conditional is in the func literal of its
+// branch, which groups chained conditionals from the right as JavaScript
+// does.
+func (c *compiler) compileConditionalExpression(ce *ast.ConditionalExpression) {
+	c.code.Write("Ternary(")
+	c.compileBool(ce.Test, false)
+	c.code.Write(", func() Object { return ")
+	c.compileExpression(ce.Consequent)
+	c.code.Write(" }, func() Object { return ")
+	c.compileExpression(ce.Alternate)
+	c.code.Write(" })")
+}
+
+// compileUnaryExpression compiles a unary expression. typeof doesn't throw
+// for undeclared variables, which are read from the global object instead
+// of being resolved, and logical nots compile to the negated Go bool of
+// their argument.
+func (c *compiler) compileUnaryExpression(ue *ast.UnaryExpression) {
+	if ue.Operator == "!" {
+		c.code.Write("JSBoolean(")
+		c.compileBool(ue, false)
+		c.code.Write(")")
+		return
+	}
+	if ue.Operator == "void" {
+		c.compileVoid(ue)
+		return
+	}
+	if ue.Operator == "delete" {
+		c.compileDelete(ue)
+		return
+	}
+
+	fn, ok := unaryOperators[ue.Operator]
+	if !ok {
+		c.errorf(ue, "unary operator %s is not supported", ue.Operator)
+	}
+
+	c.code.Write(fn + "(")
+	if id, ok := ue.Argument.(*ast.Identifier); ok && ue.Operator == "typeof" && c.lookup(id.Name) == nil {
+		c.code.Write(fmt.Sprintf("global.Get(%s)", strconv.Quote(id.Name)))
 	} else {
-		c.code.Write(fmt.Sprintf(`global.GetProperty("%s")`, i.Name))
+		c.compileExpression(ue.Argument)
 	}
+	c.code.Write(")")
 }
 
-func (c *compiler) compileStringLiteral(s *ast.StringLiteral) {
-	c.code.Write(fmt.Sprintf(`JSString("%s")`, s.Value))
+// compileDelete compiles the delete of a member to a Delete call, which
+// leaves a hole when the object is an array
+func (c *compiler) compileDelete(ue *ast.UnaryExpression) {
+	me, ok := ue.Argument.(*ast.MemberExpression)
+	if !ok {
+		c.errorf(ue, "delete of %s is not supported", ue.Argument)
+	}
+
+	c.code.Write("Delete(")
+	c.compileExpression(me.Object)
+	c.code.Write(", ")
+	c.compileMemberKey(me.Property, me.Computed)
+	c.code.Write(")")
 }
 
-func (c *compiler) compileNumericLiteral(n *ast.NumericLiteral) {
-	c.code.Write(fmt.Sprintf(`JSNumber(%f)`, n.Value))
+// compileVoid compiles a void expression to undefined. The literal operands
+// of idioms like `void 0` have no side effects and are dropped, others are
+// evaluated by a func literal discarding their value.
+func (c *compiler) compileVoid(ue *ast.UnaryExpression) {
+	switch ue.Argument.(type) {
+	case *ast.StringLiteral, *ast.NumericLiteral, *ast.NullLiteral, *ast.BooleanLiteral:
+		c.code.Write("nil")
+		return
+	}
+
+	c.code.WriteLine("func() Object {")
+	c.code.Write("_ = ")
+	c.compileExpression(ue.Argument)
+	c.code.WriteLine("")
+	c.code.WriteLine("return nil")
+	c.code.Write("}()")
 }
 
-func (c *compiler) writeLineNo(node ast.Node) {
-	c.code.WriteLine(fmt.Sprintf(`// line %d: %s`, node.GetAttr().Loc.Start.Line, node))
+// compileUpdateExpression compiles an update whose value is used to a func
+// literal assigning the variable and returning the number it held before a
+// postfix update, e.g. `arr[i++]` reads the element at the former index,
+// or the one it holds after a prefix update. Go doesn't order the call with
+// the reads of the variable elsewhere in the expression, as in `f(i++, i)`,
+// which are compiled to calls too, see orderedReads.
+func (c *compiler) compileUpdateExpression(ue *ast.UpdateExpression) {
+	b := c.updatedBinding(ue)
+	// the func literal reads the variable in order
+	reads := c.orderedReads
+	c.orderedReads = nil
+	defer func() { c.orderedReads = reads }()
+
+	c.code.WriteLine("func() Object {")
+	if ue.Prefix {
+		c.compileUpdateStatement(ue)
+		c.code.WriteLine("")
+		c.code.WriteLine("return " + b.value())
+	} else {
+		old, value := c.tempVar("u"), fmt.Sprintf("ToNumber(%s)", b.value())
+		if b.goType == "float64" {
+			value = b.value()
+		}
+		c.code.WriteLine(fmt.Sprintf("%s := %s", old, value))
+		c.compileUpdateStatement(ue)
+		c.code.WriteLine("")
+		c.code.WriteLine("return " + old)
+	}
+	c.code.Write("}()")
+}
+
+// orderedReads returns the variables the update expressions of node, a
+// statement or the concise body of an arrow function, update when their
+// value is used. Go evaluates the calls of an expression in order, but not
+// the reads of variables, so reading the variable before or after the func
+// literal of such an update, as in `f(i, i++, i)`, may see either value.
+// Their reads are compiled to calls, e.g. `func() Object { return i }()`.
+// The nested blocks and functions are left out, their statements are
+// compiled with the updates of their own.
+func orderedReads(node ast.Node) map[string]bool {
+	// unused are the expressions compiled to statements
+	unused := make(map[ast.Node]bool)
+	var names map[string]bool
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch v := n.(type) {
+		case *ast.BlockStatement, *ast.FunctionDeclaration, *ast.FunctionExpression, *ast.ArrowFunctionExpression, *ast.ObjectMethod:
+			return false
+		case *ast.ExpressionStatement:
+			unused[v.Expression] = true
+		case *ast.ForStatement:
+			if seq, ok := v.Update.(*ast.SequenceExpression); ok {
+				for _, e := range seq.Expressions {
+					unused[e] = true
+				}
+			} else if v.Update != nil {
+				unused[v.Update] = true
+			}
+		case *ast.SequenceExpression:
+			for _, e := range v.Expressions[:len(v.Expressions)-1] {
+				unused[e] = true
+			}
+		case *ast.UpdateExpression:
+			if id, ok := v.Argument.(*ast.Identifier); ok && !unused[v] {
+				if names == nil {
+					names = make(map[string]bool)
+				}
+				names[id.Name] = true
+			}
+		}
+		return n != nil
+	})
+
+	return names
+}
+
+// orderedRead returns the Go expression value reading the variable name,
+// of Go type goType, in a call when it's one of the orderedReads
+func (c *compiler) orderedRead(name, goType, value string) string {
+	if !c.orderedReads[name] {
+		return value
+	}
+
+	return fmt.Sprintf("func() %s { return %s }()", goType, value)
+}
+
+// compileUpdateStatement compiles an update whose value is unused to the
+// assignment of the variable
+func (c *compiler) compileUpdateStatement(ue *ast.UpdateExpression) {
+	c.code.Write(c.updatedBinding(ue).goName + " = ")
+	c.compileUpdateValue(ue)
+}
+
+// updatedBinding returns the binding of the declared variable ue updates,
+// the only updates supported
+func (c *compiler) updatedBinding(ue *ast.UpdateExpression) *binding {
+	id, ok := ue.Argument.(*ast.Identifier)
+	if !ok || c.scope.lookup(id.Name) == nil {
+		c.errorf(ue, "update of %s is not supported", ue.Argument)
+	}
+
+	return c.scope.lookup(id.Name)
+}
+
+// compileUpdateValue compiles the value an update expression assigns
+func (c *compiler) compileUpdateValue(ue *ast.UpdateExpression) {
+	if id, ok := ue.Argument.(*ast.Identifier); ok {
+		if b := c.scope.lookup(id.Name); b != nil && b.goType == "float64" {
+			c.code.Write(fmt.Sprintf("%s %s 1", b.goName, ue.Operator[:1]))
+			return
+		} else if b != nil && b.goType != "" {
+			// annotated vars of other types keep their type
+			value := c.code.Capture(func() { c.compileNumberUpdate(ue) })
+			c.code.Write(convertValue(b.goType, value))
+			return
+		}
+	}
+
+	c.compileNumberUpdate(ue)
+}
+
+// compileNumberUpdate compiles the number an update expression assigns
+func (c *compiler) compileNumberUpdate(ue *ast.UpdateExpression) {
+	c.code.Write("ToNumber(")
+	c.compileExpression(ue.Argument)
+	if ue.Operator == "++" {
+		c.code.Write(") + 1")
+	} else {
+		c.code.Write(") - 1")
+	}
+}
+
+// globalSet returns the Go call setting the property name of the global
+// object to the Go expression value, which an assignment to an undeclared
+// variable compiles to, e.g. `Set(global, JSString("q"), JSNumber(1))` for
+// `q = 1`. Like the assignment, it returns the value.
+func globalSet(name, value string) string {
+	return fmt.Sprintf("Set(global, JSString(%s), %s)", strconv.Quote(name), value)
+}
+
+func (c *compiler) compileIdentifier(i *ast.Identifier) {
+	if b := c.lookup(i.Name); b != nil {
+		if b.kind == "namespace" {
+			c.errorf(i, "namespace import %s can only be used to access its exports", i.Name)
+		}
+		if b.module != nil {
+			b.module.used = true
+		}
+
+		c.code.Write(c.orderedRead(i.Name, "Object", b.value()))
+	} else if globalObjectNames[i.Name] {
+		c.code.Write("global")
+	} else {
+		c.code.Write(fmt.Sprintf(`global.Resolve(%s)`, strconv.Quote(i.Name)))
+	}
+}
+
+// globalObjectNames are the global variables referencing the global object
+// itself, which compile to the Go var of the global object rather than being
+// resolved. window isn't one of them: scripts test typeof window to detect
+// browsers, which programs compiled to Go aren't.
+var globalObjectNames = map[string]bool{
+	"globalThis": true,
+}
+
+func (c *compiler) compileStringLiteral(s *ast.StringLiteral) {
+	c.code.Write(fmt.Sprintf(`JSString(%s)`, strconv.Quote(s.Value)))
 }
 
-// defineVar defines the var when the compiler sees it
-// This is used for optimizing compiled code for direct reference of var
-// FIXME: defined var is cached in global context for now
-func (c *compiler) defineVar(name string) {
-	// FIXME: Prop value is a dummpy obj for now
-	c.ctx.Global.DefineProperty(name, &runtime.JSObject{})
+// compileTemplateLiteral compiles a template literal to the concatenation
+// of its strings and of its interpolated values converted to strings the way
+// JavaScript's String(v) does.
+func (c *compiler) compileTemplateLiteral(tl *ast.TemplateLiteral) {
+	c.code.Write("JSString(")
+	n := 0
+	for i, q := range tl.Quasis {
+		if q.Cooked != "" || (i == 0 && len(tl.Expressions) == 0) {
+			if n > 0 {
+				c.code.Write(" + ")
+			}
+			c.code.Write(strconv.Quote(q.Cooked))
+			n++
+		}
+
+		if i < len(tl.Expressions) {
+			if n > 0 {
+				c.code.Write(" + ")
+			}
+			c.code.Write("string(ToString(")
+			c.compileExpression(tl.Expressions[i])
+			c.code.Write("))")
+			n++
+		}
+	}
+	c.code.Write(")")
 }
 
-// FIXME: Using global context for now
-func (c *compiler) isVarDefined(name string) bool {
-	_, err := c.ctx.Global.GetProperty(name)
-	return err == nil
+// writeLineNo writes the source line of node and the first line of its
+// source as a comment
+func (c *compiler) writeLineNo(node ast.Node) {
+	c.track(node)
+	src := strings.SplitN(node.String(), "\n", 2)[0]
+	c.code.WriteLine(source.LineComment(node.GetAttr().Loc.Start.Line, src))
 }
 
 func (c *compiler) getBuiltinFunc(objExp, propExp ast.Expression) string {
 	oID, ok := objExp.(*ast.Identifier)
-	if !ok {
+	if !ok || c.lookup(oID.Name) != nil {
 		return ""
 	}
 
-	pID := propExp.(*ast.Identifier)
+	pID, ok := propExp.(*ast.Identifier)
 	if !ok {
 		return ""
 	}
@@ -2,10 +2,22 @@ package compiler
 
 import (
 	"encoding/json"
+	goast "go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/jingweno/godzilla/ast"
+	"github.com/jingweno/godzilla/source"
 )
 
 func TestCompile(t *testing.T) {
@@ -17,8 +29,1756 @@ func TestCompile(t *testing.T) {
 		t.Fatalf("error decoding AST JSON: %s", err)
 	}
 
-	code := Compile(f)
-	if !strings.Contains(code.String(), `Console_Log([]Object{JSString("Hello, Godzilla")}`) {
-		t.Fatalf("compiler has error:\n%s", code)
+	res, err := Compile(f, CompileOptions{})
+	if err != nil {
+		t.Fatalf("error compiling: %s", err)
 	}
+	if !strings.Contains(res.Source, `Console_Log([]Object{JSString("Hello, Godzilla")}`) {
+		t.Fatalf("compiler has error:\n%s", res.Source)
+	}
+}
+
+func TestCompile_Result(t *testing.T) {
+	// function greet(name) { console.log(name) }
+	// let count = 1
+	// /a/.test("a")
+	f := file(
+		funcDecl("greet", []string{"name"},
+			exprStmt(call(member(ident("console"), ident("log")), ident("name"))),
+		),
+		varDecl("let", "count", num(1)),
+		exprStmt(call(member(regExp("a", ""), ident("test")), str("a"))),
+	)
+
+	for _, opts := range []CompileOptions{{}, {WrapMain: true}} {
+		res, err := Compile(f, opts)
+		if err != nil {
+			t.Fatalf("error compiling: %s", err)
+		}
+		if want := map[string]string{"greet": "greet", "count": "count"}; !reflect.DeepEqual(want, res.Symbols) {
+			t.Errorf("symbols with %+v: want=%v got=%v", opts, want, res.Symbols)
+		}
+		if want := []string{source.RuntimeImport, `"regexp"`}; !reflect.DeepEqual(want, res.Imports) {
+			t.Errorf("imports with %+v: want=%v got=%v", opts, want, res.Imports)
+		}
+		if !strings.Contains(res.Source, "package main") {
+			t.Errorf("source with %+v:\n%s", opts, res.Source)
+		}
+	}
+}
+
+func TestCompileProgram(t *testing.T) {
+	// a.js: function greet(name) { console.log(name) }
+	a := file(
+		funcDecl("greet", []string{"name"},
+			exprStmt(call(member(ident("console"), ident("log")), ident("name"))),
+		),
+	)
+	// b.js: greet("hi")
+	b := file(exprStmt(call(ident("greet"), str("hi"))))
+
+	out, err := CompileProgram(map[string]*ast.File{"a.js": a, "b.js": b}, CompileOptions{})
+	if err != nil {
+		t.Fatalf("error compiling program: %s", err)
+	}
+
+	for _, want := range []string{
+		"var greet Object\n",
+		"func main() {}",
+		"func init() {",
+		"greet = NewFunction(func(args []Object) Object {",
+	} {
+		if !strings.Contains(out["a.js"], want) {
+			t.Fatalf("a.js doesn't contain %q:\n%s", want, out["a.js"])
+		}
+	}
+
+	if want := `Call(greet, []Object{JSString("hi")})`; !strings.Contains(out["b.js"], want) {
+		t.Fatalf("b.js doesn't contain %q:\n%s", want, out["b.js"])
+	}
+	for _, notWant := range []string{"var greet", "func main", "global :="} {
+		if strings.Contains(out["b.js"], notWant) {
+			t.Fatalf("b.js contains %q:\n%s", notWant, out["b.js"])
+		}
+	}
+
+	for name, src := range out {
+		if n := strings.Count(src, "github.com/jingweno/godzilla/runtime"); n != 1 {
+			t.Fatalf("%s imports runtime %d times:\n%s", name, n, src)
+		}
+	}
+}
+
+func TestCompileProgram_Redeclaration(t *testing.T) {
+	a := file(varDecl("let", "foo", str("a")))
+	b := file(varDecl("var", "foo", str("b")))
+
+	_, err := CompileProgram(map[string]*ast.File{"a.js": a, "b.js": b}, CompileOptions{})
+	if err == nil || !strings.Contains(err.Error(), `identifier "foo" has already been declared`) {
+		t.Fatalf("want redeclaration error, got %v", err)
+	}
+}
+
+func TestCompile_WrapMain(t *testing.T) {
+	// function f() {}
+	// console.log("hi")
+	f := file(
+		funcDecl("f", nil),
+		exprStmt(call(member(ident("console"), ident("log")), str("hi"))),
+	)
+
+	code := compile(t, f, CompileOptions{WrapMain: true})
+	mainAt := strings.Index(code, "func main() {")
+	if mainAt < 0 {
+		t.Fatalf("compiled code has no main:\n%s", code)
+	}
+	if i := strings.Index(code, `Console_Log([]Object{JSString("hi")})`); i < mainAt {
+		t.Fatalf("console.log isn't in main:\n%s", code)
+	}
+	for _, want := range []string{"var global = NewDefaultContext().Global\n", "var f Object\n", "func init() {\n"} {
+		if i := strings.Index(code, want); i < 0 || i > mainAt {
+			t.Fatalf("compiled code doesn't contain %q at package level:\n%s", want, code)
+		}
+	}
+	if i := strings.Index(code, "f = NewFunction("); i < 0 || i > mainAt {
+		t.Fatalf("f isn't assigned in init:\n%s", code)
+	}
+}
+
+func TestCompile_WithStatement(t *testing.T) {
+	// let obj
+	// with (obj) {}
+	astJSON := `{"type":"File","start":0,"end":22,"loc":{"start":{"line":1,"column":0},"end":{"line":2,"column":13}},"program":{"type":"Program","start":0,"end":22,"loc":{"start":{"line":1,"column":0},"end":{"line":2,"column":13}},"sourceType":"script","body":[{"type":"VariableDeclaration","start":0,"end":7,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":7}},"declarations":[{"type":"VariableDeclarator","start":4,"end":7,"loc":{"start":{"line":1,"column":4},"end":{"line":1,"column":7}},"id":{"type":"Identifier","start":4,"end":7,"loc":{"start":{"line":1,"column":4},"end":{"line":1,"column":7},"identifierName":"obj"},"name":"obj"},"init":null}],"kind":"let"},{"type":"WithStatement","start":8,"end":22,"loc":{"start":{"line":2,"column":0},"end":{"line":2,"column":13}},"object":{"type":"Identifier","start":14,"end":17,"loc":{"start":{"line":2,"column":6},"end":{"line":2,"column":9},"identifierName":"obj"},"name":"obj"},"body":{"type":"BlockStatement","start":19,"end":22,"loc":{"start":{"line":2,"column":11},"end":{"line":2,"column":13}},"body":[],"directives":[]}}],"directives":[]}}`
+
+	f := &ast.File{}
+	if err := json.Unmarshal([]byte(astJSON), f); err != nil {
+		t.Fatalf("error decoding AST JSON: %s", err)
+	}
+
+	_, err := Compile(f, CompileOptions{})
+	if err == nil {
+		t.Fatal("want error compiling with statement")
+	}
+	if want, got := "2:0: with statement is not supportable", err.Error(); want != got {
+		t.Fatalf("error doesn't match: want=%q got=%q", want, got)
+	}
+	if _, ok := err.(*CompileError); !ok {
+		t.Fatalf("want CompileError, got %T", err)
+	}
+}
+
+func TestCompile_SymbolKey(t *testing.T) {
+	// obj[Symbol.iterator]
+	key := member(ident("Symbol"), ident("iterator"))
+	key.Attr.Loc = &ast.SourceLocation{
+		Start: &ast.Position{Line: 1, Column: 4},
+		End:   &ast.Position{Line: 1, Column: 19},
+	}
+	f := file(exprStmt(index(ident("obj"), key)))
+
+	_, err := Compile(f, CompileOptions{})
+	if _, ok := err.(*CompileError); !ok {
+		t.Fatalf("want CompileError, got %T: %v", err, err)
+	}
+	if want, got := "1:4: symbol key Symbol.iterator is not supported", err.Error(); want != got {
+		t.Fatalf("error doesn't match: want=%q got=%q", want, got)
+	}
+
+	// let Symbol = { iterator: "it" }
+	// obj[Symbol.iterator]
+	f = file(
+		varDecl("let", "Symbol", object(prop(ident("iterator"), str("it")))),
+		exprStmt(index(ident("obj"), member(ident("Symbol"), ident("iterator")))),
+	)
+	compile(t, f, CompileOptions{})
+}
+
+func TestCompile_OptionalChainAssignment(t *testing.T) {
+	tests := []struct {
+		f    *ast.File
+		want string
+	}{
+		// a?.b = c
+		{file(exprStmt(assign("=", optionalMember(ident("a"), ident("b"), true), ident("c")))),
+			"invalid assignment to optional chain a?.b"},
+		// [a?.b] = c
+		{file(exprStmt(assign("=", arrayPattern(optionalMember(ident("a"), ident("b"), true)), ident("c")))),
+			"invalid assignment to optional chain a?.b"},
+	}
+
+	for _, test := range tests {
+		_, err := Compile(test.f, CompileOptions{})
+		if _, ok := err.(*CompileError); !ok {
+			t.Fatalf("want CompileError, got %T: %v", err, err)
+		}
+		if !strings.HasSuffix(err.Error(), test.want) {
+			t.Errorf("error %q doesn't end with %q", err, test.want)
+		}
+	}
+}
+
+func TestCompile_PanicRecovery(t *testing.T) {
+	// console.log("ok")
+	//
+	// <call without callee>()
+	broken := &ast.CallExpression{Attr: attr("CallExpression")}
+	broken.Attr.Loc = &ast.SourceLocation{
+		Start: &ast.Position{Line: 3, Column: 2},
+		End:   &ast.Position{Line: 3, Column: 4},
+	}
+	f := file(
+		exprStmt(call(member(ident("console"), ident("log")), str("ok"))),
+		&ast.ExpressionStatement{Attr: broken.Attr, Expression: broken},
+	)
+
+	_, err := Compile(f, CompileOptions{})
+	ce, ok := err.(*CompileError)
+	if !ok {
+		t.Fatalf("want CompileError, got %T: %v", err, err)
+	}
+	if !strings.HasPrefix(ce.Error(), "3:2: internal compiler error: ") {
+		t.Fatalf("error isn't located at the broken call: %s", ce)
+	}
+}
+
+func TestCompile_StringLiteralEscaping(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{`say "hi"`, `JSString("say \"hi\"")`},
+		{"a\nb", `JSString("a\nb")`},
+		{"a\tb", `JSString("a\tb")`},
+		{`C:\dir`, `JSString("C:\\dir")`},
+		{"héllo, 世界", `JSString("héllo, 世界")`},
+		{"\U0001F600", `JSString("😀")`},
+		{"a\x00b", `JSString("a\x00b")`},
+		{"\xed\xa0\xbd", `JSString("\xed\xa0\xbd")`},
+	}
+
+	for _, test := range tests {
+		code := compile(t, file(exprStmt(call(member(ident("console"), ident("log")), str(test.value)))), CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Fatalf("compiled code doesn't contain %s:\n%s", test.want, code)
+		}
+	}
+}
+
+func TestCompile_ForStatementClauses(t *testing.T) {
+	tests := []struct {
+		fs   *ast.ForStatement
+		want string
+	}{
+		// for (; x;) { break; }
+		{
+			&ast.ForStatement{Attr: attr("ForStatement"), Test: ident("x"), Body: block(&ast.BreakStatement{Attr: attr("BreakStatement")})},
+			"for Truthy(global.Resolve(\"x\")) {\n// line 1: break;\nbreak\n}",
+		},
+		// for (;;) { break; }
+		{
+			&ast.ForStatement{Attr: attr("ForStatement"), Body: block(&ast.BreakStatement{Attr: attr("BreakStatement")})},
+			"for {\n// line 1: break;\nbreak\n}",
+		},
+		// for (let i = 0;; i++) {}
+		{
+			&ast.ForStatement{Attr: attr("ForStatement"), Init: varDecl("let", "i", num(0)), Update: update("++", ident("i")), Body: block()},
+			"for ; ; i = ToNumber(i) + 1 {",
+		},
+	}
+
+	for _, test := range tests {
+		code := compile(t, file(test.fs), CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("compiled code doesn't contain %q:\n%s", test.want, code)
+		}
+	}
+}
+
+func TestCompile_ForStatementSequenceUpdate(t *testing.T) {
+	// for (let i = 0, j = 3; i < j; i++, j--) {
+	//   console.log(i, j)
+	// }
+	f := file(&ast.ForStatement{
+		Attr: attr("ForStatement"),
+		Init: &ast.VariableDeclaration{
+			Attr: attr("VariableDeclaration"),
+			Kind: "let",
+			Declarations: []*ast.VariableDeclarator{
+				{Attr: attr("VariableDeclarator"), ID: ident("i"), Init: num(0)},
+				{Attr: attr("VariableDeclarator"), ID: ident("j"), Init: num(3)},
+			},
+		},
+		Test:   binary("<", ident("i"), ident("j")),
+		Update: sequence(update("++", ident("i")), update("--", ident("j"))),
+		Body:   block(exprStmt(call(member(ident("console"), ident("log")), ident("i"), ident("j")))),
+	})
+
+	code := compile(t, f, CompileOptions{})
+	want := "for ; Truthy(Less(i, j)); i, j = ToNumber(i) + 1, ToNumber(j) - 1 {"
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_ForStatementDependentSequenceUpdate(t *testing.T) {
+	// let i = 0, j = 0
+	// for (; i < 3; i++, j = i) {}
+	f := file(
+		varDecl("let", "i", num(0)),
+		varDecl("let", "j", num(0)),
+		&ast.ForStatement{
+			Attr:   attr("ForStatement"),
+			Test:   binary("<", ident("i"), num(3)),
+			Update: sequence(update("++", ident("i")), assign("=", ident("j"), ident("i"))),
+			Body:   block(),
+		},
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := "; func() {\ni = ToNumber(i) + 1\nj = i\n}() {"
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_OptionalMemberChain(t *testing.T) {
+	// let a
+	// a?.b?.c
+	f := file(
+		varDecl("let", "a", nil),
+		exprStmt(call(member(ident("console"), ident("log")),
+			optionalMember(optionalMember(ident("a"), ident("b"), true), ident("c"), true),
+		)),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := `Console_Log([]Object{func() Object {
+o1 := a
+if IsNullish(o1) {
+return nil
+}
+o1 = Get(o1, JSString("b"))
+if IsNullish(o1) {
+return nil
+}
+o1 = Get(o1, JSString("c"))
+return o1
+}()})`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_OptionalMemberChainShortCircuit(t *testing.T) {
+	// let o1
+	// a?.b.c
+	f := file(
+		varDecl("let", "o1", nil),
+		exprStmt(call(member(ident("console"), ident("log")),
+			optionalMember(optionalMember(ident("o1"), ident("b"), true), ident("c"), false),
+		)),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	if strings.Count(code, "IsNullish(") != 1 {
+		t.Fatalf("want a single nullish check:\n%s", code)
+	}
+	// the temp var doesn't shadow the o1 var
+	if want := "o2 := o1\n"; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_OptionalCall(t *testing.T) {
+	// let obj
+	// obj?.method(next())
+	// obj.method?.(next())
+	f := file(
+		varDecl("let", "obj", nil),
+		exprStmt(optionalCall(optionalMember(ident("obj"), ident("method"), true), false, call(ident("next")))),
+		exprStmt(optionalCall(member(ident("obj"), ident("method")), true, call(ident("next")))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		`func() Object {
+o1 := obj
+if IsNullish(o1) {
+return nil
+}
+o1 = Get(o1, JSString("method"))
+o1 = Call(o1, []Object{Call(global.Resolve("next"), []Object{})})
+return o1
+}()`,
+		`func() Object {
+o2 := Get(obj, JSString("method"))
+if IsNullish(o2) {
+return nil
+}
+o2 = Call(o2, []Object{Call(global.Resolve("next"), []Object{})})
+return o2
+}()`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_OptionalMethodCallArguments(t *testing.T) {
+	// let a
+	// a?.b(c(), d)
+	f := file(
+		varDecl("let", "a", nil),
+		exprStmt(optionalCall(optionalMember(ident("a"), ident("b"), true), false, call(ident("c")), ident("d"))),
+	)
+
+	// the arguments are evaluated past the guard, only when a isn't nullish
+	code := compile(t, f, CompileOptions{})
+	want := `func() Object {
+o1 := a
+if IsNullish(o1) {
+return nil
+}
+o1 = Get(o1, JSString("b"))
+o1 = Call(o1, []Object{Call(global.Resolve("c"), []Object{}), global.Resolve("d")})
+return o1
+}()`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_OptionalComputedMember(t *testing.T) {
+	// let arr
+	// arr?.[0]
+	// arr?.[next()]
+	f := file(
+		varDecl("let", "arr", nil),
+		exprStmt(optionalIndex(ident("arr"), num(0))),
+		exprStmt(optionalIndex(ident("arr"), call(ident("next")))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	if want := "GetOptional(arr, JSNumber(0))"; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+
+	// next isn't called when arr is nullish
+	want := `func() Object {
+o1 := arr
+if IsNullish(o1) {
+return nil
+}
+o1 = Get(o1, Call(global.Resolve("next"), []Object{}))
+return o1
+}()`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_StaticComputedKeys(t *testing.T) {
+	// let o = {["get" + "X"]: 1}
+	// o["get" + "X"]
+	// o[1 + 2]
+	// o["a" + b]
+	key := binary("+", str("get"), str("X"))
+	f := file(
+		varDecl("let", "o", object(&ast.ObjectProperty{Attr: attr("ObjectProperty"), Key: key, Value: num(1), Computed: true})),
+		exprStmt(index(ident("o"), key)),
+		exprStmt(index(ident("o"), binary("+", num(1), num(2)))),
+		exprStmt(index(ident("o"), binary("+", str("a"), ident("b")))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		`Set(o1, JSString("getX"), JSNumber(1))`,
+		`Get(o, JSString("getX"))`,
+		`Get(o, JSString("3"))`,
+		`Get(o, Add(JSString("a"), global.Resolve("b")))`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_ComputedMemberCompoundAssignment(t *testing.T) {
+	// let arr = console
+	// arr[next()] += 1
+	f := file(
+		varDecl("let", "arr", ident("console")),
+		exprStmt(assign("+=", index(ident("arr"), call(ident("next"))), num(1))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	if n := strings.Count(code, `global.Resolve("next")`); n != 1 {
+		t.Fatalf("want next() compiled once, got %d times:\n%s", n, code)
+	}
+
+	want := `func() Object {
+o1, k2 := arr, Call(global.Resolve("next"), []Object{})
+return Set(o1, k2, Add(Get(o1, k2), JSNumber(1)))
+}()`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_Division(t *testing.T) {
+	// let a = 1
+	// a / 0
+	// a %= 2
+	f := file(
+		varDecl("let", "a", num(1)),
+		exprStmt(binary("/", ident("a"), num(0))),
+		exprStmt(assign("%=", ident("a"), num(2))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		"Divide(a, JSNumber(0))",
+		"a = Modulo(a, JSNumber(2))",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_UpdateValue(t *testing.T) {
+	// let i = 0
+	// arr[i++]
+	// f(--i)
+	predec := update("--", ident("i"))
+	predec.Prefix = true
+	f := file(
+		varDecl("let", "i", num(0)),
+		exprStmt(index(ident("arr"), update("++", ident("i")))),
+		exprStmt(call(ident("f"), predec)),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		// the element at the former index is read
+		`Get(global.Resolve("arr"), func() Object {
+u1 := ToNumber(i)
+i = ToNumber(i) + 1
+return u1
+}())`,
+		`Call(global.Resolve("f"), []Object{func() Object {
+i = ToNumber(i) - 1
+return i
+}()})`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+
+	// a float64 var keeps its type
+	code = compile(t, file(
+		varDecl("let", "i", num(0)),
+		exprStmt(index(ident("arr"), update("++", ident("i")))),
+	), CompileOptions{InferTypes: true})
+	want := `u1 := JSNumber(i)
+i = i + 1
+return u1`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_UpdateOrder(t *testing.T) {
+	// let a = [10, 20, 30]
+	// let i = 0
+	// console.log(a[i++], i, ++i)
+	// console.log(i, i--, i + 1)
+	preinc := update("++", ident("i"))
+	preinc.Prefix = true
+	log := func(args ...ast.Expression) ast.Statement {
+		return exprStmt(call(member(ident("console"), ident("log")), args...))
+	}
+	f := file(
+		varDecl("let", "a", array(num(10), num(20), num(30))),
+		varDecl("let", "i", num(0)),
+		log(index(ident("a"), update("++", ident("i"))), ident("i"), preinc),
+		log(ident("i"), update("--", ident("i")), binary("+", ident("i"), num(1))),
+	)
+
+	for _, opts := range []CompileOptions{{}, {InferTypes: true}} {
+		code := compile(t, f, opts)
+		if got, want := goCommand(t, code, "main.go", "run"), "10 1 2\n2 2 2\n"; got != want {
+			t.Errorf("output with InferTypes=%t: want=%q got=%q\n%s", opts.InferTypes, want, got, code)
+		}
+	}
+}
+
+func TestCompile_MemberAssignment(t *testing.T) {
+	// let obj = console
+	// obj.a = 1
+	// obj[k] *= 2
+	f := file(
+		varDecl("let", "obj", ident("console")),
+		varDecl("let", "k", str("a")),
+		exprStmt(assign("=", member(ident("obj"), ident("a")), num(1))),
+		exprStmt(assign("*=", index(ident("obj"), ident("k")), num(2))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		`Set(obj, JSString("a"), JSNumber(1))`,
+		"Set(obj, k, Mul(Get(obj, k), JSNumber(2)))",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_VarHoisting(t *testing.T) {
+	// function f() {
+	//   console.log(x)
+	//   {
+	//     var x = 1
+	//     let y = 2
+	//   }
+	//   return x
+	// }
+	log := member(ident("console"), ident("log"))
+	f := file(funcDecl("f", nil,
+		exprStmt(call(log, ident("x"))),
+		block(varDecl("var", "x", num(1)), varDecl("let", "y", num(2))),
+		ret(ident("x")),
+	))
+
+	code := compile(t, f, CompileOptions{})
+	want := `f = NewFunction(func(args []Object) Object {
+var x Object
+_ = x
+// line 1: console.log(x);
+Console_Log([]Object{x})`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+	if n := strings.Count(code, "var x Object"); n != 1 {
+		t.Fatalf("want x declared once, got %d times:\n%s", n, code)
+	}
+	if strings.Index(code, "var y Object") < strings.Index(code, "// line 1: {") {
+		t.Fatalf("y isn't declared in its block:\n%s", code)
+	}
+	if strings.Contains(code, `global.Resolve("x")`) {
+		t.Fatalf("x isn't hoisted to the top of f:\n%s", code)
+	}
+}
+
+func TestCompile_HoistedFunctionCaptures(t *testing.T) {
+	// let n = 1
+	// function inc() { n += 1 }
+	// function f() { return x }
+	// let x = 1
+	// let unused = 2
+	body := func() []ast.Statement {
+		return []ast.Statement{
+			varDecl("let", "n", num(1)),
+			funcDecl("inc", nil, exprStmt(assign("+=", ident("n"), num(1)))),
+			funcDecl("f", nil, ret(ident("x"))),
+			varDecl("let", "x", num(1)),
+			varDecl("let", "unused", num(2)),
+		}
+	}
+
+	for _, f := range []*ast.File{
+		file(body()...),
+		// the same in the body of a function
+		file(funcDecl("outer", nil, body()...)),
+	} {
+		code := compile(t, f, CompileOptions{InferTypes: true})
+		for _, want := range []string{
+			"n = Add(n, JSNumber(1))",
+			"return x",
+			"n = JSNumber(1)",
+			"x = JSNumber(1)",
+			// names the functions don't reference keep their inferred type
+			"unused := 2.0",
+		} {
+			if !strings.Contains(code, want) {
+				t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+			}
+		}
+		if strings.Index(code, "var x Object") > strings.Index(code, "return x") {
+			t.Fatalf("x isn't declared ahead of the hoisted functions:\n%s", code)
+		}
+		if strings.Contains(code, "global.Resolve") {
+			t.Fatalf("compiled code resolves a declared name as a global:\n%s", code)
+		}
+	}
+}
+
+func TestCompile_ChainedMethodCalls(t *testing.T) {
+	// "a,b".split(",").map(f).filter(g)
+	chain := call(member(call(member(call(member(str("a,b"), ident("split")), str(",")), ident("map")), ident("f")), ident("filter")), ident("g"))
+	f := file(
+		funcDecl("f", []string{"s"}, ret(ident("s"))),
+		funcDecl("g", []string{"s"}, ret(ident("s"))),
+		exprStmt(chain),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := `Call(Get(Call(Get(Call(Get(JSString("a,b"), JSString("split")), []Object{JSString(",")}), JSString("map")), []Object{f}), JSString("filter")), []Object{g})`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_ObjectSpread(t *testing.T) {
+	// let a = {}
+	// let b = {}
+	// let o = {...a, ...b, c: 1}
+	f := file(
+		varDecl("let", "a", object()),
+		varDecl("let", "b", object()),
+		varDecl("let", "o", object(spread(ident("a")), spread(ident("b")), prop(ident("c"), num(1)))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := `o = func() Object {
+o1 := NewObject()
+SpreadObject(o1, a)
+SpreadObject(o1, b)
+Set(o1, JSString("c"), JSNumber(1))
+return o1
+}()`
+	for _, want := range []string{"a = NewObject()", want} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_ObjectGetter(t *testing.T) {
+	// let o = {a: 1, get x() { return compute() }}
+	getter := &ast.ObjectMethod{
+		Attr: attr("ObjectMethod"),
+		Kind: "get",
+		Key:  ident("x"),
+		Body: block(ret(call(ident("compute")))),
+	}
+	f := file(varDecl("let", "o", object(prop(ident("a"), num(1)), getter)))
+
+	code := compile(t, f, CompileOptions{})
+	want := `Set(o1, JSString("a"), JSNumber(1))
+DefineGetter(o1, JSString("x"), NewFunction(func(args []Object) Object {`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+
+	getter.Kind = "set"
+	if _, err := Compile(f, CompileOptions{}); err == nil || !strings.Contains(err.Error(), "set accessor is not supported") {
+		t.Fatalf("expected a setter error, got %v", err)
+	}
+}
+
+func TestCompile_SparseArray(t *testing.T) {
+	// let a = [1, , 3]
+	// let [x, y] = [, 2]
+	f := file(
+		varDecl("let", "a", array(num(1), nil, num(3))),
+		varDecl("let", "x", nil),
+		varDecl("let", "y", nil),
+		exprStmt(assign("=", arrayPattern(ident("x"), ident("y")), array(nil, num(2)))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		"a = NewArrayWithHoles([]Object{JSNumber(1), nil, JSNumber(3)}, 1)",
+		"x, y = nil, JSNumber(2)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+	typeCheck(t, code)
+}
+
+func TestCompile_ArraySpread(t *testing.T) {
+	// let a = [..."ab", 1, , ...b]
+	// let [x, y] = [...a]
+	f := file(
+		varDecl("let", "a", array(spread(str("ab")), num(1), nil, spread(ident("b")))),
+		varDecl("let", "x", nil),
+		varDecl("let", "y", nil),
+		exprStmt(assign("=", arrayPattern(ident("x"), ident("y")), array(spread(ident("a"))))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := `a = func() Object {
+var h2 []int
+var e1 []Object
+e1 = SpreadIterable(e1, JSString("ab"))
+e1 = append(e1, JSNumber(1))
+h2 = append(h2, len(e1))
+e1 = append(e1, nil)
+e1 = SpreadIterable(e1, global.Resolve("b"))
+return NewArrayWithHoles(e1, h2...)
+}()`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+	// spread values aren't assigned in parallel
+	if want := "SpreadIterable(e4, a)"; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_CallArguments(t *testing.T) {
+	tests := []struct {
+		e    ast.Expression
+		want string
+	}{
+		// f()
+		{call(ident("f")), `Call(global.Resolve("f"), []Object{})`},
+		// f(a)
+		{call(ident("f"), ident("a")), `Call(global.Resolve("f"), []Object{global.Resolve("a")})`},
+		// new F(a, b)
+		{newExpr(ident("F"), ident("a"), ident("b")), `New(global.Resolve("F"), []Object{global.Resolve("a"), global.Resolve("b")})`},
+		// new Date(...parts)
+		{newExpr(ident("Date"), spread(ident("parts"))), `New(global.Resolve("Date"), func() []Object {
+var e1 []Object
+e1 = SpreadIterable(e1, global.Resolve("parts"))
+return e1
+}())`},
+		// new F(a, ...b, c)
+		{newExpr(ident("F"), ident("a"), spread(ident("b")), ident("c")), `New(global.Resolve("F"), func() []Object {
+var e1 []Object
+e1 = append(e1, global.Resolve("a"))
+e1 = SpreadIterable(e1, global.Resolve("b"))
+e1 = append(e1, global.Resolve("c"))
+return e1
+}())`},
+		// f(a, ...b)
+		{call(ident("f"), ident("a"), spread(ident("b"))), `Call(global.Resolve("f"), func() []Object {
+var e1 []Object
+e1 = append(e1, global.Resolve("a"))
+e1 = SpreadIterable(e1, global.Resolve("b"))
+return e1
+}())`},
+		// console.log(...a)
+		{call(member(ident("console"), ident("log")), spread(ident("a"))), `Console_Log(func() []Object {
+var e1 []Object
+e1 = SpreadIterable(e1, global.Resolve("a"))
+return e1
+}())`},
+		// f?.(...a)
+		{optionalCall(ident("f"), true, spread(ident("a"))), `o1 = Call(o1, func() []Object {
+var e2 []Object
+e2 = SpreadIterable(e2, global.Resolve("a"))
+return e2
+}())`},
+	}
+
+	for _, test := range tests {
+		code := compile(t, file(exprStmt(test.e)), CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("compiled code of %s doesn't contain %q:\n%s", test.e, test.want, code)
+		}
+		// multi-line arguments are valid Go gofmt can format
+		if _, err := format.Source([]byte(code)); err != nil {
+			t.Errorf("compiled code of %s doesn't format: %s\n%s", test.e, err, code)
+		}
+	}
+}
+
+func TestCompile_Switch(t *testing.T) {
+	// let x = 1
+	// switch (x) {
+	// case "1":
+	//   console.log("string")
+	//   break
+	// case 0:
+	// case 1:
+	//   let y = "number"
+	// default:
+	//   console.log(y)
+	// }
+	f := file(
+		varDecl("let", "x", num(1)),
+		switchStmt(ident("x"),
+			switchCase(str("1"), exprStmt(call(member(ident("console"), ident("log")), str("string"))), &ast.BreakStatement{Attr: attr("BreakStatement")}),
+			switchCase(num(0)),
+			switchCase(num(1), varDecl("let", "y", str("number"))),
+			switchCase(nil, exprStmt(call(member(ident("console"), ident("log")), ident("y")))),
+		),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := `{
+s1 := x
+var y Object
+_ = y
+switch {
+case StrictEquals(s1, JSString("1")):
+// line 1: console.log("string");
+Console_Log([]Object{JSString("string")})
+// line 1: break;
+break
+case StrictEquals(s1, JSNumber(0)), StrictEquals(s1, JSNumber(1)):
+// line 1: let y = "number";
+y = JSString("number")
+
+fallthrough
+default:
+// line 1: console.log(y);
+Console_Log([]Object{y})
+}
+}`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_ForOfStatement(t *testing.T) {
+	// function gen() { return [1, 2] }
+	// for (const x of gen()) console.log(x)
+	// let y
+	// for (y of "ab") {}
+	// for (z of "cd") {}
+	f := file(
+		funcDecl("gen", nil, ret(array(num(1), num(2)))),
+		forOf(&ast.VariableDeclaration{Attr: attr("VariableDeclaration"), Kind: "const", Declarations: []*ast.VariableDeclarator{
+			{Attr: attr("VariableDeclarator"), ID: ident("x")},
+		}}, call(ident("gen")), exprStmt(call(member(ident("console"), ident("log")), ident("x")))),
+		&ast.VariableDeclaration{Attr: attr("VariableDeclaration"), Kind: "let", Declarations: []*ast.VariableDeclarator{
+			{Attr: attr("VariableDeclarator"), ID: ident("y")},
+		}},
+		forOf(ident("y"), str("ab"), block()),
+		forOf(ident("z"), str("cd"), block()),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		`for _, x := range Iterate(Call(gen, []Object{})) {
+_ = x
+// line 1: console.log(x);
+Console_Log([]Object{x})
+}`,
+		`for _, v1 := range Iterate(JSString("ab")) {
+y = v1
+}`,
+		// an undeclared loop variable is a property of the global object
+		`for _, v2 := range Iterate(JSString("cd")) {
+Set(global, JSString("z"), v2)
+}`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+	typeCheck(t, code)
+}
+
+func TestCompile_ForAwaitOf(t *testing.T) {
+	// for await (const v of promises) {}
+	loop := forOf(varDecl("const", "v", nil), ident("promises"), block())
+	loop.Await = true
+
+	_, err := Compile(file(loop), CompileOptions{})
+	if _, ok := err.(*CompileError); !ok {
+		t.Fatalf("want CompileError, got %T: %v", err, err)
+	}
+	if want := "for await...of is not supported"; !strings.Contains(err.Error(), want) {
+		t.Fatalf("error %q doesn't contain %q", err, want)
+	}
+}
+
+func TestCompile_DoWhileContinue(t *testing.T) {
+	// let i = 0
+	// do {
+	//   i++
+	//   switch (i) {
+	//   case 2:
+	//     continue
+	//   }
+	//   console.log(i)
+	// } while (i < 3)
+	f := file(
+		varDecl("let", "i", num(0)),
+		&ast.DoWhileStatement{Attr: attr("DoWhileStatement"), Body: block(
+			exprStmt(update("++", ident("i"))),
+			switchStmt(ident("i"), switchCase(num(2), &ast.ContinueStatement{Attr: attr("ContinueStatement")})),
+			exprStmt(call(member(ident("console"), ident("log")), ident("i"))),
+		), Test: binary("<", ident("i"), num(3))},
+	)
+
+	// the continue jumps to the post statement, ahead of the test
+	code := compile(t, f, CompileOptions{})
+	want := `for first1 := true; first1 || Truthy(Less(i, JSNumber(3))); first1 = false {
+// line 1: i++;
+i = ToNumber(i) + 1
+`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+	if !strings.Contains(code, "continue\n") {
+		t.Fatalf("compiled code doesn't continue the loop:\n%s", code)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("compiled code isn't valid Go: %s\n%s", err, code)
+	}
+}
+
+func TestCompile_SwitchEmptyCases(t *testing.T) {
+	// switch (x) {
+	// case 1:
+	// case 2:
+	// case 3:
+	//   console.log("low")
+	//   break
+	// case 4:
+	//   console.log("four")
+	// case 5:
+	//   console.log("high")
+	// }
+	log := func(s string) ast.Statement { return exprStmt(call(member(ident("console"), ident("log")), str(s))) }
+	f := file(
+		varDecl("let", "x", num(2)),
+		switchStmt(ident("x"),
+			switchCase(num(1)),
+			switchCase(num(2)),
+			switchCase(num(3), log("low"), &ast.BreakStatement{Attr: attr("BreakStatement")}),
+			switchCase(num(4), log("four")),
+			switchCase(num(5), log("high")),
+		),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := `switch {
+case StrictEquals(s1, JSNumber(1)), StrictEquals(s1, JSNumber(2)), StrictEquals(s1, JSNumber(3)):
+// line 1: console.log("low");
+Console_Log([]Object{JSString("low")})
+// line 1: break;
+break
+case StrictEquals(s1, JSNumber(4)):
+// line 1: console.log("four");
+Console_Log([]Object{JSString("four")})
+fallthrough
+case StrictEquals(s1, JSNumber(5)):
+// line 1: console.log("high");
+Console_Log([]Object{JSString("high")})
+}`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_SwitchTypeOf(t *testing.T) {
+	// switch (typeof f()) {
+	// case "number":
+	//   console.log("number")
+	//   break
+	// case "string":
+	//   console.log("string")
+	//   break
+	// default:
+	//   console.log("other")
+	// }
+	log := func(s string) ast.Statement { return exprStmt(call(member(ident("console"), ident("log")), str(s))) }
+	brk := &ast.BreakStatement{Attr: attr("BreakStatement")}
+	typeOf := &ast.UnaryExpression{Attr: attr("UnaryExpression"), Operator: "typeof", Argument: call(ident("f"))}
+	f := file(switchStmt(typeOf,
+		switchCase(str("number"), log("number"), brk),
+		switchCase(str("string"), log("string"), brk),
+		switchCase(nil, log("other")),
+	))
+
+	code := compile(t, f, CompileOptions{})
+	want := `s1 := TypeOf(Call(global.Resolve("f"), []Object{}))
+switch {
+case s1 == "number":
+// line 1: console.log("number");
+Console_Log([]Object{JSString("number")})
+// line 1: break;
+break
+case s1 == "string":
+// line 1: console.log("string");
+Console_Log([]Object{JSString("string")})
+// line 1: break;
+break
+default:
+// line 1: console.log("other");
+Console_Log([]Object{JSString("other")})
+}`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+	// the discriminant is evaluated once
+	if n := strings.Count(code, "TypeOf("); n != 1 {
+		t.Fatalf("want one TypeOf call, got %d:\n%s", n, code)
+	}
+}
+
+func TestCompile_PreserveLineNumbers(t *testing.T) {
+	log := func(s string, line int) ast.Statement {
+		stmt := exprStmt(call(member(ident("console"), ident("log")), str(s)))
+		stmt.Loc.Start.Line = line
+		return stmt
+	}
+	// the statement on line 2 is offset by the package clause and the
+	// imports
+	f := file(log("a", 2), log("b", 15), log("c", 30))
+
+	code := compile(t, f, CompileOptions{PreserveLineNumbers: true})
+	lines := strings.Split(code, "\n")
+	for line, want := range map[int]string{
+		15: `Console_Log([]Object{JSString("b")})`,
+		30: `Console_Log([]Object{JSString("c")})`,
+	} {
+		if got := strings.TrimSpace(lines[line-1]); got != want {
+			t.Errorf("line %d: want=%q got=%q:\n%s", line, want, got, code)
+		}
+	}
+	if !strings.Contains(code, `// line 2: console.log("a");`+"\n"+`Console_Log([]Object{JSString("a")})`) {
+		t.Errorf("want the statement on line 2 compiled right after its line comment:\n%s", code)
+	}
+}
+
+func TestCompile_ArrowFunction(t *testing.T) {
+	// let arr = [1, 2]
+	// arr.map(x => x + 1)
+	arrow := &ast.ArrowFunctionExpression{
+		Attr:   attr("ArrowFunctionExpression"),
+		Params: []ast.Expression{ident("x")},
+		Body:   binary("+", ident("x"), num(1)),
+	}
+	f := file(
+		varDecl("let", "arr", array(num(1), num(2))),
+		exprStmt(call(member(ident("arr"), ident("map")), arrow)),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := `Call(Get(arr, JSString("map")), []Object{NewFunction(func(args []Object) Object {
+x := Arg(args, 0)
+_ = x
+return Add(x, JSNumber(1))
+})})`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("compiled code isn't valid Go: %s\n%s", err, code)
+	}
+}
+
+func TestCompile_ReturnSequence(t *testing.T) {
+	// function f(value) {
+	//   return (console.log(value), value)
+	// }
+	// const g = (value) => (console.log(value), value)
+	// function h(value) {
+	//   return (0, value)
+	// }
+	// const k = (value) => (value, 1)
+	logValue := call(member(ident("console"), ident("log")), ident("value"))
+	arrow := func(body ast.Expression) *ast.ArrowFunctionExpression {
+		return &ast.ArrowFunctionExpression{
+			Attr:   attr("ArrowFunctionExpression"),
+			Params: []ast.Expression{ident("value")},
+			Body:   body,
+		}
+	}
+	f := file(
+		funcDecl("f", []string{"value"}, ret(sequence(logValue, ident("value")))),
+		varDecl("const", "g", arrow(sequence(logValue, ident("value")))),
+		funcDecl("h", []string{"value"}, ret(sequence(num(0), ident("value")))),
+		varDecl("const", "k", arrow(sequence(ident("value"), num(1)))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := `Console_Log([]Object{value})
+return value
+`
+	if n := strings.Count(code, want); n != 2 {
+		t.Fatalf("compiled code contains %q %d times, want 2:\n%s", want, n, code)
+	}
+	for _, want := range []string{
+		"_ = JSNumber(0)\nreturn value\n",
+		"_ = value\nreturn JSNumber(1)\n",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+	typeCheck(t, code)
+}
+
+func TestCompile_GlobalThis(t *testing.T) {
+	// console.log(globalThis.console === console)
+	// globalThis.answer = 42
+	// console.log(typeof window)
+	f := file(
+		exprStmt(call(member(ident("console"), ident("log")), binary("===", member(ident("globalThis"), ident("console")), ident("console")))),
+		exprStmt(assign("=", member(ident("globalThis"), ident("answer")), num(42))),
+		exprStmt(call(member(ident("console"), ident("log")), &ast.UnaryExpression{Attr: attr("UnaryExpression"), Operator: "typeof", Argument: ident("window")})),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		`Console_Log([]Object{JSBoolean(StrictEquals(Get(global, JSString("console")), global.Resolve("console")))})`,
+		`Set(global, JSString("answer"), JSNumber(42))`,
+		`TypeOf(global.Get("window"))`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+	if got, want := goCommand(t, code, "main.go", "run"), "true\nundefined\n"; got != want {
+		t.Errorf("output: want=%q got=%q", want, got)
+	}
+}
+
+func TestCompile_GlobalAssignment(t *testing.T) {
+	// q = 1
+	// console.log(q += 1)
+	f := file(
+		exprStmt(assign("=", ident("q"), num(1))),
+		exprStmt(call(member(ident("console"), ident("log")), assign("+=", ident("q"), num(1)))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		`Set(global, JSString("q"), JSNumber(1))`,
+		`Console_Log([]Object{Set(global, JSString("q"), Add(global.Resolve("q"), JSNumber(1)))})`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+	typeCheck(t, code)
+}
+
+func TestCompile_StringKeys(t *testing.T) {
+	// let obj = {}
+	// console.log(obj["valid"], obj["has-dash"])
+	// console["log"](obj.valid)
+	f := file(
+		varDecl("let", "obj", object()),
+		exprStmt(call(member(ident("console"), ident("log")), index(ident("obj"), str("valid")), index(ident("obj"), str("has-dash")))),
+		exprStmt(call(index(ident("console"), str("log")), member(ident("obj"), ident("valid")))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		`Console_Log([]Object{Get(obj, JSString("valid")), Get(obj, JSString("has-dash"))})`,
+		`Console_Log([]Object{Get(obj, JSString("valid"))})`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_NumericKeys(t *testing.T) {
+	// let obj = {}, arr = []
+	// console.log(obj[0], arr[0])
+	f := file(
+		varDecl("let", "obj", object()),
+		varDecl("let", "arr", array()),
+		exprStmt(call(member(ident("console"), ident("log")), index(ident("obj"), num(0)), index(ident("arr"), num(0)))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	if want := `Console_Log([]Object{Get(obj, JSNumber(0)), Get(arr, JSNumber(0))})`; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_StringIndex(t *testing.T) {
+	// let s = "héllo"
+	// console.log("héllo"[1], s[i])
+	f := file(
+		varDecl("let", "s", str("héllo")),
+		exprStmt(call(member(ident("console"), ident("log")), index(str("héllo"), num(1)), index(ident("s"), ident("i")))),
+	)
+
+	code := compile(t, f, CompileOptions{InferTypes: true})
+	if want := `Console_Log([]Object{StringIndex(JSString("héllo"), JSNumber(1)), StringIndex(JSString(s), global.Resolve("i"))})`; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_StaticMethods(t *testing.T) {
+	// let obj = {}, arr = []
+	// console.log(Object.keys(obj), Array.isArray(arr))
+	f := file(
+		varDecl("let", "obj", object()),
+		varDecl("let", "arr", array()),
+		exprStmt(call(member(ident("console"), ident("log")),
+			call(member(ident("Object"), ident("keys")), ident("obj")),
+			call(member(ident("Array"), ident("isArray")), ident("arr")),
+		)),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	if want := `Console_Log([]Object{Object_Keys([]Object{obj}), Array_IsArray([]Object{arr})})`; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_LiteralMember(t *testing.T) {
+	// console.log("abc".toUpperCase(), [1, 2, 3].length)
+	f := file(exprStmt(call(member(ident("console"), ident("log")),
+		call(member(str("abc"), ident("toUpperCase"))),
+		member(array(num(1), num(2), num(3)), ident("length")),
+	)))
+
+	code := compile(t, f, CompileOptions{})
+	want := `Console_Log([]Object{Call(Get(JSString("abc"), JSString("toUpperCase")), []Object{}), Get(NewArray([]Object{JSNumber(1), JSNumber(2), JSNumber(3)}), JSString("length"))})`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_InOperator(t *testing.T) {
+	// let obj = {a: 1}
+	// console.log("a" in obj)
+	f := file(
+		varDecl("let", "obj", object(prop(ident("a"), num(1)))),
+		exprStmt(call(member(ident("console"), ident("log")), binary("in", str("a"), ident("obj")))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	if want := `Console_Log([]Object{In(JSString("a"), obj)})`; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_TypeOf(t *testing.T) {
+	// let n = 1
+	// console.log(typeof n, typeof undeclared, typeof null)
+	typeOf := func(e ast.Expression) *ast.UnaryExpression {
+		return &ast.UnaryExpression{Attr: attr("UnaryExpression"), Operator: "typeof", Argument: e}
+	}
+	f := file(
+		varDecl("let", "n", num(1)),
+		exprStmt(call(member(ident("console"), ident("log")),
+			typeOf(ident("n")), typeOf(ident("undeclared")), typeOf(&ast.NullLiteral{Attr: attr("NullLiteral")}))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	// undeclared variables don't throw
+	if want := `Console_Log([]Object{TypeOf(n), TypeOf(global.Get("undeclared")), TypeOf(Null)})`; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_LogicalNot(t *testing.T) {
+	not := func(e ast.Expression) *ast.UnaryExpression {
+		return &ast.UnaryExpression{Attr: attr("UnaryExpression"), Operator: "!", Argument: e}
+	}
+	tests := []struct {
+		e    ast.Expression
+		want string
+	}{
+		// !(a === b)
+		{not(binary("===", ident("a"), ident("b"))), `JSBoolean(!StrictEquals(a, b))`},
+		// !(a !== b)
+		{not(binary("!==", ident("a"), ident("b"))), `JSBoolean(StrictEquals(a, b))`},
+		// a !== b
+		{binary("!==", ident("a"), ident("b")), `JSBoolean(!StrictEquals(a, b))`},
+		// !(a < b) isn't a >= b, which is false for NaN
+		{not(binary("<", ident("a"), ident("b"))), `JSBoolean(!Truthy(Less(a, b)))`},
+		// !!a
+		{not(not(ident("a"))), `JSBoolean(Truthy(a))`},
+		// !!!a
+		{not(not(not(ident("a")))), `JSBoolean(!Truthy(a))`},
+	}
+
+	for _, test := range tests {
+		f := file(varDecl("let", "a", num(1)), varDecl("let", "b", num(2)), exprStmt(test.e))
+		code := compile(t, f, CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("compiled code of %s doesn't contain %q:\n%s", test.e, test.want, code)
+		}
+	}
+}
+
+func TestCompile_ChainedConditional(t *testing.T) {
+	// let n = 1
+	// console.log(n < 0 ? "neg" : n === 0 ? "zero" : "pos")
+	cond := func(test, consequent, alternate ast.Expression) *ast.ConditionalExpression {
+		return &ast.ConditionalExpression{Attr: attr("ConditionalExpression"), Test: test, Consequent: consequent, Alternate: alternate}
+	}
+	f := file(
+		varDecl("let", "n", num(1)),
+		exprStmt(call(member(ident("console"), ident("log")), cond(
+			binary("<", ident("n"), num(0)),
+			str("neg"),
+			cond(binary("===", ident("n"), num(0)), str("zero"), str("pos")),
+		))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := `Console_Log([]Object{Ternary(Truthy(Less(n, JSNumber(0))), func() Object { return JSString("neg") }, ` +
+		`func() Object { return Ternary(StrictEquals(n, JSNumber(0)), func() Object { return JSString("zero") }, func() Object { return JSString("pos") }) })})`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_Void(t *testing.T) {
+	void := func(e ast.Expression) *ast.UnaryExpression {
+		return &ast.UnaryExpression{Attr: attr("UnaryExpression"), Operator: "void", Argument: e}
+	}
+	tests := []struct {
+		e    ast.Expression
+		want string
+	}{
+		// console.log(void 0)
+		{call(member(ident("console"), ident("log")), void(num(0))), `Console_Log([]Object{nil})`},
+		// x = void 0
+		{assign("=", ident("x"), void(num(0))), `x = nil`},
+		// x = void f()
+		{assign("=", ident("x"), void(call(ident("f")))), `x = func() Object {
+_ = Call(f, []Object{})
+return nil
+}()`},
+	}
+
+	for _, test := range tests {
+		f := file(varDecl("let", "x", num(1)), funcDecl("f", nil), exprStmt(test.e))
+		code := compile(t, f, CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("compiled code of %s doesn't contain %q:\n%s", test.e, test.want, code)
+		}
+	}
+}
+
+func TestCompile_Delete(t *testing.T) {
+	// let arr = [1, 2, 3]
+	// delete arr[1]
+	f := file(
+		varDecl("let", "arr", array(num(1), num(2), num(3))),
+		exprStmt(&ast.UnaryExpression{Attr: attr("UnaryExpression"), Operator: "delete", Argument: index(ident("arr"), num(1))}),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	if want := `Delete(arr, JSNumber(1))`; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_Coalesce(t *testing.T) {
+	// function compute() { return null }
+	// const x = compute() ?? fallback
+	f := file(
+		funcDecl("compute", nil, ret(&ast.NullLiteral{Attr: attr("NullLiteral")})),
+		varDecl("const", "x", &ast.LogicalExpression{
+			Attr:     attr("LogicalExpression"),
+			Operator: "??",
+			Left:     call(ident("compute")),
+			Right:    ident("fallback"),
+		}),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := `x = Coalesce(Call(compute, []Object{}), func() Object { return global.Resolve("fallback") })`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+	// the left side is evaluated once
+	if n := strings.Count(code, "Call(compute"); n != 1 {
+		t.Fatalf("want compute called once, got %d calls:\n%s", n, code)
+	}
+}
+
+func TestCompile_LogicalAssignedValue(t *testing.T) {
+	// let a = "", x
+	// x = a || "default"
+	// x = a && check()
+	logical := func(op ast.LogicalOperator, left, right ast.Expression) *ast.LogicalExpression {
+		return &ast.LogicalExpression{Attr: attr("LogicalExpression"), Operator: op, Left: left, Right: right}
+	}
+	f := file(
+		varDecl("let", "a", str("")),
+		varDecl("let", "x", nil),
+		exprStmt(assign("=", ident("x"), logical("||", ident("a"), str("default")))),
+		exprStmt(assign("=", ident("x"), logical("&&", ident("a"), call(ident("check"))))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		`x = Or(a, func() Object { return JSString("default") })`,
+		`x = And(a, func() Object { return Call(global.Resolve("check"), []Object{}) })`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_LogicalNullishMix(t *testing.T) {
+	logical := func(op ast.LogicalOperator, left, right ast.Expression) *ast.LogicalExpression {
+		return &ast.LogicalExpression{Attr: attr("LogicalExpression"), Operator: op, Left: left, Right: right}
+	}
+	tests := []struct {
+		e    ast.Expression
+		want string
+	}{
+		// (a && b) ?? c
+		{logical("??", logical("&&", ident("a"), ident("b")), ident("c")), `// line 1: (a && b) ?? c;
+Coalesce(And(a, func() Object { return b }), func() Object { return c })`},
+		// a ?? (b || c)
+		{logical("??", ident("a"), logical("||", ident("b"), ident("c"))), `// line 1: a ?? (b || c);
+Coalesce(a, func() Object { return Or(b, func() Object { return c }) })`},
+		// (a ?? b) || c
+		{logical("||", logical("??", ident("a"), ident("b")), ident("c")), `// line 1: (a ?? b) || c;
+Or(Coalesce(a, func() Object { return b }), func() Object { return c })`},
+	}
+
+	for _, test := range tests {
+		f := file(varDecl("let", "a", nil), varDecl("let", "b", nil), varDecl("let", "c", nil), exprStmt(test.e))
+		code := compile(t, f, CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("compiled code of %s doesn't contain %q:\n%s", test.e, test.want, code)
+		}
+	}
+}
+
+func TestCompile_TemplateLiteral(t *testing.T) {
+	tests := []struct {
+		tl   *ast.TemplateLiteral
+		want string
+	}{
+		// `x=${[1, 2]}`
+		{
+			template([]string{"x=", ""}, array(num(1), num(2))),
+			`JSString("x=" + string(ToString(NewArray([]Object{JSNumber(1), JSNumber(2)}))))`,
+		},
+		// `o=${{}}`
+		{
+			template([]string{"o=", ""}, object()),
+			`JSString("o=" + string(ToString(NewObject())))`,
+		},
+		// `${a} and ${b}!`
+		{
+			template([]string{"", " and ", "!"}, ident("a"), ident("b")),
+			`JSString(string(ToString(global.Resolve("a"))) + " and " + string(ToString(global.Resolve("b"))) + "!")`,
+		},
+		// ``
+		{template([]string{""}), `JSString("")`},
+	}
+
+	for _, test := range tests {
+		code := compile(t, file(exprStmt(test.tl)), CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("compiled code of %s doesn't contain %q:\n%s", test.tl, test.want, code)
+		}
+	}
+}
+
+func compile(t *testing.T, f *ast.File, opts CompileOptions) string {
+	res, err := Compile(f, opts)
+	if err != nil {
+		t.Fatalf("error compiling: %s", err)
+	}
+
+	return res.Source
+}
+
+// typeCheck fails t when code, the compiled Go source of a main package,
+// doesn't type-check
+func typeCheck(t *testing.T, code string) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", code, 0)
+	if err != nil {
+		t.Fatalf("compiled code isn't valid Go: %s\n%s", err, code)
+	}
+	conf := &types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("main", fset, []*goast.File{f}, nil); err != nil {
+		t.Fatalf("compiled code doesn't build: %s\n%s", err, code)
+	}
+}
+
+// goCommand writes code to a file named name in a temporary directory and
+// returns the output of running the go command on it, e.g. `go run` for
+// the "run" command. The test is skipped in short mode, or when go isn't
+// installed.
+func goCommand(t *testing.T, code, name, command string, flags ...string) string {
+	if testing.Short() {
+		t.Skipf("skipping go %s of the compiled code in short mode", command)
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go isn't installed")
+	}
+
+	dir, err := ioutil.TempDir("", "godzilla")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command("go", append(append([]string{command}, flags...), path)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("error running `go %s`: %s\n%s\n%s", command, err, out, code)
+	}
+
+	return string(out)
+}
+
+// helpers for building ASTs by hand
+
+// loc is the offsets and the source location of the nodes of the AST JSON
+// fixtures
+const loc = `"start":0,"end":0,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":0}}`
+
+func attr(typ string) *ast.Attr {
+	return &ast.Attr{
+		Type: typ,
+		Loc: &ast.SourceLocation{
+			Start: &ast.Position{Line: 1},
+			End:   &ast.Position{Line: 1},
+		},
+	}
+}
+
+func file(body ...ast.Statement) *ast.File {
+	return &ast.File{
+		Attr: attr("File"),
+		Program: &ast.Program{
+			Attr:       attr("Program"),
+			SourceType: "script",
+			Body:       body,
+		},
+	}
+}
+
+func exprStmt(e ast.Expression) *ast.ExpressionStatement {
+	return &ast.ExpressionStatement{Attr: attr("ExpressionStatement"), Expression: e}
+}
+
+func varDecl(kind, name string, init ast.Expression) *ast.VariableDeclaration {
+	return &ast.VariableDeclaration{
+		Attr: attr("VariableDeclaration"),
+		Kind: kind,
+		Declarations: []*ast.VariableDeclarator{
+			{Attr: attr("VariableDeclarator"), ID: ident(name), Init: init},
+		},
+	}
+}
+
+func block(body ...ast.Statement) *ast.BlockStatement {
+	return &ast.BlockStatement{Attr: attr("BlockStatement"), Body: body}
+}
+
+func ret(arg ast.Expression) *ast.ReturnStatement {
+	return &ast.ReturnStatement{Attr: attr("ReturnStatement"), Argument: arg}
+}
+
+func funcDecl(name string, params []string, body ...ast.Statement) *ast.FunctionDeclaration {
+	var ids []ast.Expression
+	for _, p := range params {
+		ids = append(ids, ident(p))
+	}
+
+	return &ast.FunctionDeclaration{
+		Attr:   attr("FunctionDeclaration"),
+		ID:     ident(name),
+		Params: ids,
+		Body:   block(body...),
+	}
+}
+
+func ident(name string) *ast.Identifier {
+	return &ast.Identifier{Attr: attr("Identifier"), Name: name}
+}
+
+func str(value string) *ast.StringLiteral {
+	return &ast.StringLiteral{Attr: attr("StringLiteral"), Value: value}
+}
+
+func num(value float64) *ast.NumericLiteral {
+	return &ast.NumericLiteral{Attr: attr("NumericLiteral"), Value: value}
+}
+
+func call(callee ast.Expression, args ...ast.Expression) *ast.CallExpression {
+	return &ast.CallExpression{Attr: attr("CallExpression"), Callee: callee, Arguments: args}
+}
+
+func binary(op string, left, right ast.Expression) *ast.BinaryExpression {
+	return &ast.BinaryExpression{Attr: attr("BinaryExpression"), Operator: ast.BinaryOperator(op), Left: left, Right: right}
+}
+
+func assign(op string, left, right ast.Expression) *ast.AssignmentExpression {
+	return &ast.AssignmentExpression{Attr: attr("AssignmentExpression"), Operator: ast.AssignmentOperator(op), Left: left, Right: right}
+}
+
+func update(op string, arg ast.Expression) *ast.UpdateExpression {
+	return &ast.UpdateExpression{Attr: attr("UpdateExpression"), Operator: ast.UpdateOperator(op), Argument: arg}
+}
+
+func sequence(exprs ...ast.Expression) *ast.SequenceExpression {
+	return &ast.SequenceExpression{Attr: attr("SequenceExpression"), Expressions: exprs}
+}
+
+func optionalMember(object, property ast.Expression, optional bool) *ast.OptionalMemberExpression {
+	return &ast.OptionalMemberExpression{Attr: attr("OptionalMemberExpression"), Object: object, Property: property, Optional: optional}
+}
+
+func optionalCall(callee ast.Expression, optional bool, args ...ast.Expression) *ast.OptionalCallExpression {
+	return &ast.OptionalCallExpression{Attr: attr("OptionalCallExpression"), Callee: callee, Arguments: args, Optional: optional}
+}
+
+func optionalIndex(object, property ast.Expression) *ast.OptionalMemberExpression {
+	return &ast.OptionalMemberExpression{Attr: attr("OptionalMemberExpression"), Object: object, Property: property, Computed: true, Optional: true}
+}
+
+func member(object, property ast.Expression) *ast.MemberExpression {
+	return &ast.MemberExpression{Attr: attr("MemberExpression"), Object: object, Property: property}
+}
+
+func object(props ...ast.Node) *ast.ObjectExpression {
+	return &ast.ObjectExpression{Attr: attr("ObjectExpression"), Properties: props}
+}
+
+func prop(key, value ast.Expression) *ast.ObjectProperty {
+	return &ast.ObjectProperty{Attr: attr("ObjectProperty"), Key: key, Value: value}
+}
+
+func forOf(left ast.Node, right ast.Expression, body ast.Statement) *ast.ForOfStatement {
+	return &ast.ForOfStatement{Attr: attr("ForOfStatement"), Left: left, Right: right, Body: body}
+}
+
+func switchStmt(discriminant ast.Expression, cases ...*ast.SwitchCase) *ast.SwitchStatement {
+	return &ast.SwitchStatement{Attr: attr("SwitchStatement"), Discriminant: discriminant, Cases: cases}
+}
+
+func switchCase(test ast.Expression, consequent ...ast.Statement) *ast.SwitchCase {
+	return &ast.SwitchCase{Attr: attr("SwitchCase"), Test: test, Consequent: consequent}
+}
+
+func spread(arg ast.Expression) *ast.SpreadElement {
+	return &ast.SpreadElement{Attr: attr("SpreadElement"), Argument: arg}
+}
+
+func template(quasis []string, exprs ...ast.Expression) *ast.TemplateLiteral {
+	tl := &ast.TemplateLiteral{Attr: attr("TemplateLiteral"), Expressions: exprs}
+	for i, q := range quasis {
+		tl.Quasis = append(tl.Quasis, &ast.TemplateElement{
+			Attr:   attr("TemplateElement"),
+			Raw:    q,
+			Cooked: q,
+			Tail:   i == len(quasis)-1,
+		})
+	}
+
+	return tl
+}
+
+func index(object, property ast.Expression) *ast.MemberExpression {
+	return &ast.MemberExpression{Attr: attr("MemberExpression"), Object: object, Property: property, Computed: true}
 }
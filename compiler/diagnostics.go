@@ -0,0 +1,121 @@
+package compiler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// Severity tells how serious a Diagnostic is.
+type Severity int
+
+const (
+	// SeverityError is a problem the file can't be compiled with.
+	SeverityError Severity = iota
+	// SeverityWarning is a problem the compiler worked around, e.g. by
+	// skipping an unsupported construct, which may change what the program
+	// does.
+	SeverityWarning
+	// SeverityInfo is a note on how the file is compiled.
+	SeverityInfo
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	}
+
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Diagnostic is a message about compiling a node, located in the JavaScript
+// source.
+type Diagnostic struct {
+	Severity Severity
+	Loc      *ast.SourceLocation
+	Msg      string
+}
+
+func (d *Diagnostic) String() string {
+	if d.Loc == nil || d.Loc.Start == nil {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Msg)
+	}
+
+	return fmt.Sprintf("%d:%d: %s: %s", d.Loc.Start.Line, d.Loc.Start.Column, d.Severity, d.Msg)
+}
+
+// Diagnostics collects the diagnostics of compilations, in the order they're
+// reported. It's safe for concurrent use.
+type Diagnostics struct {
+	mu   sync.Mutex
+	list []*Diagnostic
+}
+
+// Errorf reports an error located at loc.
+func (d *Diagnostics) Errorf(loc *ast.SourceLocation, format string, a ...interface{}) {
+	d.add(SeverityError, loc, fmt.Sprintf(format, a...))
+}
+
+// Warningf reports a warning located at loc.
+func (d *Diagnostics) Warningf(loc *ast.SourceLocation, format string, a ...interface{}) {
+	d.add(SeverityWarning, loc, fmt.Sprintf(format, a...))
+}
+
+// Infof reports an informational message located at loc.
+func (d *Diagnostics) Infof(loc *ast.SourceLocation, format string, a ...interface{}) {
+	d.add(SeverityInfo, loc, fmt.Sprintf(format, a...))
+}
+
+func (d *Diagnostics) add(sev Severity, loc *ast.SourceLocation, msg string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.list = append(d.list, &Diagnostic{Severity: sev, Loc: loc, Msg: msg})
+}
+
+// List returns the diagnostics reported, or only the ones of the given
+// severities.
+func (d *Diagnostics) List(severities ...Severity) []*Diagnostic {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var list []*Diagnostic
+	for _, diag := range d.list {
+		if len(severities) == 0 {
+			list = append(list, diag)
+			continue
+		}
+
+		for _, sev := range severities {
+			if diag.Severity == sev {
+				list = append(list, diag)
+				break
+			}
+		}
+	}
+
+	return list
+}
+
+// HasErrors tells whether an error was reported.
+func (d *Diagnostics) HasErrors() bool {
+	return len(d.List(SeverityError)) > 0
+}
+
+// warnf reports a warning located at node and carries on compiling.
+func (c *compiler) warnf(node ast.Node, format string, a ...interface{}) {
+	var loc *ast.SourceLocation
+	if attr := node.GetAttr(); attr != nil {
+		loc = attr.Loc
+	}
+
+	msg := fmt.Sprintf(format, a...)
+	c.diags.Warningf(loc, "%s", msg)
+	c.traceDiagnostic(SeverityWarning, loc, msg)
+}
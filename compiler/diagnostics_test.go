@@ -0,0 +1,64 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_Diagnostics(t *testing.T) {
+	// debugger
+	// console.log("hi")
+	debugger := &ast.DebuggerStatement{Attr: attr("DebuggerStatement")}
+	f := file(
+		debugger,
+		exprStmt(call(member(ident("console"), ident("log")), str("hi"))),
+	)
+
+	diags := &Diagnostics{}
+	code := compile(t, f, CompileOptions{Diagnostics: diags})
+	if want := `Console_Log([]Object{JSString("hi")})`; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+
+	warnings := diags.List(SeverityWarning)
+	if len(warnings) != 1 || len(diags.List()) != 1 {
+		t.Fatalf("want one warning, got %v", diags.List())
+	}
+	if want, got := "1:0: warning: debugger statement is ignored", warnings[0].String(); want != got {
+		t.Fatalf("want=%s got=%s", want, got)
+	}
+	if diags.HasErrors() {
+		t.Fatalf("want no errors, got %v", diags.List(SeverityError))
+	}
+}
+
+func TestCompile_DiagnosticsError(t *testing.T) {
+	f := file(exprStmt(sequence(ident("a"), ident("b"))))
+
+	diags := &Diagnostics{}
+	if _, err := Compile(f, CompileOptions{Diagnostics: diags}); err == nil {
+		t.Fatal("want an error compiling a sequence expression")
+	}
+	if errs := diags.List(SeverityError); len(errs) != 1 || errs[0].Msg != "sequence expression is not supported" {
+		t.Fatalf("want the compile error reported, got %v", diags.List())
+	}
+}
+
+func TestCompile_DiagnosticsTDZ(t *testing.T) {
+	// console.log(x)
+	// let x = 1
+	f := file(
+		exprStmt(call(member(ident("console"), ident("log")), ident("x"))),
+		varDecl("let", "x", num(1)),
+	)
+
+	diags := &Diagnostics{}
+	compile(t, f, CompileOptions{Diagnostics: diags})
+
+	warnings := diags.List(SeverityWarning)
+	if len(warnings) != 1 || warnings[0].Msg != "x is referenced before its declaration" {
+		t.Fatalf("want a warning about x, got %v", diags.List())
+	}
+}
@@ -0,0 +1,147 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// frozenEnum returns the Go type and the members of e when it freezes an
+// object literal of literals of a single type, numbers or strings, with
+// distinct keys which are Go identifiers, e.g.
+// `Object.freeze({RED: 0, GREEN: 1})`
+func (i *typeInferrer) frozenEnum(e ast.Expression) (string, []*ast.ObjectProperty) {
+	ce, ok := e.(*ast.CallExpression)
+	if !ok || len(ce.Arguments) != 1 || i.lookup("Object") != nil {
+		return "", nil
+	}
+	callee, ok := ce.Callee.(*ast.MemberExpression)
+	if !ok || callee.Computed {
+		return "", nil
+	}
+	if obj, ok := callee.Object.(*ast.Identifier); !ok || obj.Name != "Object" {
+		return "", nil
+	}
+	if prop, ok := callee.Property.(*ast.Identifier); !ok || prop.Name != "freeze" {
+		return "", nil
+	}
+	oe, ok := ce.Arguments[0].(*ast.ObjectExpression)
+	if !ok || len(oe.Properties) == 0 {
+		return "", nil
+	}
+
+	var goType string
+	var members []*ast.ObjectProperty
+	keys := make(map[string]bool)
+	for _, p := range oe.Properties {
+		op, ok := p.(*ast.ObjectProperty)
+		if !ok {
+			return "", nil
+		}
+		key := enumKey(op)
+		t := literalType(op.Value)
+		if key == "" || keys[key] || t == "bool" || t == "" || goType != "" && t != goType {
+			return "", nil
+		}
+
+		goType = t
+		keys[key] = true
+		members = append(members, op)
+	}
+
+	return goType, members
+}
+
+// enumKey returns the key of the member p of an enum, its name or string
+// literal when it's a Go identifier, or "" when it can't be one
+func enumKey(p *ast.ObjectProperty) string {
+	var key string
+	switch k := p.Key.(type) {
+	case *ast.Identifier:
+		if !p.Computed {
+			key = k.Name
+		}
+	case *ast.StringLiteral:
+		key = k.Value
+	}
+	if !isIdentifierName(key) || strings.Contains(key, "$") {
+		return ""
+	}
+
+	return key
+}
+
+// enumMember returns the member of the enum t named key, if any
+func enumMember(t *inferredType, key string) *ast.ObjectProperty {
+	for _, p := range t.enum {
+		if enumKey(p) == key {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// enumOf returns the type of the enum const me is a member of, if any
+func (i *typeInferrer) enumOf(me *ast.MemberExpression) *inferredType {
+	id, ok := me.Object.(*ast.Identifier)
+	if !ok {
+		return nil
+	}
+	if t := i.lookup(id.Name); t != nil && t.enum != nil {
+		return t
+	}
+
+	return nil
+}
+
+// mixEnum leaves the enum me is a member of untyped, as its members are
+// assigned or deleted
+func (i *typeInferrer) mixEnum(me *ast.MemberExpression) {
+	if t := i.enumOf(me); t != nil {
+		t.mixed = true
+	}
+}
+
+// compileEnum declares an enum const as a named Go type and the group of
+// its constants, prefixed with the name of the type, e.g.
+// `type Color_ float64` and `const (Color_RED Color_ = 0.0; ...)`. The
+// const has no runtime value, its members are read from the Go constants.
+func (c *compiler) compileEnum(kind string, vd *ast.VariableDeclarator, t *inferredType) {
+	name := vd.ID.(*ast.Identifier).Name
+	b := c.scope.declare(name, c.localName(name), kind)
+	b.enum = make(map[string]string)
+
+	prefix := strings.TrimSuffix(b.goName, "_") + "_"
+	specs := make([]string, len(t.enum))
+	for i, p := range t.enum {
+		name := prefix + enumKey(p)
+		specs[i] = fmt.Sprintf("%s %s = %s", name, b.goName, goLiteral(p.Value))
+		b.enum[enumKey(p)] = fmt.Sprintf("%s(%s)", typeConversions[t.goType], name)
+	}
+
+	decl := fmt.Sprintf("type %s %s\n\nconst (\n%s\n)", b.goName, t.goType, strings.Join(specs, "\n"))
+	if c.pkgLevel && c.scope == c.module {
+		c.code.WriteDecl(decl)
+	} else {
+		c.code.WriteLine(decl)
+	}
+}
+
+// compileEnumMember compiles the read of a member of an enum const to the
+// runtime value of its Go constant, e.g. `JSNumber(Color_RED)` for
+// `Color.RED`. It tells whether it did.
+func (c *compiler) compileEnumMember(me *ast.MemberExpression) bool {
+	id, ok := me.Object.(*ast.Identifier)
+	if !ok || me.Computed {
+		return false
+	}
+	b := c.lookup(id.Name)
+	if b == nil || b.enum == nil {
+		return false
+	}
+
+	c.code.Write(b.enum[me.Property.String()])
+	return true
+}
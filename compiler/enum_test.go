@@ -0,0 +1,64 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func freeze(props ...ast.Node) *ast.CallExpression {
+	return call(member(ident("Object"), ident("freeze")), object(props...))
+}
+
+func TestCompile_Enums(t *testing.T) {
+	// const Color = Object.freeze({RED: 0, GREEN: 1})
+	// console.log(Color.GREEN, Color["RED"])
+	f := file(
+		varDecl("const", "Color", freeze(prop(ident("RED"), num(0)), prop(ident("GREEN"), num(1)))),
+		exprStmt(call(member(ident("console"), ident("log")), member(ident("Color"), ident("GREEN")), index(ident("Color"), str("RED")))),
+	)
+
+	code := compile(t, f, CompileOptions{Enums: true})
+	for _, want := range []string{
+		`type Color_ float64
+
+const (
+Color_RED Color_ = 0.0
+Color_GREEN Color_ = 1.0
+)`,
+		`Console_Log([]Object{JSNumber(Color_GREEN), JSNumber(Color_RED)})`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_EnumsUntyped(t *testing.T) {
+	// const Dir = Object.freeze({UP: "up", DOWN: "down"})
+	// console.log(Dir)
+	// const Mixed = Object.freeze({A: 0, B: "b"})
+	// const Assigned = Object.freeze({A: 0})
+	// Assigned.A = 1
+	// const Missing = Object.freeze({A: 0})
+	// console.log(Missing.B)
+	f := file(
+		varDecl("const", "Dir", freeze(prop(ident("UP"), str("up")), prop(ident("DOWN"), str("down")))),
+		exprStmt(call(member(ident("console"), ident("log")), ident("Dir"))),
+		varDecl("const", "Mixed", freeze(prop(ident("A"), num(0)), prop(ident("B"), str("b")))),
+		varDecl("const", "Assigned", freeze(prop(ident("A"), num(0)))),
+		exprStmt(assign("=", member(ident("Assigned"), ident("A")), num(1))),
+		varDecl("const", "Missing", freeze(prop(ident("A"), num(0)))),
+		exprStmt(call(member(ident("console"), ident("log")), member(ident("Missing"), ident("B")))),
+	)
+
+	// the consts used other than by reading their members stay objects
+	code := compile(t, f, CompileOptions{Enums: true})
+	if strings.Contains(code, "type ") {
+		t.Errorf("compiled code has enum types:\n%s", code)
+	}
+	if n := strings.Count(code, "Object_Freeze("); n != 4 {
+		t.Errorf("compiled code freezes %d objects, want 4:\n%s", n, code)
+	}
+}
@@ -0,0 +1,51 @@
+package compiler
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// CompileError is an error compiling a node, located in the JavaScript
+// source.
+type CompileError struct {
+	Loc *ast.SourceLocation
+	Msg string
+}
+
+func (e *CompileError) Error() string {
+	if e.Loc == nil || e.Loc.Start == nil {
+		return e.Msg
+	}
+
+	return fmt.Sprintf("%d:%d: %s", e.Loc.Start.Line, e.Loc.Start.Column, e.Msg)
+}
+
+// errorf aborts compiling with a CompileError located at node.
+func (c *compiler) errorf(node ast.Node, format string, a ...interface{}) {
+	err := &CompileError{Msg: fmt.Sprintf(format, a...)}
+	if attr := node.GetAttr(); attr != nil {
+		err.Loc = attr.Loc
+	}
+
+	panic(err)
+}
+
+// track records the location of node, the last one being compiled, so that
+// unexpected failures compiling it are located as well.
+func (c *compiler) track(node ast.Node) {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return
+	}
+
+	if attr := node.GetAttr(); attr != nil && attr.Loc != nil {
+		c.loc = attr.Loc
+	}
+}
+
+// internalError returns the CompileError of an unexpected panic, located at
+// the last node tracked
+func (c *compiler) internalError(r interface{}) *CompileError {
+	return &CompileError{Loc: c.loc, Msg: fmt.Sprintf("internal compiler error: %v", r)}
+}
@@ -0,0 +1,290 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jingweno/godzilla/ast"
+	"github.com/jingweno/godzilla/runtime"
+)
+
+// sliceMethods are the generic helpers of the array methods compiled over
+// Go slices
+var sliceMethods = map[string]string{
+	"map":    "mapSlice",
+	"filter": "filterSlice",
+}
+
+// genericHelpers are the declarations of the generic helpers, available
+// since Go 1.18
+var genericHelpers = map[string]string{
+	"mapSlice": `func mapSlice[T, U any](s []T, fn func(T) U) []U {
+	r := make([]U, len(s))
+	for i, v := range s {
+		r[i] = fn(v)
+	}
+	return r
+}`,
+	"filterSlice": `func filterSlice[T any](s []T, fn func(T) bool) []T {
+	var r []T
+	for _, v := range s {
+		if fn(v) {
+			r = append(r, v)
+		}
+	}
+	return r
+}`,
+	"sliceArray": `func sliceArray[T any](s []T, value func(T) Object) *JSArray {
+	elements := make([]Object, len(s))
+	for i, v := range s {
+		elements[i] = value(v)
+	}
+	return NewArray(elements)
+}`,
+}
+
+// declareHelper declares the generic helper name at package level, once
+// for the files sharing the module scope, in which it's bound to a name
+// JavaScript names can't clash with.
+func (c *compiler) declareHelper(name string) {
+	key := "#" + name
+	if c.module.lookupLocal(key) != nil {
+		return
+	}
+
+	c.module.declare(key, name, "helper")
+	c.code.WriteDecl(genericHelpers[name])
+}
+
+// sliceCall returns the receiver, the method and the callback of a call of
+// an array method compiled over Go slices, when the callback is an arrow
+// function of one parameter with a concise body.
+func sliceCall(e ast.Expression) (ast.Expression, string, *ast.ArrowFunctionExpression) {
+	ce, ok := e.(*ast.CallExpression)
+	if !ok || len(ce.Arguments) != 1 {
+		return nil, "", nil
+	}
+	me, ok := ce.Callee.(*ast.MemberExpression)
+	if !ok {
+		return nil, "", nil
+	}
+	me = dotted(me)
+	prop, ok := me.Property.(*ast.Identifier)
+	if !ok || me.Computed || sliceMethods[prop.Name] == "" {
+		return nil, "", nil
+	}
+	af, ok := ce.Arguments[0].(*ast.ArrowFunctionExpression)
+	if !ok || len(af.Params) != 1 {
+		return nil, "", nil
+	}
+	if _, ok := af.Params[0].(*ast.Identifier); !ok {
+		return nil, "", nil
+	}
+	if _, ok := af.Body.(*ast.BlockStatement); ok {
+		return nil, "", nil
+	}
+
+	return me.Object, prop.Name, af
+}
+
+// sliceType returns the Go type of the elements of the array e evaluates
+// to when they're all known to be of one: the ones of an array literal of
+// literals of that type, or the ones a map or filter call over such an
+// array computes, or "".
+func (c *compiler) sliceType(e ast.Expression) string {
+	if ae, ok := e.(*ast.ArrayExpression); ok {
+		t := ""
+		for _, el := range ae.Elements {
+			lt := literalType(el)
+			if lt == "" || t != "" && lt != t {
+				return ""
+			}
+			t = lt
+		}
+		return t
+	}
+
+	recv, method, af := sliceCall(e)
+	if af == nil {
+		return ""
+	}
+	t := c.sliceType(recv)
+	if t == "" || method == "filter" {
+		return t
+	}
+
+	c.pushScope()
+	defer c.popScope()
+	param := af.Params[0].(*ast.Identifier).Name
+	c.scope.declare(param, c.mangler.Mangle(param), "param").goType = t
+
+	return c.exprType(af.Body.(ast.Expression))
+}
+
+// compileSliceCall compiles the map and filter calls over arrays of an
+// inferred element type to the generic helpers over Go slices of that type
+// when the targeted Go version has generics, e.g.
+// `sliceArray(mapSlice([]float64{1.0, 2.0}, func(x float64) float64 {...}), ...)`
+// for `[1, 2].map(x => x * 2)`. The resulting slice is turned into an
+// array. It tells whether it did.
+func (c *compiler) compileSliceCall(ce *ast.CallExpression) bool {
+	if !c.infer || !c.targets("go1.18") {
+		return false
+	}
+	t := c.sliceType(ce)
+	if t == "" {
+		return false
+	}
+
+	c.declareHelper("sliceArray")
+	c.code.Write("sliceArray(")
+	c.compileSlice(ce)
+	c.code.Write(fmt.Sprintf(", func(v %s) Object { return %s(v) })", t, typeConversions[t]))
+
+	return true
+}
+
+// compileSlice compiles e, an array of the elements sliceType infers the
+// type of, to a Go slice of that type
+func (c *compiler) compileSlice(e ast.Expression) {
+	if ae, ok := e.(*ast.ArrayExpression); ok {
+		elements := make([]string, len(ae.Elements))
+		for i, el := range ae.Elements {
+			elements[i] = goLiteral(el)
+		}
+		c.code.Write(fmt.Sprintf("[]%s{%s}", literalType(ae.Elements[0]), strings.Join(elements, ", ")))
+		return
+	}
+
+	recv, method, af := sliceCall(e)
+	t, result := c.sliceType(recv), "bool"
+	if method == "map" {
+		result = c.sliceType(e)
+	}
+
+	helper := sliceMethods[method]
+	c.declareHelper(helper)
+	c.code.Write(helper + "(")
+	c.compileSlice(recv)
+
+	c.pushScope()
+	defer c.popScope()
+	param := af.Params[0].(*ast.Identifier).Name
+	b := c.scope.declare(param, c.mangler.Mangle(param), "param")
+	b.goType = t
+	c.code.WriteLine(fmt.Sprintf(", func(%s %s) %s {", b.goName, t, result))
+	c.code.Write("return ")
+	c.compileTypedExpression(result, af.Body.(ast.Expression))
+	c.code.WriteLine("")
+	c.code.Write("})")
+}
+
+// exprType returns the Go type e evaluates to when it's computed from
+// variables of inferred types by operators Go applies to them as
+// JavaScript does, or "". Expressions of constants only are left untyped,
+// as Go computes them exactly rather than in float64.
+func (c *compiler) exprType(e ast.Expression) string {
+	switch v := e.(type) {
+	case *ast.Identifier:
+		if b := c.lookup(v.Name); b != nil && typeConversions[b.goType] != "" {
+			return b.goType
+		}
+		return ""
+	case *ast.BinaryExpression:
+		t := c.exprType(v.Left)
+		if t == "" || t != c.exprType(v.Right) || c.isConstant(v) {
+			return ""
+		}
+		switch v.Operator {
+		case "+":
+			if t != "bool" {
+				return t
+			}
+		case "-", "*", "/":
+			if t == "float64" && (v.Operator != "/" || !c.isZeroDivisor(v.Right)) {
+				return t
+			}
+		case "<", ">", "<=", ">=":
+			if t == "float64" {
+				return "bool"
+			}
+		case "===", "!==":
+			return "bool"
+		}
+		return ""
+	}
+
+	return literalType(e)
+}
+
+// isConstant tells whether e may compile to a Go constant expression: a
+// literal, a const or an operation on them
+func (c *compiler) isConstant(e ast.Expression) bool {
+	switch v := e.(type) {
+	case *ast.Identifier:
+		b := c.lookup(v.Name)
+		return b != nil && b.kind == "const"
+	case *ast.BinaryExpression:
+		return c.isConstant(v.Left) && c.isConstant(v.Right)
+	}
+
+	return literalType(e) != ""
+}
+
+// isZeroDivisor tells whether e may compile to a Go constant zero, which
+// Go doesn't compile divisions by, whereas JavaScript divides by zero
+func (c *compiler) isZeroDivisor(e ast.Expression) bool {
+	if !c.isConstant(e) {
+		return false
+	}
+	v, ok := constantValue(e)
+
+	return !ok || v == runtime.JSNumber(0)
+}
+
+// typedOperators are the Go operators of the JavaScript ones differently
+// spelled
+var typedOperators = map[ast.BinaryOperator]string{
+	"===": "==",
+	"!==": "!=",
+}
+
+// compileTypedExpression compiles e to a value of goType, with Go
+// operators on the typed values of its operands when exprType infers it's
+// of that type, e.g. `x * 2.0` for `x * 2` with x a float64 var, or else
+// converting its runtime value
+func (c *compiler) compileTypedExpression(goType string, e ast.Expression) {
+	if c.exprType(e) != goType {
+		c.compileTypedValue(goType, e)
+		return
+	}
+
+	switch v := e.(type) {
+	case *ast.Identifier:
+		c.code.Write(c.orderedRead(v.Name, goType, c.lookup(v.Name).goName))
+	case *ast.BinaryExpression:
+		op := string(v.Operator)
+		if typed, ok := typedOperators[v.Operator]; ok {
+			op = typed
+		}
+		t := c.exprType(v.Left)
+		c.compileTypedOperand(t, v.Left)
+		c.code.Write(fmt.Sprintf(" %s ", op))
+		c.compileTypedOperand(t, v.Right)
+	default:
+		c.code.Write(goLiteral(e))
+	}
+}
+
+// compileTypedOperand compiles an operand of a typed operation, in
+// parentheses when it's an operation itself
+func (c *compiler) compileTypedOperand(goType string, e ast.Expression) {
+	if _, ok := e.(*ast.BinaryExpression); ok {
+		c.code.Write("(")
+		c.compileTypedExpression(goType, e)
+		c.code.Write(")")
+		return
+	}
+
+	c.compileTypedExpression(goType, e)
+}
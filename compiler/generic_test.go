@@ -0,0 +1,79 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func arrow(param string, body ast.Expression) *ast.ArrowFunctionExpression {
+	return &ast.ArrowFunctionExpression{
+		Attr:   attr("ArrowFunctionExpression"),
+		Params: []ast.Expression{ident(param)},
+		Body:   body,
+	}
+}
+
+func TestCompile_GenericMap(t *testing.T) {
+	// let doubled = [1, 2, 3].map(x => x * 2)
+	mapped := call(member(array(num(1), num(2), num(3)), ident("map")), arrow("x", binary("*", ident("x"), num(2))))
+	f := file(varDecl("let", "doubled", mapped))
+
+	code := compile(t, f, CompileOptions{InferTypes: true, GoVersion: "1.18"})
+	for _, want := range []string{
+		"func mapSlice[T, U any](s []T, fn func(T) U) []U {",
+		"func sliceArray[T any](s []T, value func(T) Object) *JSArray {",
+		`doubled = sliceArray(mapSlice([]float64{1.0, 2.0, 3.0}, func(x float64) float64 {
+return x * 2.0
+}), func(v float64) Object { return JSNumber(v) })`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+
+	// generics aren't used without inference nor before Go 1.18
+	for _, opts := range []CompileOptions{{GoVersion: "1.18"}, {InferTypes: true, GoVersion: "1.17"}, {InferTypes: true}} {
+		if code := compile(t, f, opts); strings.Contains(code, "mapSlice") {
+			t.Fatalf("compiled code with %+v uses generics:\n%s", opts, code)
+		}
+	}
+}
+
+func TestCompile_GenericFilter(t *testing.T) {
+	// [1, 2, 3].map(x => x + 1).filter(y => y > 2).filter(z => ok(z))
+	mapped := call(member(array(num(1), num(2), num(3)), ident("map")), arrow("x", binary("+", ident("x"), num(1))))
+	filtered := call(member(mapped, ident("filter")), arrow("y", binary(">", ident("y"), num(2))))
+	f := file(exprStmt(call(member(filtered, ident("filter")), arrow("z", call(ident("ok"), ident("z"))))))
+
+	code := compile(t, f, CompileOptions{InferTypes: true, GoVersion: "1.21"})
+	if want := `sliceArray(filterSlice(filterSlice(mapSlice([]float64{1.0, 2.0, 3.0}, func(x float64) float64 {
+return x + 1.0
+}), func(y float64) bool {
+return y > 2.0
+}), func(z float64) bool {
+return Truthy(Call(global.Resolve("ok"), []Object{JSNumber(z)}))
+}), func(v float64) Object { return JSNumber(v) })`; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+	if n := strings.Count(code, "func filterSlice["); n != 1 {
+		t.Fatalf("want filterSlice declared once, got %d:\n%s", n, code)
+	}
+}
+
+func TestCompile_GenericMapUntyped(t *testing.T) {
+	// [1, "a"].map(x => x); [1, 2].map(x => f(x)); [1].map(x => 0.1 + 0.2)
+	// [1].map(x => x / 0)
+	f := file(
+		exprStmt(call(member(array(num(1), str("a")), ident("map")), arrow("x", ident("x")))),
+		exprStmt(call(member(array(num(1), num(2)), ident("map")), arrow("x", call(ident("f"), ident("x"))))),
+		exprStmt(call(member(array(num(1)), ident("map")), arrow("x", binary("+", num(0.1), num(0.2))))),
+		exprStmt(call(member(array(num(1)), ident("map")), arrow("x", binary("/", ident("x"), num(0))))),
+	)
+
+	code := compile(t, f, CompileOptions{InferTypes: true, GoVersion: "1.21"})
+	if strings.Contains(code, "mapSlice") {
+		t.Fatalf("arrays of unknown element types shouldn't use generics:\n%s", code)
+	}
+}
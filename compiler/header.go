@@ -0,0 +1,75 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"go/build/constraint"
+	"strings"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// Version is the version of godzilla written in the build info of the
+// generated files. Releases set it when linking, e.g. with
+// `-ldflags "-X github.com/jingweno/godzilla/compiler.Version=v1.0.0"`.
+var Version = "devel"
+
+// fileHeader returns the comments the file generated from f starts with: the
+// leading comments of f, e.g. a license header, the FileHeader of opts, the
+// //go:build line of its BuildTags, then the build info comment, each
+// followed by a blank line as Go requires of build constraints. The blank
+// line ahead of the package clause keeps the build info from documenting
+// the package.
+func fileHeader(opts CompileOptions, f *ast.File) (string, error) {
+	var blocks []string
+
+	if comments := f.LeadingComments; len(comments) > 0 {
+		lines := make([]string, len(comments))
+		for i, c := range comments {
+			lines[i] = c.String()
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	if h := strings.TrimSpace(opts.FileHeader); h != "" {
+		lines := strings.Split(h, "\n")
+		for i, l := range lines {
+			if l = strings.TrimSpace(l); !strings.HasPrefix(l, "//") {
+				l = strings.TrimSpace("// " + l)
+			}
+			lines[i] = l
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	var expr constraint.Expr
+	for _, tag := range opts.BuildTags {
+		x, err := constraint.Parse("//go:build " + tag)
+		if err != nil {
+			return "", fmt.Errorf("invalid build tag %q: %s", tag, err)
+		}
+
+		if expr == nil {
+			expr = x
+		} else {
+			expr = &constraint.AndExpr{X: expr, Y: x}
+		}
+	}
+	if expr != nil {
+		blocks = append(blocks, "//go:build "+expr.String())
+	}
+
+	if opts.EmitBuildInfo {
+		blocks = append(blocks, buildInfo(f))
+	}
+
+	return strings.Join(blocks, "\n\n"), nil
+}
+
+// buildInfo returns the comment stating the Version of godzilla and the
+// SHA-256 of the AST of f, printed as JavaScript so that it's the same
+// wherever the nodes are in the source, e.g.
+// "// Compiled by godzilla devel from the AST sha256:1f2e...".
+func buildInfo(f *ast.File) string {
+	return fmt.Sprintf("// Compiled by godzilla %s from the AST sha256:%x", Version, sha256.Sum256([]byte(f.String())))
+}
@@ -0,0 +1,148 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_FileHeader(t *testing.T) {
+	f := file(exprStmt(call(member(ident("console"), ident("log")), str("hi"))))
+	opts := CompileOptions{
+		FileHeader: "Code generated by godzilla. DO NOT EDIT.",
+		BuildTags:  []string{"linux", "amd64 || arm64"},
+	}
+
+	code := compile(t, f, opts)
+	want := `// Code generated by godzilla. DO NOT EDIT.
+
+//go:build linux && (amd64 || arm64)
+
+package main
+`
+	if !strings.HasPrefix(code, want) {
+		t.Fatalf("compiled code doesn't start with %q:\n%s", want, code)
+	}
+
+	// the go tool honors the constraint
+	for goos, match := range map[string]bool{"linux": true, "darwin": false} {
+		ctx := build.Default
+		ctx.GOOS, ctx.GOARCH = goos, "amd64"
+		ctx.OpenFile = func(string) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(code)), nil
+		}
+		if got, err := ctx.MatchFile(".", "main.go"); err != nil || got != match {
+			t.Errorf("%s: want match=%t got=%t (err=%v)", goos, match, got, err)
+		}
+	}
+
+	code = compile(t, f, CompileOptions{})
+	if !strings.HasPrefix(code, "package main\n") {
+		t.Fatalf("compiled code without header doesn't start with the package clause:\n%s", code)
+	}
+}
+
+func TestCompile_InvalidBuildTag(t *testing.T) {
+	_, err := Compile(file(), CompileOptions{BuildTags: []string{"linux &&"}})
+	if err == nil || !strings.Contains(err.Error(), `invalid build tag "linux &&"`) {
+		t.Fatalf("want invalid build tag error, got %v", err)
+	}
+}
+
+func TestCompile_Interpreter(t *testing.T) {
+	// #!/usr/bin/env node
+	// console.log("hi")
+	f := file(exprStmt(call(member(ident("console"), ident("log")), str("hi"))))
+	f.Program.Interpreter = "/usr/bin/env node"
+
+	// Go files can't run with an interpreter, the shebang line is dropped
+	code := compile(t, f, CompileOptions{})
+	if !strings.HasPrefix(code, "package main\n") {
+		t.Fatalf("compiled code doesn't start with the package clause:\n%s", code)
+	}
+}
+
+func TestCompile_LeadingComments(t *testing.T) {
+	// /* license */
+	// // see LICENSE
+	// console.log("hi")
+	f := file(exprStmt(call(member(ident("console"), ident("log")), str("hi"))))
+	f.LeadingComments = []*ast.Comment{
+		{Attr: attr("CommentBlock"), Value: " license "},
+		{Attr: attr("CommentLine"), Value: " see LICENSE"},
+	}
+
+	code := compile(t, f, CompileOptions{FileHeader: "Code generated by godzilla. DO NOT EDIT."})
+	want := `/* license */
+// see LICENSE
+
+// Code generated by godzilla. DO NOT EDIT.
+
+package main
+`
+	if !strings.HasPrefix(code, want) {
+		t.Fatalf("compiled code doesn't start with %q:\n%s", want, code)
+	}
+
+	out, err := CompileProgram(map[string]*ast.File{"a.js": f, "b.js": file()}, CompileOptions{})
+	if err != nil {
+		t.Fatalf("error compiling: %s", err)
+	}
+	if !strings.HasPrefix(out["a.js"], "/* license */\n") || strings.Contains(out["b.js"], "license") {
+		t.Fatalf("want the comments of a.js only in its output:\n%s\n%s", out["a.js"], out["b.js"])
+	}
+}
+
+func TestCompile_EmitBuildInfo(t *testing.T) {
+	// console.log("hi")
+	f := file(exprStmt(call(member(ident("console"), ident("log")), str("hi"))))
+	opts := CompileOptions{BuildTags: []string{"linux"}, EmitBuildInfo: true}
+
+	code := compile(t, f, opts)
+	sum := sha256.Sum256([]byte("console.log(\"hi\");\n"))
+	want := fmt.Sprintf(`//go:build linux
+
+// Compiled by godzilla %s from the AST sha256:%x
+
+package main
+`, Version, sum)
+	if !strings.HasPrefix(code, want) {
+		t.Fatalf("compiled code doesn't start with %q:\n%s", want, code)
+	}
+
+	// the build constraint is still honored and the comment doesn't
+	// document the package
+	ctx := build.Default
+	ctx.GOOS = "darwin"
+	ctx.OpenFile = func(string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(code)), nil
+	}
+	if got, err := ctx.MatchFile(".", "main.go"); err != nil || got {
+		t.Errorf("darwin: want match=false got=%t (err=%v)", got, err)
+	}
+	gf, err := parser.ParseFile(token.NewFileSet(), "main.go", code, parser.ParseComments|parser.PackageClauseOnly)
+	if err != nil {
+		t.Fatalf("error parsing compiled code: %s", err)
+	}
+	if gf.Doc != nil {
+		t.Errorf("want no package doc, got %q", gf.Doc.Text())
+	}
+
+	// the hash is of the input, wherever its nodes are
+	moved := file(exprStmt(call(member(ident("console"), ident("log")), str("hi"))))
+	moved.Program.Body[0].GetAttr().Start = 10
+	if got := compile(t, moved, opts); !strings.HasPrefix(got, want) {
+		t.Errorf("moved nodes change the build info:\n%s", got)
+	}
+	other := compile(t, file(exprStmt(call(member(ident("console"), ident("log")), str("bye")))), opts)
+	if strings.Contains(other, fmt.Sprintf("%x", sum)) {
+		t.Errorf("another input has the same hash:\n%s", other)
+	}
+}
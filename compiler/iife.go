@@ -0,0 +1,99 @@
+package compiler
+
+import (
+	"github.com/jingweno/godzilla/ast"
+)
+
+// iifeInliner replaces the immediately-invoked function expressions at the
+// top level of files, e.g. `(function() { ... })()` as emitted by Babel and
+// bundlers, by blocks of their body, so that they don't compile to a Go
+// closure called once.
+type iifeInliner struct {
+	// taken holds the top-level and free variables of the files, which the
+	// vars of an inlined function would clash with once hoisted out of it
+	taken map[string]bool
+}
+
+func newIIFEInliner(files ...*ast.File) *iifeInliner {
+	in := &iifeInliner{taken: make(map[string]bool)}
+	for _, f := range files {
+		for _, name := range ast.DeclaredVariables(f.Program) {
+			in.taken[name] = true
+		}
+		for _, name := range ast.FreeVariables(f.Program) {
+			in.taken[name] = true
+		}
+	}
+
+	return in
+}
+
+// inline returns a copy of f with its inlinable IIFEs replaced by blocks.
+// The let, const and function declarations of their body stay scoped to the
+// block, while their vars are hoisted to the top level.
+func (in *iifeInliner) inline(f *ast.File) *ast.File {
+	p := *f.Program
+	p.Body = make([]ast.Statement, len(f.Program.Body))
+	for i, s := range f.Program.Body {
+		if fe := in.inlinable(s); fe != nil {
+			for _, name := range ast.HoistedVariables(fe.Body) {
+				in.taken[name] = true
+			}
+			s = fe.Body
+		}
+
+		p.Body[i] = s
+	}
+
+	return &ast.File{Attr: f.Attr, Program: &p, LeadingComments: f.LeadingComments}
+}
+
+// inlinable returns the function s invokes when s is an IIFE without
+// parameters nor arguments whose body behaves the same as a block: it
+// doesn't return, doesn't reference arguments or its own name, and its
+// vars don't clash with other variables.
+func (in *iifeInliner) inlinable(s ast.Statement) *ast.FunctionExpression {
+	es, ok := s.(*ast.ExpressionStatement)
+	if !ok {
+		return nil
+	}
+	ce, ok := es.Expression.(*ast.CallExpression)
+	if !ok || len(ce.Arguments) != 0 {
+		return nil
+	}
+	fe, ok := ce.Callee.(*ast.FunctionExpression)
+	if !ok || len(fe.Params) != 0 || returns(fe.Body) {
+		return nil
+	}
+
+	for _, name := range ast.FreeVariables(fe.Body) {
+		if name == "arguments" || (fe.ID != nil && name == fe.ID.Name) {
+			return nil
+		}
+	}
+	for _, name := range ast.HoistedVariables(fe.Body) {
+		if in.taken[name] {
+			return nil
+		}
+	}
+
+	return fe
+}
+
+// returns tells whether node has a return statement outside of nested
+// functions
+func returns(node ast.Node) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FunctionDeclaration, *ast.FunctionExpression, *ast.ObjectMethod, *ast.ArrowFunctionExpression:
+			return false
+		case *ast.ReturnStatement:
+			found = true
+		}
+
+		return !found
+	})
+
+	return found
+}
@@ -0,0 +1,96 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_InlineIIFEs(t *testing.T) {
+	// (function() {
+	//   var count = 0
+	//   function inc() {
+	//     count++
+	//   }
+	//   inc()
+	//   console.log(count)
+	// })()
+	f := file(
+		exprStmt(iife(
+			varDecl("var", "count", num(0)),
+			funcDecl("inc", nil, exprStmt(update("++", ident("count")))),
+			exprStmt(call(ident("inc"))),
+			exprStmt(call(member(ident("console"), ident("log")), ident("count"))),
+		)),
+	)
+
+	code := compile(t, f, CompileOptions{InlineIIFEs: true})
+	if strings.Contains(code, "Call(NewFunction(") {
+		t.Fatalf("compiled code calls the IIFE:\n%s", code)
+	}
+	for _, want := range []string{"var count Object", "Call(inc, []Object{})"} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+
+	code = compile(t, f, CompileOptions{})
+	if !strings.Contains(code, "Call(NewFunction(") {
+		t.Fatalf("compiled code without inlining doesn't call the IIFE:\n%s", code)
+	}
+}
+
+func TestCompile_InlineIIFEsGuards(t *testing.T) {
+	log := member(ident("console"), ident("log"))
+	cases := []struct {
+		name string
+		body []ast.Statement
+	}{
+		{
+			// let x = 1
+			// (function() { var x = 2 })()
+			"var shadowing a top-level name",
+			[]ast.Statement{
+				varDecl("let", "x", num(1)),
+				exprStmt(iife(varDecl("var", "x", num(2)))),
+			},
+		},
+		{
+			// (function() { var x = 1 })()
+			// console.log(x)
+			"var shadowing a free variable",
+			[]ast.Statement{
+				exprStmt(iife(varDecl("var", "x", num(1)))),
+				exprStmt(call(log, ident("x"))),
+			},
+		},
+		{
+			// (function() { var x = 1 })()
+			// (function() { var x = 2 })()
+			"var of another IIFE",
+			[]ast.Statement{
+				exprStmt(iife(varDecl("var", "x", num(1)))),
+				exprStmt(iife(varDecl("var", "x", num(2)))),
+			},
+		},
+		{
+			// (function() { return; console.log(1) })()
+			"return",
+			[]ast.Statement{
+				exprStmt(iife(ret(nil), exprStmt(call(log, num(1))))),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		code := compile(t, file(tc.body...), CompileOptions{InlineIIFEs: true})
+		if !strings.Contains(code, "Call(NewFunction(") {
+			t.Errorf("%s: IIFE is inlined:\n%s", tc.name, code)
+		}
+	}
+}
+
+func iife(body ...ast.Statement) *ast.CallExpression {
+	return call(&ast.FunctionExpression{Attr: attr("FunctionExpression"), Body: block(body...)})
+}
@@ -0,0 +1,475 @@
+package compiler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// inferredType is the Go type inferred for a let or const declared from a
+// literal, or declared by its type annotation
+type inferredType struct {
+	goType string
+	// annotated tells whether the type is the one of the variable's type
+	// annotation, which assigned values are converted to
+	annotated bool
+	// mixed tells whether the variable is assigned a value of another type,
+	// or a value whose type isn't known
+	mixed bool
+	// assigned tells whether the variable is assigned after its
+	// declaration
+	assigned bool
+	// referenced tells whether the variable is referenced other than by
+	// reading the members of its enum
+	referenced bool
+	// enum holds the members of a const initialized with a frozen object of
+	// literals of goType, which only ever has its members read
+	enum []*ast.ObjectProperty
+}
+
+// typeConversions are the runtime types values of inferred types convert to
+var typeConversions = map[string]string{
+	"float64": "JSNumber",
+	"string":  "JSString",
+	"bool":    "JSBoolean",
+}
+
+// literalType returns the Go type of e when it's a literal of one. Infinite
+// numbers aren't Go constants and are left untyped.
+func literalType(e ast.Expression) string {
+	switch v := e.(type) {
+	case *ast.NumericLiteral:
+		if math.IsInf(v.Value, 0) {
+			return ""
+		}
+		return "float64"
+	case *ast.StringLiteral:
+		return "string"
+	case *ast.BooleanLiteral:
+		return "bool"
+	}
+
+	return ""
+}
+
+// annotatedType returns the Go type of the type annotation of id, or "" when
+// it isn't annotated with a type values can be converted to
+func annotatedType(id *ast.Identifier) string {
+	switch t := id.TypeAnnotation.(type) {
+	case *ast.TSKeywordType:
+		switch t.Keyword {
+		case "number":
+			return "float64"
+		case "string":
+			return "string"
+		case "boolean":
+			return "bool"
+		}
+	case *ast.TSArrayType:
+		return "*JSArray"
+	case *ast.TSTypeReference:
+		if (t.TypeName.Name == "Array" || t.TypeName.Name == "ReadonlyArray") && len(t.TypeParameters) == 1 {
+			return "*JSArray"
+		}
+	}
+
+	return ""
+}
+
+// valueConversions convert runtime values to the Go types of annotations
+var valueConversions = map[string]string{
+	"float64":  "float64(ToNumber(%s))",
+	"string":   "string(ToString(%s))",
+	"bool":     "Truthy(%s)",
+	"*JSArray": "ToArray(%s)",
+}
+
+// convertValue returns the Go expression converting the runtime value of
+// the Go expression value to goType
+func convertValue(goType, value string) string {
+	if conv, ok := valueConversions[goType]; ok {
+		return fmt.Sprintf(conv, value)
+	}
+
+	return value
+}
+
+// goLiteral returns the Go literal of e, a literal of an inferred type
+func goLiteral(e ast.Expression) string {
+	switch v := e.(type) {
+	case *ast.NumericLiteral:
+		s := strconv.FormatFloat(v.Value, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s
+	case *ast.StringLiteral:
+		return strconv.Quote(v.Value)
+	case *ast.BooleanLiteral:
+		return strconv.FormatBool(v.Value)
+	}
+
+	panic("not a literal of an inferred type " + e.String())
+}
+
+// isTypedAssignment tells whether the assignment op right keeps a variable
+// of goType of that type
+func isTypedAssignment(goType string, op ast.AssignmentOperator, right ast.Expression) bool {
+	switch {
+	case op == "=":
+		return literalType(right) == goType
+	case goType == "string":
+		return op == "+="
+	case goType == "float64":
+		return (op == "+=" || op == "-=" || op == "*=") && literalType(right) == goType
+	}
+
+	return false
+}
+
+// inferTypes infers the Go types of the initialized let and const
+// declarations of p. With literals, the ones initialized from literals and
+// only ever assigned values of the same type are typed, with annotations,
+// the ones annotated with types, and with enums, the consts of frozen
+// objects of literals. Variables assigned before their declaration are left
+// untyped.
+func inferTypes(p *ast.Program, literals, annotations, enums bool) map[*ast.VariableDeclarator]*inferredType {
+	i := &typeInferrer{
+		literals:    literals,
+		annotations: annotations,
+		enums:       enums,
+		types:       make(map[*ast.VariableDeclarator]*inferredType),
+	}
+	ast.Walk(i, p)
+
+	types := make(map[*ast.VariableDeclarator]*inferredType)
+	for d, t := range i.types {
+		if !t.mixed {
+			types[d] = t
+		}
+	}
+
+	return types
+}
+
+type typeScope struct {
+	parent *typeScope
+	names  map[string]*inferredType
+}
+
+type typeInferrer struct {
+	literals    bool
+	annotations bool
+	enums       bool
+	scope       *typeScope
+	types       map[*ast.VariableDeclarator]*inferredType
+}
+
+func (i *typeInferrer) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.Program:
+		i.walkScope(n, func() { i.walkStatements(n.Body) })
+		return nil
+	case *ast.FunctionDeclaration:
+		i.walkScope(n, func() {
+			i.walkParams(n.Params)
+			i.walkStatements(n.Body.Body)
+		})
+		return nil
+	case *ast.FunctionExpression:
+		i.walkScope(n, func() {
+			i.walkParams(n.Params)
+			i.walkStatements(n.Body.Body)
+		})
+		return nil
+	case *ast.ObjectMethod:
+		i.walkScope(n, func() {
+			i.walkParams(n.Params)
+			i.walkStatements(n.Body.Body)
+		})
+		return nil
+	case *ast.ArrowFunctionExpression:
+		i.walkScope(n, func() {
+			i.walkParams(n.Params)
+			if bs, ok := n.Body.(*ast.BlockStatement); ok {
+				i.walkStatements(bs.Body)
+			} else {
+				ast.Walk(i, n.Body)
+			}
+		})
+		return nil
+	case *ast.BlockStatement:
+		i.walkScope(n, func() { i.walkStatements(n.Body) })
+		return nil
+	case *ast.SwitchStatement:
+		ast.Walk(i, n.Discriminant)
+		i.walkScope(n, func() {
+			for _, c := range n.Cases {
+				ast.Walk(i, c)
+			}
+		})
+		return nil
+	case *ast.ForStatement:
+		i.walkScope(n, func() {
+			for _, c := range []ast.Node{n.Init, n.Test, n.Update} {
+				if c != nil {
+					ast.Walk(i, c)
+				}
+			}
+			ast.Walk(i, n.Body)
+		})
+		return nil
+	case *ast.ForOfStatement:
+		ast.Walk(i, n.Right)
+		if target, ok := n.Left.(ast.Expression); ok {
+			// the values iterated over are of unknown types
+			i.mixTargets(target)
+		}
+		i.walkScope(n, func() { ast.Walk(i, n.Body) })
+		return nil
+	case *ast.VariableDeclaration:
+		for _, d := range n.Declarations {
+			if d.Init == nil {
+				continue
+			}
+
+			ast.Walk(i, d.Init)
+			id, ok := d.ID.(*ast.Identifier)
+			if !ok {
+				// the destructured values are of unknown types, the
+				// defaults of the pattern are walked for their references
+				ast.Walk(i, d.ID)
+				i.mixTargets(d.ID)
+				continue
+			}
+			if t := i.lookup(id.Name); t != nil && isLexical(n.Kind) && t.goType == "" && !t.mixed {
+				if i.annotations {
+					t.goType = annotatedType(id)
+					t.annotated = t.goType != ""
+				}
+				if i.literals && t.goType == "" {
+					t.goType = literalType(d.Init)
+				}
+				if i.enums && t.goType == "" && n.Kind == "const" && !t.referenced {
+					t.goType, t.enum = i.frozenEnum(d.Init)
+				}
+				if t.goType != "" {
+					i.types[d] = t
+				}
+			}
+		}
+		return nil
+	case *ast.AssignmentExpression:
+		switch v := n.Left.(type) {
+		case *ast.Identifier:
+			if t := i.lookup(v.Name); t != nil {
+				t.assigned = true
+				if !t.annotated && !isTypedAssignment(t.goType, n.Operator, n.Right) {
+					t.mixed = true
+				}
+			}
+		case *ast.ArrayPattern, *ast.ObjectPattern:
+			// destructured values are of unknown types
+			i.mixTargets(v)
+		case *ast.MemberExpression:
+			i.mixEnum(v)
+		}
+	case *ast.UpdateExpression:
+		if id, ok := n.Argument.(*ast.Identifier); ok {
+			if t := i.lookup(id.Name); t != nil {
+				t.assigned = true
+				if !t.annotated && t.goType != "float64" {
+					t.mixed = true
+				}
+			}
+		}
+		if me, ok := n.Argument.(*ast.MemberExpression); ok {
+			i.mixEnum(me)
+		}
+	case *ast.UnaryExpression:
+		if me, ok := n.Argument.(*ast.MemberExpression); ok && n.Operator == "delete" {
+			i.mixEnum(me)
+		}
+	case *ast.MemberExpression:
+		me := dotted(n)
+		if me.Computed {
+			break
+		}
+		if t := i.enumOf(me); t != nil && enumMember(t, me.Property.String()) != nil {
+			return nil
+		}
+		// the name of the property isn't a reference
+		ast.Walk(i, me.Object)
+		return nil
+	case *ast.Identifier:
+		// enums are only ever used to read their members, after their
+		// declaration
+		if t := i.lookup(n.Name); t != nil {
+			t.referenced = true
+			if t.enum != nil {
+				t.mixed = true
+			}
+		}
+	}
+
+	return i
+}
+
+// mixTargets marks the unannotated variables assigned by target, an
+// identifier or a pattern, as assigned values of unknown types
+func (i *typeInferrer) mixTargets(target ast.Expression) {
+	ast.Inspect(target, func(node ast.Node) bool {
+		if id, ok := node.(*ast.Identifier); ok {
+			if t := i.lookup(id.Name); t != nil && !t.annotated {
+				t.mixed = true
+			}
+		}
+		return true
+	})
+}
+
+// walkParams walks the destructuring patterns of params for the references
+// their defaults make
+func (i *typeInferrer) walkParams(params []ast.Expression) {
+	for _, p := range params {
+		if _, ok := p.(*ast.Identifier); !ok {
+			ast.Walk(i, p)
+		}
+	}
+}
+
+// walkScope walks the children of node with walk, in the scope node creates
+func (i *typeInferrer) walkScope(node ast.Node, walk func()) {
+	s := &typeScope{parent: i.scope, names: make(map[string]*inferredType)}
+	for _, name := range ast.DeclaredVariables(node) {
+		s.names[name] = &inferredType{}
+	}
+
+	i.scope = s
+	walk()
+	i.scope = s.parent
+}
+
+func (i *typeInferrer) walkStatements(body []ast.Statement) {
+	for _, s := range body {
+		ast.Walk(i, s)
+	}
+}
+
+func (i *typeInferrer) lookup(name string) *inferredType {
+	for s := i.scope; s != nil; s = s.parent {
+		if t, ok := s.names[name]; ok {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// value returns the Go expression of the runtime value of b
+func (b *binding) value() string {
+	if conv, ok := typeConversions[b.goType]; ok {
+		return fmt.Sprintf("%s(%s)", conv, b.goName)
+	}
+
+	return b.goName
+}
+
+// compileTypedDeclarator declares a var of an inferred type, initialized
+// from its literal or its converted value
+func (c *compiler) compileTypedDeclarator(kind string, vd *ast.VariableDeclarator, t *inferredType) {
+	name := vd.ID.(*ast.Identifier).Name
+	b := c.scope.declare(name, c.localName(name), kind)
+	b.goType = t.goType
+	c.code.Write(b.goName + " := ")
+	c.compileTypedValue(b.goType, vd.Init)
+	c.code.WriteLine("")
+	c.code.WriteLine(fmt.Sprintf("_ = %s", b.goName))
+	c.defineGlobal(name, b)
+}
+
+// typedConsts returns the leading declarators of a declaration of kind
+// which can be Go constants: consts initialized from literals of their
+// inferred type which are never assigned
+func (c *compiler) typedConsts(kind string, vds []*ast.VariableDeclarator) []*ast.VariableDeclarator {
+	if kind != "const" {
+		return nil
+	}
+
+	n := 0
+	for _, vd := range vds {
+		if t := c.types[vd]; t == nil || t.assigned || literalType(vd.Init) != t.goType || c.scope.lookupLocal(vd.ID.(*ast.Identifier).Name) != nil {
+			break
+		}
+		n++
+	}
+
+	return vds[:n]
+}
+
+// compileTypedConsts declares consts of inferred types as Go constants,
+// grouped in a single const declaration, e.g. `const (a = 1.0; b = 2.0)`
+// for `const a = 1, b = 2`
+func (c *compiler) compileTypedConsts(vds []*ast.VariableDeclarator) {
+	var specs []string
+	var bindings []*binding
+	for _, vd := range vds {
+		name := vd.ID.(*ast.Identifier).Name
+		b := c.scope.declare(name, c.localName(name), "const")
+		b.goType = c.types[vd].goType
+		specs = append(specs, fmt.Sprintf("%s = %s", b.goName, goLiteral(vd.Init)))
+		bindings = append(bindings, b)
+	}
+
+	decl := "const " + specs[0]
+	if len(specs) > 1 {
+		decl = "const (\n" + strings.Join(specs, "\n") + "\n)"
+	}
+	if c.pkgLevel && c.scope == c.module {
+		c.code.WriteDecl(decl)
+	} else {
+		c.code.WriteLine(decl)
+	}
+
+	for i, vd := range vds {
+		c.defineGlobal(vd.ID.(*ast.Identifier).Name, bindings[i])
+	}
+}
+
+// compileTypedValue compiles e to a value of goType: its Go literal when
+// it's a literal of that type, or its converted runtime value
+func (c *compiler) compileTypedValue(goType string, e ast.Expression) {
+	if literalType(e) == goType {
+		c.code.Write(goLiteral(e))
+		return
+	}
+
+	value := c.code.Capture(func() { c.compileExpression(e) })
+	c.code.Write(convertValue(goType, value))
+}
+
+// compileTypedAssignment compiles an assignment to a variable of an inferred
+// type, which keeps its type
+func (c *compiler) compileTypedAssignment(b *binding, ae *ast.AssignmentExpression) {
+	switch {
+	case ae.Operator == "=":
+		c.code.Write(b.goName + " = ")
+		c.compileTypedValue(b.goType, ae.Right)
+	case b.goType == "string" && ae.Operator == "+=":
+		c.code.Write(fmt.Sprintf("%s += string(ToString(", b.goName))
+		c.compileExpression(ae.Right)
+		c.code.Write("))")
+	case b.goType == "float64" && isTypedAssignment(b.goType, ae.Operator, ae.Right):
+		c.code.Write(fmt.Sprintf("%s %s %s", b.goName, ae.Operator, goLiteral(ae.Right)))
+	default:
+		// other assignments to annotated vars compute a runtime value
+		op := binaryOperators[ast.BinaryOperator(strings.TrimSuffix(string(ae.Operator), "="))]
+		value := c.code.Capture(func() {
+			c.compileAssignedValue(op, func() { c.code.Write(b.value()) }, ae.Right)
+		})
+		c.code.Write(fmt.Sprintf("%s = %s", b.goName, convertValue(b.goType, value)))
+	}
+}
@@ -0,0 +1,171 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_InferTypes(t *testing.T) {
+	// let n = 1
+	// n++
+	// let s = "a"
+	// s += n
+	// const ok = true
+	// console.log(n, s, ok)
+	f := file(
+		varDecl("let", "n", num(1)),
+		exprStmt(update("++", ident("n"))),
+		varDecl("let", "s", str("a")),
+		exprStmt(assign("+=", ident("s"), ident("n"))),
+		varDecl("const", "ok", &ast.BooleanLiteral{Attr: attr("BooleanLiteral"), Value: true}),
+		exprStmt(call(member(ident("console"), ident("log")), ident("n"), ident("s"), ident("ok"))),
+	)
+
+	code := compile(t, f, CompileOptions{InferTypes: true})
+	for _, want := range []string{
+		"n := 1.0",
+		"n = n + 1",
+		`s := "a"`,
+		"s += string(ToString(JSNumber(n)))",
+		"const ok = true",
+		"Console_Log([]Object{JSNumber(n), JSString(s), JSBoolean(ok)})",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+
+	code = compile(t, f, CompileOptions{})
+	if want := "var n Object"; !strings.Contains(code, want) {
+		t.Fatalf("compiled code without inference doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_InferTypesConsts(t *testing.T) {
+	// const a = 1, b = "b"
+	// const c = 3, d = f(), e = true
+	// const g = 1
+	// g = 2
+	f := file(
+		&ast.VariableDeclaration{Attr: attr("VariableDeclaration"), Kind: "const", Declarations: []*ast.VariableDeclarator{
+			declarator("a", num(1)), declarator("b", str("b")),
+		}},
+		&ast.VariableDeclaration{Attr: attr("VariableDeclaration"), Kind: "const", Declarations: []*ast.VariableDeclarator{
+			declarator("c", num(3)), declarator("d", call(ident("f"))), declarator("e", &ast.BooleanLiteral{Attr: attr("BooleanLiteral"), Value: true}),
+		}},
+		varDecl("const", "g", num(1)),
+		exprStmt(assign("=", ident("g"), num(2))),
+	)
+
+	code := compile(t, f, CompileOptions{InferTypes: true})
+	for _, want := range []string{
+		"const (\na = 1.0\nb = \"b\"\n)",
+		// initializers which aren't literals aren't constants
+		"const c = 3.0\nglobal.DefineProperty(\"c\", JSNumber(c))\nvar d Object",
+		"const e = true",
+		// an assigned const can't be a Go constant
+		"g := 1.0",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_InferTypesPolymorphic(t *testing.T) {
+	// let x = 1
+	// x = "one"
+	// let y = 1
+	// {
+	//   let y = "shadowed"
+	// }
+	f := file(
+		varDecl("let", "x", num(1)),
+		exprStmt(assign("=", ident("x"), str("one"))),
+		varDecl("let", "y", num(1)),
+		block(varDecl("let", "y", str("shadowed"))),
+	)
+
+	code := compile(t, f, CompileOptions{InferTypes: true})
+	for _, want := range []string{
+		"var x Object",
+		`x = JSString("one")`,
+		"y := 1.0",
+		`y := "shadowed"`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_TypeAnnotations(t *testing.T) {
+	// let x: number = f()
+	// x = g()
+	// x += 1
+	// const names: string[] = []
+	// console.log(x, names)
+	x := varDecl("let", "x", call(ident("f")))
+	x.Declarations[0].ID.(*ast.Identifier).TypeAnnotation = keywordType("number")
+	names := varDecl("const", "names", array())
+	names.Declarations[0].ID.(*ast.Identifier).TypeAnnotation = &ast.TSArrayType{Attr: attr("TSArrayType"), ElementType: keywordType("string")}
+	f := file(
+		x,
+		exprStmt(assign("=", ident("x"), call(ident("g")))),
+		exprStmt(assign("+=", ident("x"), num(1))),
+		names,
+		exprStmt(call(member(ident("console"), ident("log")), ident("x"), ident("names"))),
+	)
+
+	code := compile(t, f, CompileOptions{TypeAnnotations: true})
+	for _, want := range []string{
+		`x := float64(ToNumber(Call(global.Resolve("f"), []Object{})))`,
+		`x = float64(ToNumber(Call(global.Resolve("g"), []Object{})))`,
+		"x += 1.0",
+		"names := ToArray(NewArray([]Object{}))",
+		"Console_Log([]Object{JSNumber(x), names})",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+
+	code = compile(t, f, CompileOptions{})
+	if want := "var x Object"; !strings.Contains(code, want) {
+		t.Fatalf("compiled code without annotations doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_TypeAnnotationsParams(t *testing.T) {
+	// function greet(name: string, times: number, loud: any) {
+	//   return name + times + loud
+	// }
+	fd := funcDecl("greet", []string{"name", "times", "loud"},
+		ret(binary("+", binary("+", ident("name"), ident("times")), ident("loud"))),
+	)
+	fd.Params[0].(*ast.Identifier).TypeAnnotation = keywordType("string")
+	fd.Params[1].(*ast.Identifier).TypeAnnotation = keywordType("number")
+	fd.Params[2].(*ast.Identifier).TypeAnnotation = keywordType("any")
+
+	code := compile(t, file(fd), CompileOptions{TypeAnnotations: true})
+	for _, want := range []string{
+		"name := string(ToString(Arg(args, 0)))",
+		"times := float64(ToNumber(Arg(args, 1)))",
+		"loud := Arg(args, 2)",
+		"return Add(Add(JSString(name), JSNumber(times)), loud)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func keywordType(keyword string) *ast.TSKeywordType {
+	return &ast.TSKeywordType{Attr: attr("TS" + strings.ToUpper(keyword[:1]) + keyword[1:] + "Keyword"), Keyword: keyword}
+}
+
+func declarator(name string, init ast.Expression) *ast.VariableDeclarator {
+	return &ast.VariableDeclarator{Attr: attr("VariableDeclarator"), ID: ident(name), Init: init}
+}
@@ -0,0 +1,106 @@
+package compiler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// jsdocGoTypes are the Go types of the JSDoc types values can be converted
+// to
+var jsdocGoTypes = map[string]string{
+	"number":  "float64",
+	"string":  "string",
+	"boolean": "bool",
+}
+
+var (
+	// jsdocParam matches the type and the name of a `@param {number} x` tag.
+	// Optional parameters, e.g. `[x]`, may be undefined and aren't matched.
+	jsdocParam   = regexp.MustCompile(`@param\s+\{([^}]*)\}\s+([A-Za-z_$][\w$]*)`)
+	jsdocReturns = regexp.MustCompile(`@returns?\s+\{([^}]*)\}`)
+)
+
+// jsdoc is the types the JSDoc block of a function documents
+type jsdoc struct {
+	// params maps the names of the parameters to their Go types
+	params  map[string]string
+	returns string
+}
+
+// parseJSDoc returns the types of the JSDoc block, a block comment starting
+// with `/**`, closest to the node comments lead, or nil when there's none.
+// The types other than the ones of jsdocGoTypes, e.g. `?number` or
+// `Object`, are left out.
+func parseJSDoc(comments []*ast.Comment) *jsdoc {
+	for i := len(comments) - 1; i >= 0; i-- {
+		c := comments[i]
+		if c.Type != "CommentBlock" || !strings.HasPrefix(c.Value, "*") {
+			continue
+		}
+
+		doc := &jsdoc{params: make(map[string]string)}
+		for _, m := range jsdocParam.FindAllStringSubmatch(c.Value, -1) {
+			if t := jsdocGoTypes[strings.TrimSpace(m[1])]; t != "" {
+				doc.params[m[2]] = t
+			}
+		}
+		if m := jsdocReturns.FindStringSubmatch(c.Value); m != nil {
+			doc.returns = jsdocGoTypes[strings.TrimSpace(m[1])]
+		}
+		return doc
+	}
+
+	return nil
+}
+
+// jsdocTypes returns the Go types of the parameters and of the values
+// returned by the function declarations of p documented with JSDoc, keyed
+// by the parameters and by the bodies of the functions. The JSDoc block of
+// an exported function leads its export declaration.
+func jsdocTypes(p *ast.Program) (map[*ast.Identifier]string, map[*ast.BlockStatement]string) {
+	params := make(map[*ast.Identifier]string)
+	returns := make(map[*ast.BlockStatement]string)
+	ast.Inspect(p, func(n ast.Node) bool {
+		var fd *ast.FunctionDeclaration
+		switch v := n.(type) {
+		case *ast.FunctionDeclaration:
+			fd = v
+		case *ast.ExportNamedDeclaration:
+			fd, _ = v.Declaration.(*ast.FunctionDeclaration)
+		case *ast.ExportDefaultDeclaration:
+			fd, _ = v.Declaration.(*ast.FunctionDeclaration)
+		}
+		if fd == nil || n.GetAttr() == nil {
+			return n != nil
+		}
+
+		doc := parseJSDoc(n.GetAttr().LeadingComments)
+		if doc == nil {
+			return true
+		}
+		for _, param := range fd.Params {
+			// destructured parameters aren't typed
+			if id, ok := param.(*ast.Identifier); ok && doc.params[id.Name] != "" {
+				params[id] = doc.params[id.Name]
+			}
+		}
+		if doc.returns != "" {
+			returns[fd.Body] = doc.returns
+		}
+		return true
+	})
+
+	return params, returns
+}
+
+// compileTypedReturn compiles the return of the value of e converted to
+// goType, the type of the values the function returns, e.g.
+// `return JSString(string(ToString(x)))` for `return x` of a function
+// documented to return a string
+func (c *compiler) compileTypedReturn(goType string, e ast.Expression) {
+	value := c.code.Capture(func() { c.compileTypedExpression(goType, e) })
+	c.code.Write(fmt.Sprintf("return %s(%s)", typeConversions[goType], value))
+}
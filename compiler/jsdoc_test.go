@@ -0,0 +1,67 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_JSDocTypes(t *testing.T) {
+	// /**
+	//  * @param {number} x
+	//  * @param {Object} y
+	//  * @param {string} [z]
+	//  * @returns {string}
+	//  */
+	// function f(x, y, z) {
+	//   const g = v => x
+	//   return x + 1
+	// }
+	fd := funcDecl("f", []string{"x", "y", "z"},
+		varDecl("const", "g", arrow("v", ident("x"))),
+		ret(binary("+", ident("x"), num(1))),
+	)
+	fd.LeadingComments = []*ast.Comment{
+		jsdocComment("*\n * @param {number} x\n * @param {Object} y\n * @param {string} [z]\n * @returns {string}\n "),
+	}
+	f := file(fd)
+
+	code := compile(t, f, CompileOptions{JSDocTypes: true})
+	for _, want := range []string{
+		"x := float64(ToNumber(Arg(args, 0)))",
+		"y := Arg(args, 1)",
+		"z := Arg(args, 2)",
+		"return JSNumber(x)",
+		"return JSString(string(ToString(Add(JSNumber(x), JSNumber(1)))))",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+
+	code = compile(t, f, CompileOptions{})
+	if want := "x := Arg(args, 0)"; !strings.Contains(code, want) {
+		t.Fatalf("compiled code without JSDoc types doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_JSDocTypesExported(t *testing.T) {
+	// // @param {number} x
+	// /** @param {boolean} x */
+	// export function f(x) {}
+	e := exportNamed(funcDecl("f", []string{"x"}))
+	e.LeadingComments = []*ast.Comment{
+		{Attr: attr("CommentLine"), Value: " @param {number} x"},
+		jsdocComment("* @param {boolean} x "),
+	}
+
+	code := compile(t, file(e), CompileOptions{Package: "mod", JSDocTypes: true})
+	if want := "x := Truthy(Arg(args, 0))"; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func jsdocComment(value string) *ast.Comment {
+	return &ast.Comment{Attr: attr("CommentBlock"), Value: value}
+}
@@ -0,0 +1,160 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// labels are the Go labels of the function being compiled. Go labels are
+// visible in the whole function and must be distinct, whereas a JavaScript
+// label is only visible in the statement it labels and may be reused by
+// the statements after it.
+type labels struct {
+	// used are the Go names of the labels of the function
+	used map[string]bool
+	// active are the labels of the statements being compiled
+	active map[string]*label
+	// loop is the label of the loop about to be compiled, if any
+	loop *label
+}
+
+type label struct {
+	goName string
+	// loop tells whether the label is the label of a loop, which Go can
+	// break and continue. Other statements are broken out of with a goto.
+	loop bool
+	// referenced tells whether a break or continue jumps to the label, as
+	// Go rejects unused labels
+	referenced bool
+}
+
+func newLabels() *labels {
+	return &labels{used: make(map[string]bool), active: make(map[string]*label)}
+}
+
+// newLabel returns a label named after name which is distinct from the
+// other labels of the function, synthesized ones included.
+func (ls *labels) newLabel(name string) *label {
+	goName := name
+	for i := 1; ls.used[goName]; i++ {
+		goName = fmt.Sprintf("%s%d", name, i)
+	}
+	ls.used[goName] = true
+
+	return &label{goName: goName}
+}
+
+func (c *compiler) compileLabeledStatement(ls *ast.LabeledStatement) {
+	if c.labels == nil {
+		c.labels = newLabels()
+	}
+
+	name := ls.Label.Name
+	if c.labels.active[name] != nil {
+		c.errorf(ls.Label, "label %q has already been declared", name)
+	}
+
+	// a loop with several labels, as in `a: b: for (...)`, has a single Go
+	// label
+	l := c.labels.loop
+	if l == nil {
+		l = c.labels.newLabel(c.mangler.Mangle(name))
+	}
+	switch labeledStatement(ls).(type) {
+	case *ast.ForStatement, *ast.ForOfStatement, *ast.DoWhileStatement:
+		l.loop = true
+		c.labels.loop = l
+	}
+
+	c.labels.active[name] = l
+	defer delete(c.labels.active, name)
+
+	if l.loop {
+		c.compileStatement(ls.Body)
+		return
+	}
+
+	// the goto jumping to the end of the statement mustn't jump over var
+	// declarations, so the statement is compiled in a block
+	if _, ok := ls.Body.(*ast.BlockStatement); ok {
+		c.compileStatement(ls.Body)
+	} else {
+		c.code.WriteLine("{")
+		c.compileStatement(ls.Body)
+		c.code.WriteLine("")
+		c.code.Write("}")
+	}
+	if l.referenced {
+		c.code.Write(fmt.Sprintf("\n%s:", l.goName))
+	}
+}
+
+// labeledStatement returns the statement ls labels, which may have other
+// labels
+func labeledStatement(ls *ast.LabeledStatement) ast.Statement {
+	for {
+		inner, ok := ls.Body.(*ast.LabeledStatement)
+		if !ok {
+			return ls.Body
+		}
+		ls = inner
+	}
+}
+
+// loopLabel writes the label of the loop about to be compiled, once
+// compiling it tells whether it's referenced.
+func (c *compiler) loopLabel(compileLoop func()) {
+	var l *label
+	if c.labels != nil {
+		l, c.labels.loop = c.labels.loop, nil
+	}
+
+	loop := c.code.Capture(compileLoop)
+	if l != nil && l.referenced {
+		c.code.WriteLine(l.goName + ":")
+	}
+	c.code.Write(loop)
+}
+
+func (c *compiler) compileBreakStatement(bs *ast.BreakStatement) {
+	if bs.Label == nil {
+		c.code.Write("break")
+		return
+	}
+
+	l := c.jumpLabel(bs.Label)
+	if l.loop {
+		c.code.Write("break " + l.goName)
+	} else {
+		c.code.Write("goto " + l.goName)
+	}
+}
+
+func (c *compiler) compileContinueStatement(cs *ast.ContinueStatement) {
+	c.compileLoopCopies(cs)
+	if cs.Label == nil {
+		c.code.Write("continue")
+		return
+	}
+
+	l := c.jumpLabel(cs.Label)
+	if !l.loop {
+		c.errorf(cs.Label, "continue to %s, which doesn't label a loop", cs.Label.Name)
+	}
+	c.code.Write("continue " + l.goName)
+}
+
+// jumpLabel returns the label a break or continue jumps to
+func (c *compiler) jumpLabel(id *ast.Identifier) *label {
+	var l *label
+	if c.labels != nil {
+		l = c.labels.active[id.Name]
+	}
+	if l == nil {
+		c.errorf(id, "undefined label %q", id.Name)
+	}
+	l.referenced = true
+
+	return l
+}
@@ -0,0 +1,98 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_LabeledBreak(t *testing.T) {
+	// a$: for (let i = 0; i < 3; i++) {
+	//   a_dollar_: for (let j = 0; j < 3; j++) {
+	//     continue a$;
+	//     break a_dollar_;
+	//   }
+	// }
+	// for: { break for; }
+	// for: { break for; }
+	//
+	// both loop labels mangle to a_dollar_ and the block labels are reused
+	f := file(
+		labeled("a$", forLoop("i", 3, block(
+			labeled("a_dollar_", forLoop("j", 3, block(
+				&ast.ContinueStatement{Attr: attr("ContinueStatement"), Label: ident("a$")},
+				brk("a_dollar_"),
+			))),
+		))),
+		labeled("for", block(brk("for"))),
+		labeled("for", block(brk("for"))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		"a_dollar_:\nfor ;",
+		"a_dollar_1:\nfor ;",
+		"continue a_dollar_\n",
+		"break a_dollar_1\n",
+		"goto for_\n",
+		"}\nfor_:",
+		"goto for_1\n",
+		"}\nfor_1:",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_UnusedLabel(t *testing.T) {
+	// a: for (let i = 0; i < 3; i++) {}
+	code := compile(t, file(labeled("a", forLoop("i", 3, block()))), CompileOptions{})
+	if strings.Contains(code, "\na:\n") {
+		t.Fatalf("Go rejects unused labels:\n%s", code)
+	}
+}
+
+func TestCompile_LabelErrors(t *testing.T) {
+	tests := []struct {
+		f    *ast.File
+		want string
+	}{
+		// a: { break b; }
+		{file(labeled("a", block(brk("b")))), `undefined label "b"`},
+		// a: { a: { break a; } }
+		{file(labeled("a", block(labeled("a", block(brk("a")))))), `label "a" has already been declared`},
+		// a: { continue a; }
+		{
+			file(labeled("a", block(&ast.ContinueStatement{Attr: attr("ContinueStatement"), Label: ident("a")}))),
+			"continue to a, which doesn't label a loop",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Compile(test.f, CompileOptions{})
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("want an error containing %q, got %v", test.want, err)
+		}
+	}
+}
+
+// forLoop returns `for (let name = 0; name < n; name++) body`
+func forLoop(name string, n float64, body ast.Statement) *ast.ForStatement {
+	return &ast.ForStatement{
+		Attr:   attr("ForStatement"),
+		Init:   varDecl("let", name, num(0)),
+		Test:   binary("<", ident(name), num(n)),
+		Update: update("++", ident(name)),
+		Body:   body,
+	}
+}
+
+func labeled(name string, body ast.Statement) *ast.LabeledStatement {
+	return &ast.LabeledStatement{Attr: attr("LabeledStatement"), Label: ident(name), Body: body}
+}
+
+func brk(label string) *ast.BreakStatement {
+	return &ast.BreakStatement{Attr: attr("BreakStatement"), Label: ident(label)}
+}
@@ -0,0 +1,117 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// loopCopy is the per-iteration copy of a let or const loop variable,
+// which the closures of the loop body capture. JavaScript binds these
+// variables afresh on each iteration, whereas Go shares the variables
+// declared ahead of a loop between its iterations.
+type loopCopy struct {
+	name string
+	// loopVar is the binding of the loop variable and iterVar the one of
+	// its copy
+	loopVar, iterVar *binding
+}
+
+// copyBack returns the statement assigning the copy, which the body
+// assigns, back to the loop variable for the update of the next iteration
+func (lc *loopCopy) copyBack() string {
+	return fmt.Sprintf("%s = %s", lc.loopVar.goName, lc.iterVar.goName)
+}
+
+// isCaptured tells whether a function or a class of body references name
+func isCaptured(body ast.Node, name string) bool {
+	captured := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FunctionDeclaration, *ast.FunctionExpression, *ast.ArrowFunctionExpression, *ast.ObjectMethod, *ast.ClassDeclaration:
+			for _, free := range ast.FreeVariables(n) {
+				captured = captured || free == name
+			}
+			return false
+		}
+		return !captured
+	})
+
+	return captured
+}
+
+// isAssigned tells whether body may assign name, which any assignment to
+// a target referencing it is assumed to
+func isAssigned(body ast.Node, name string) bool {
+	assigned := false
+	references := func(target ast.Node) {
+		ast.Inspect(target, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Identifier); ok && id.Name == name {
+				assigned = true
+			}
+			return !assigned
+		})
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch v := n.(type) {
+		case *ast.AssignmentExpression:
+			references(v.Left)
+		case *ast.UpdateExpression:
+			references(v.Argument)
+		case *ast.ForOfStatement:
+			references(v.Left)
+		}
+		return !assigned
+	})
+
+	return assigned
+}
+
+// declareLoopCopies declares the per-iteration copies of the let and const
+// variables vd declares for a loop whose body captures them, e.g. `i := i`.
+// The copies the body assigns are distinct Go vars, to be copied back to
+// the loop variables at the end of the iteration, which it returns.
+func (c *compiler) declareLoopCopies(vd *ast.VariableDeclaration, body ast.Statement) []*loopCopy {
+	if !isLexical(vd.Kind) {
+		return nil
+	}
+
+	var copies []*loopCopy
+	for _, d := range vd.Declarations {
+		for _, id := range ast.BoundIdentifiers(d.ID) {
+			b := c.lookup(id.Name)
+			if b == nil || b.enum != nil || !isCaptured(body, id.Name) {
+				continue
+			}
+
+			goName := b.goName
+			assigned := isAssigned(body, id.Name)
+			if assigned {
+				goName = c.tempVar(b.goName)
+			}
+			iterVar := c.scope.declare(id.Name, goName, vd.Kind)
+			iterVar.goType = b.goType
+			c.code.WriteLine(fmt.Sprintf("%s := %s", iterVar.goName, b.goName))
+			c.code.WriteLine(fmt.Sprintf("_ = %s", iterVar.goName))
+			if assigned {
+				copies = append(copies, &loopCopy{name: id.Name, loopVar: b, iterVar: iterVar})
+			}
+		}
+	}
+
+	return copies
+}
+
+// compileLoopCopies writes the copy-backs of the per-iteration copies of the
+// loops being compiled, ahead of a continue statement or at the end of an
+// iteration. Copying back the variables of an enclosing loop early doesn't
+// change its next iteration, which copies them back again when it ends.
+func (c *compiler) compileLoopCopies(node ast.Node) {
+	for i := len(c.loopCopies) - 1; i >= 0; i-- {
+		lc := c.loopCopies[i]
+		if c.lookup(lc.name) != lc.iterVar {
+			c.errorf(node, "continue where the loop variable %s is shadowed is not supported", lc.name)
+		}
+		c.code.WriteLine(lc.copyBack())
+	}
+}
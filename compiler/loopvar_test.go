@@ -0,0 +1,89 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// captureLoop returns `for (kind i = 0; i < 3; i++) { fns[fns.length] = () => i; ...body }`
+func captureLoop(kind string, body ...ast.Statement) *ast.ForStatement {
+	capture := exprStmt(assign("=",
+		index(ident("fns"), member(ident("fns"), ident("length"))),
+		&ast.ArrowFunctionExpression{Attr: attr("ArrowFunctionExpression"), Body: ident("i")},
+	))
+
+	return &ast.ForStatement{
+		Attr:   attr("ForStatement"),
+		Init:   varDecl(kind, "i", num(0)),
+		Test:   binary("<", ident("i"), num(3)),
+		Update: update("++", ident("i")),
+		Body:   block(append([]ast.Statement{capture}, body...)...),
+	}
+}
+
+func TestCompile_LoopVariableCapture(t *testing.T) {
+	// let: each closure captures the copy of its iteration
+	code := compile(t, file(varDecl("let", "fns", array()), captureLoop("let")), CompileOptions{})
+	if want := "i = ToNumber(i) + 1 {\ni := i\n_ = i\n"; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+
+	// var: the closures share the variable
+	code = compile(t, file(varDecl("let", "fns", array()), captureLoop("var")), CompileOptions{})
+	if strings.Contains(code, "i := i") {
+		t.Fatalf("var loop variables shouldn't be copied:\n%s", code)
+	}
+
+	// let without closures: there's nothing to copy
+	f := file(&ast.ForStatement{
+		Attr:   attr("ForStatement"),
+		Init:   varDecl("let", "i", num(0)),
+		Test:   binary("<", ident("i"), num(3)),
+		Update: update("++", ident("i")),
+		Body:   block(exprStmt(call(member(ident("console"), ident("log")), ident("i")))),
+	})
+	if code := compile(t, f, CompileOptions{}); strings.Contains(code, "i := i") {
+		t.Fatalf("uncaptured loop variables shouldn't be copied:\n%s", code)
+	}
+}
+
+func TestCompile_LoopVariableCaptureAssigned(t *testing.T) {
+	// for (let i = 0; i < 3; i++) {
+	//   fns[fns.length] = () => i
+	//   switch (i) { case 1: i++; continue }
+	//   i++
+	// }
+	loop := captureLoop("let",
+		switchStmt(ident("i"), switchCase(num(1), exprStmt(update("++", ident("i"))), &ast.ContinueStatement{Attr: attr("ContinueStatement")})),
+		exprStmt(update("++", ident("i"))),
+	)
+
+	code := compile(t, file(varDecl("let", "fns", array()), loop), CompileOptions{})
+	for _, want := range []string{
+		"i = ToNumber(i) + 1 {\ni1 := i\n_ = i1\n",
+		"i1 = ToNumber(i1) + 1\n// line 1: continue;\ni = i1\ncontinue\n",
+		"i1 = ToNumber(i1) + 1\ni = i1\n}\n}",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_ForOfCapture(t *testing.T) {
+	// for (const x of xs) { fns[fns.length] = () => x }
+	f := file(forOf(varDecl("const", "x", nil), ident("xs"), block(exprStmt(assign("=",
+		index(ident("fns"), num(0)),
+		&ast.ArrowFunctionExpression{Attr: attr("ArrowFunctionExpression"), Body: ident("x")},
+	)))))
+
+	if code := compile(t, f, CompileOptions{}); !strings.Contains(code, "_ = x\nx := x\n") {
+		t.Fatalf("the range variable should be copied:\n%s", code)
+	}
+	// range variables are per-iteration since Go 1.22
+	if code := compile(t, f, CompileOptions{GoVersion: "1.22"}); strings.Contains(code, "x := x") {
+		t.Fatalf("the range variable shouldn't be copied for Go 1.22:\n%s", code)
+	}
+}
@@ -0,0 +1,220 @@
+package compiler
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NameMangler maps a JavaScript identifier to the Go identifier it is
+// compiled to.
+type NameMangler interface {
+	Mangle(jsName string) string
+}
+
+// DefaultMangler keeps JavaScript names as they are and only escapes the
+// ones that can't be used verbatim in the generated Go source.
+type DefaultMangler struct{}
+
+// Mangle appends an underscore to names clashing with Go keywords,
+// predeclared identifiers or names reserved by the generated code.
+// Capitalized names are escaped as well since the runtime is dot-imported
+// and owns the exported namespace. `$` isn't valid in Go identifiers and is
+// spelled out.
+func (DefaultMangler) Mangle(jsName string) string {
+	name := strings.Replace(jsName, "$", "_dollar_", -1)
+
+	if goReserved[name] {
+		return name + "_"
+	}
+
+	if r, _ := utf8.DecodeRuneInString(name); unicode.IsUpper(r) {
+		return name + "_"
+	}
+
+	return name
+}
+
+// exportName returns the exported Go name of an exported JavaScript name,
+// which must not collide with the names exported by the dot-imported
+// runtime.
+func exportName(m NameMangler, jsName string) string {
+	name := m.Mangle(jsName)
+	r, n := utf8.DecodeRuneInString(name)
+	name = string(unicode.ToUpper(r)) + name[n:]
+
+	if runtimeNames[name] {
+		return name + "_"
+	}
+
+	return name
+}
+
+var goReserved = map[string]bool{
+	// keywords
+	"break":       true,
+	"case":        true,
+	"chan":        true,
+	"const":       true,
+	"continue":    true,
+	"default":     true,
+	"defer":       true,
+	"else":        true,
+	"fallthrough": true,
+	"for":         true,
+	"func":        true,
+	"go":          true,
+	"goto":        true,
+	"if":          true,
+	"import":      true,
+	"interface":   true,
+	"map":         true,
+	"package":     true,
+	"range":       true,
+	"return":      true,
+	"select":      true,
+	"struct":      true,
+	"switch":      true,
+	"type":        true,
+	"var":         true,
+
+	// predeclared identifiers
+	"any":        true,
+	"append":     true,
+	"bool":       true,
+	"byte":       true,
+	"cap":        true,
+	"clear":      true,
+	"close":      true,
+	"comparable": true,
+	"complex":    true,
+	"complex64":  true,
+	"complex128": true,
+	"copy":       true,
+	"delete":     true,
+	"error":      true,
+	"false":      true,
+	"float32":    true,
+	"float64":    true,
+	"imag":       true,
+	"int":        true,
+	"int8":       true,
+	"int16":      true,
+	"int32":      true,
+	"int64":      true,
+	"iota":       true,
+	"len":        true,
+	"make":       true,
+	"max":        true,
+	"min":        true,
+	"new":        true,
+	"nil":        true,
+	"panic":      true,
+	"print":      true,
+	"println":    true,
+	"real":       true,
+	"recover":    true,
+	"rune":       true,
+	"string":     true,
+	"true":       true,
+	"uint":       true,
+	"uint8":      true,
+	"uint16":     true,
+	"uint32":     true,
+	"uint64":     true,
+	"uintptr":    true,
+
+	// packages imported by the generated code
+	"math":    true,
+	"regexp":  true,
+	"testing": true,
+
+	// blank identifier and names used by the generated code
+	"_":           true,
+	"args":        true,
+	"filterSlice": true,
+	"global":      true,
+	"init":        true,
+	"main":        true,
+	"mapSlice":    true,
+	"sliceArray":  true,
+}
+
+// runtimeNames are the names exported by the runtime package
+var runtimeNames = map[string]bool{
+	"Add":                     true,
+	"And":                     true,
+	"Arg":                     true,
+	"Array_IsArray":           true,
+	"Call":                    true,
+	"Coalesce":                true,
+	"Console_Log":             true,
+	"Context":                 true,
+	"DefineGetter":            true,
+	"Delete":                  true,
+	"Divide":                  true,
+	"Get":                     true,
+	"GetOptional":             true,
+	"Greater":                 true,
+	"GreaterOrEqual":          true,
+	"In":                      true,
+	"IsNullish":               true,
+	"Iterate":                 true,
+	"JSArray":                 true,
+	"JSBoolean":               true,
+	"JSClass":                 true,
+	"JSFunction":              true,
+	"JSNull":                  true,
+	"JSNumber":                true,
+	"JSObject":                true,
+	"JSObjectType":            true,
+	"JSRegExp":                true,
+	"JSString":                true,
+	"JS_OBJECT_TYPE_BOOLEAN":  true,
+	"JS_OBJECT_TYPE_FUNCTION": true,
+	"JS_OBJECT_TYPE_NULL":     true,
+	"JS_OBJECT_TYPE_NUMBER":   true,
+	"JS_OBJECT_TYPE_OBJECT":   true,
+	"JS_OBJECT_TYPE_STRING":   true,
+	"Less":                    true,
+	"LessOrEqual":             true,
+	"Math_Max":                true,
+	"Math_Min":                true,
+	"Modulo":                  true,
+	"Mul":                     true,
+	"Neg":                     true,
+	"New":                     true,
+	"NewArray":                true,
+	"NewArrayWithHoles":       true,
+	"NewClass":                true,
+	"NewDefaultContext":       true,
+	"NewFunction":             true,
+	"NewObject":               true,
+	"NewRegExp":               true,
+	"Null":                    true,
+	"Object":                  true,
+	"Object_Assign":           true,
+	"Object_Entries":          true,
+	"Object_Freeze":           true,
+	"Object_Keys":             true,
+	"Object_Values":           true,
+	"Or":                      true,
+	"ReferenceError":          true,
+	"RegExp_Exec":             true,
+	"RegExp_Test":             true,
+	"Set":                     true,
+	"SpreadIterable":          true,
+	"SpreadObject":            true,
+	"StrictEquals":            true,
+	"StringIndex":             true,
+	"String_Match":            true,
+	"String_Replace":          true,
+	"Sub":                     true,
+	"ToArray":                 true,
+	"ToNumber":                true,
+	"ToString":                true,
+	"Ternary":                 true,
+	"Truthy":                  true,
+	"TypeError":               true,
+	"TypeOf":                  true,
+}
@@ -0,0 +1,89 @@
+package compiler
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestDefaultMangler(t *testing.T) {
+	tests := []struct {
+		js     string
+		goName string
+	}{
+		{"foo", "foo"},
+		{"fooBar", "fooBar"},
+		{"type", "type_"},
+		{"len", "len_"},
+		{"global", "global_"},
+		{"_", "__"},
+		{"Foo", "Foo_"},
+		{"$el", "_dollar_el"},
+	}
+
+	for _, test := range tests {
+		if got := (DefaultMangler{}).Mangle(test.js); got != test.goName {
+			t.Errorf("mangling %s: want=%s got=%s", test.js, test.goName, got)
+		}
+	}
+}
+
+type upperMangler struct{}
+
+func (upperMangler) Mangle(jsName string) string {
+	return strings.Map(unicode.ToUpper, jsName)
+}
+
+func TestCompile_CustomMangler(t *testing.T) {
+	// let foo = "hello"
+	// console.log(foo)
+	f := file(
+		varDecl("let", "foo", str("hello")),
+		exprStmt(call(member(ident("console"), ident("log")), ident("foo"))),
+	)
+
+	code := compile(t, f, CompileOptions{Mangler: upperMangler{}})
+	for _, want := range []string{
+		"var FOO Object",
+		`global.DefineProperty("foo", FOO)`,
+		`Console_Log([]Object{FOO})`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestRuntimeNames(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, "../runtime", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("error parsing runtime: %s", err)
+	}
+
+	exported := make(map[string]bool)
+	for _, f := range pkgs["runtime"].Files {
+		for name, obj := range f.Scope.Objects {
+			if ast.IsExported(name) && obj.Kind != ast.Bad {
+				exported[name] = true
+			}
+		}
+	}
+
+	for name := range exported {
+		if !runtimeNames[name] {
+			t.Errorf("runtime exports %s which is missing from runtimeNames", name)
+		}
+	}
+	for name := range runtimeNames {
+		if !exported[name] {
+			t.Errorf("runtimeNames has %s which the runtime doesn't export", name)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package compiler
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// ModuleResolver resolves the source of an import declaration to the import
+// path of the Go package the module is compiled to.
+type ModuleResolver interface {
+	Resolve(source string) (goImportPath string, err error)
+}
+
+// BaseResolver resolves relative module sources, such as "./foo", against
+// the import path of a base package, and uses other sources as Go import
+// paths verbatim.
+type BaseResolver struct {
+	// Base is the import path relative sources are resolved against
+	Base string
+}
+
+func (r BaseResolver) Resolve(source string) (string, error) {
+	if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+		return source, nil
+	}
+
+	if r.Base == "" {
+		return "", fmt.Errorf("no base package to resolve relative module %q against", source)
+	}
+
+	p := path.Join(r.Base, source)
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", fmt.Errorf("relative module %q is outside of the import path of %q", source, r.Base)
+	}
+
+	return p, nil
+}
+
+// packageName returns the Package of opts, main when it isn't set
+func packageName(opts CompileOptions) (string, error) {
+	if opts.Package == "" {
+		return "main", nil
+	}
+	if !token.IsIdentifier(opts.Package) || opts.Package == "_" {
+		return "", fmt.Errorf("invalid package name %q", opts.Package)
+	}
+
+	return opts.Package, nil
+}
+
+// writeGlobal declares the global object of a package whose files run in
+// init functions, and the main function of a main package
+func (c *compiler) writeGlobal() {
+	c.code.WriteDecl("var global = NewDefaultContext().Global")
+	if c.pkg == "main" {
+		c.code.WriteDecl("")
+		c.code.WriteDecl("func main() {}")
+	}
+}
+
+// checkImportable reports the exports of a module compiled to package main,
+// which Go can't import
+func (c *compiler) checkImportable(node ast.Node) {
+	if c.pkg == "main" {
+		c.errorf(node, "export from package main is not supported, as it can't be imported: compile the module to another Package")
+	}
+}
+
+// moduleImport is a Go package imported by the file
+type moduleImport struct {
+	path  string
+	alias string
+	// used tells whether a binding of the package is referenced, unused
+	// packages are still imported for their side effects
+	used bool
+}
+
+// compileImportDeclaration imports the Go package of a module and binds the
+// imported names to its exported vars
+func (c *compiler) compileImportDeclaration(id *ast.ImportDeclaration) {
+	importPath, err := c.resolver.Resolve(id.Source.Value)
+	if err != nil {
+		c.errorf(id, "cannot resolve module %s: %s", id.Source, err)
+	}
+	m := c.importModule(importPath)
+
+	for _, s := range id.Specifiers {
+		switch v := s.(type) {
+		case *ast.ImportDefaultSpecifier:
+			c.declareImport(v.Local.Name, m.alias+".Default", "import", m)
+		case *ast.ImportSpecifier:
+			c.declareImport(v.Local.Name, m.alias+"."+c.importedName(v.Imported.Name), "import", m)
+		case *ast.ImportNamespaceSpecifier:
+			c.declareImport(v.Local.Name, m.alias, "namespace", m)
+		}
+	}
+}
+
+func (c *compiler) declareImport(name, goName, kind string, m *moduleImport) {
+	c.imports.declare(name, goName, kind).module = m
+}
+
+// importedName returns the Go name a module exports name as
+func (c *compiler) importedName(name string) string {
+	if name == "default" {
+		return "Default"
+	}
+
+	return exportName(c.mangler, name)
+}
+
+// importModule returns the import of the Go package at importPath, aliased
+// after its last path element
+func (c *compiler) importModule(importPath string) *moduleImport {
+	for _, m := range c.modules {
+		if m.path == importPath {
+			return m
+		}
+	}
+
+	base := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, path.Base(importPath))
+
+	alias := "m_" + base
+	for i := 1; c.isAliasUsed(alias); i++ {
+		alias = fmt.Sprintf("m_%s%d", base, i)
+	}
+
+	m := &moduleImport{path: importPath, alias: alias}
+	c.modules = append(c.modules, m)
+
+	return m
+}
+
+func (c *compiler) isAliasUsed(alias string) bool {
+	for _, m := range c.modules {
+		if m.alias == alias {
+			return true
+		}
+	}
+
+	return c.scope.isGoNameUsed(alias)
+}
+
+// compileNamespaceMember compiles a member of a namespace import to the
+// exported var of its package, reporting whether me is one
+func (c *compiler) compileNamespaceMember(me *ast.MemberExpression) bool {
+	oID, ok := me.Object.(*ast.Identifier)
+	if !ok {
+		return false
+	}
+
+	b := c.lookup(oID.Name)
+	if b == nil || b.kind != "namespace" {
+		return false
+	}
+
+	pID, ok := me.Property.(*ast.Identifier)
+	if !ok || me.Computed {
+		c.errorf(me, "computed member of namespace import %s is not supported", oID.Name)
+	}
+
+	b.module.used = true
+	c.code.Write(b.goName + "." + c.importedName(pID.Name))
+
+	return true
+}
+
+// writeImports writes the Go imports of the imported modules
+func (c *compiler) writeImports() {
+	for _, m := range c.modules {
+		if m.used {
+			c.code.Import(m.alias + " " + strconv.Quote(m.path))
+		} else {
+			c.code.Import("_ " + strconv.Quote(m.path))
+		}
+	}
+}
+
+// specifierExports returns the names the export specifiers of p export,
+// e.g. foo for `export { foo }`, mapped to the names they're exported as.
+// A name exported more than once maps to its first exported name.
+func specifierExports(p *ast.Program) map[string]string {
+	exports := make(map[string]string)
+	for _, s := range p.Body {
+		en, ok := s.(*ast.ExportNamedDeclaration)
+		if !ok || en.Declaration != nil || en.Source != nil {
+			continue
+		}
+
+		for _, spec := range en.Specifiers {
+			if _, ok := exports[spec.Local.Name]; !ok {
+				exports[spec.Local.Name] = spec.Exported.Name
+			}
+		}
+	}
+
+	return exports
+}
+
+// compileExportSpecifiers checks the names export specifiers export, whose
+// declarations have compiled to the exported Go vars, e.g. Foo for
+// `const foo = 1; export { foo }`. A name can't be exported under several
+// names, as it has a single Go var.
+func (c *compiler) compileExportSpecifiers(en *ast.ExportNamedDeclaration) {
+	for _, spec := range en.Specifiers {
+		name := spec.Local.Name
+		b := c.module.lookupLocal(name)
+		switch {
+		case b == nil:
+			if c.imports.lookupLocal(name) != nil {
+				c.errorf(spec, "export of imported binding %s is not supported", name)
+			}
+			c.errorf(spec, "export of undeclared %s", name)
+		case b.goName != c.importedName(spec.Exported.Name):
+			c.errorf(spec, "export of %s under more than one name is not supported", name)
+		}
+	}
+}
+
+// compileReexport imports the Go package of the module export specifiers
+// re-export names of, and assigns its exported vars to the exported Go vars
+// of the names, e.g. `Y = m_mod.X` for `export { x as y } from "./mod"`.
+// The re-exported names aren't bound in the module scope, they're declared
+// under their export, which JavaScript names can't clash with.
+func (c *compiler) compileReexport(en *ast.ExportNamedDeclaration) {
+	importPath, err := c.resolver.Resolve(en.Source.Value)
+	if err != nil {
+		c.errorf(en, "cannot resolve module %s: %s", en.Source, err)
+	}
+	m := c.importModule(importPath)
+	m.used = true
+
+	for i, spec := range en.Specifiers {
+		key := "export " + spec.Exported.Name
+		if c.module.lookupLocal(key) != nil {
+			c.errorf(spec, "duplicate export %s", spec.Exported.Name)
+		}
+
+		b := c.declareGoVar(key, c.importedName(spec.Exported.Name), "reexport")
+		if i > 0 {
+			c.code.WriteLine("")
+		}
+		c.code.Write(fmt.Sprintf("%s = %s.%s", b.goName, m.alias, c.importedName(spec.Local.Name)))
+	}
+}
+
+// compileExportNamedDeclaration compiles an exported declaration, whose
+// names compile to exported Go vars, or the export specifiers of names
+// declared apart or re-exported from another module
+func (c *compiler) compileExportNamedDeclaration(en *ast.ExportNamedDeclaration) {
+	switch {
+	case en.Source != nil:
+		c.compileReexport(en)
+		return
+	case en.Declaration == nil:
+		c.compileExportSpecifiers(en)
+		return
+	}
+
+	switch v := en.Declaration.(type) {
+	case *ast.VariableDeclaration:
+		for _, d := range v.Declarations {
+			for _, id := range ast.BoundIdentifiers(d.ID) {
+				if c.scope.lookupLocal(id.Name) == nil {
+					c.declareExportedVar(id.Name, v.Kind)
+				}
+			}
+		}
+	case *ast.FunctionDeclaration:
+		if c.scope.lookupLocal(v.ID.Name) == nil {
+			c.declareExportedVar(v.ID.Name, "function")
+		}
+	}
+
+	c.compileStatement(en.Declaration)
+}
+
+// declareDefaultExport declares the module default, under the name default
+// which can't clash with JavaScript identifiers
+func (c *compiler) declareDefaultExport() *binding {
+	return c.declareGoVar("default", "Default", "const")
+}
+
+// defaultExportName returns the name and kind of the function or class
+// ed declares, if it's named
+func defaultExportName(ed *ast.ExportDefaultDeclaration) (name, kind string) {
+	switch v := ed.Declaration.(type) {
+	case *ast.FunctionDeclaration:
+		if v.ID != nil {
+			return v.ID.Name, "function"
+		}
+	case *ast.ClassDeclaration:
+		if v.ID != nil {
+			return v.ID.Name, "class"
+		}
+	}
+
+	return "", ""
+}
+
+// compileExportDefaultDeclaration compiles the module default to the
+// Default Go var. A named function or class is exported under its name as
+// well, e.g. `export default function foo() {}` compiles to Foo and Default.
+func (c *compiler) compileExportDefaultDeclaration(ed *ast.ExportDefaultDeclaration) {
+	b := c.scope.lookupLocal("default")
+	if b == nil {
+		b = c.declareDefaultExport()
+	}
+
+	if name, kind := defaultExportName(ed); name != "" {
+		if c.scope.lookupLocal(name) == nil {
+			c.declareExportedVar(name, kind)
+		}
+		c.compileStatement(ed.Declaration.(ast.Statement))
+		c.code.WriteLine("")
+		c.code.Write(fmt.Sprintf("%s = %s", b.goName, c.scope.lookup(name).goName))
+		return
+	}
+
+	switch v := ed.Declaration.(type) {
+	case *ast.FunctionDeclaration:
+		c.code.Write(fmt.Sprintf("%s = ", b.goName))
+		c.compileFunction(v.Params, v.Body)
+	case *ast.ClassDeclaration:
+		// anonymous classes are named default
+		c.compileClass("default", b, v)
+	case ast.Expression:
+		c.code.Write(fmt.Sprintf("%s = ", b.goName))
+		c.compileExpression(v)
+	}
+}
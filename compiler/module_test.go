@@ -0,0 +1,507 @@
+package compiler
+
+import (
+	"encoding/json"
+	"fmt"
+	goast "go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_ExportNamedDeclaration(t *testing.T) {
+	// export const x = 1
+	// console.log(x)
+	f := file(
+		exportNamed(varDecl("const", "x", num(1))),
+		exprStmt(call(member(ident("console"), ident("log")), ident("x"))),
+	)
+
+	code := compile(t, f, CompileOptions{Package: "mod"})
+	for _, want := range []string{
+		"var X Object",
+		"X = JSNumber(1)",
+		`global.DefineProperty("x", X)`,
+		"Console_Log([]Object{X})",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_ExportNamedCollidingWithRuntime(t *testing.T) {
+	// export function get() {}
+	f := file(exportNamed(funcDecl("get", nil)))
+
+	code := compile(t, f, CompileOptions{Package: "mod"})
+	if want := "var Get_ Object"; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_ExportSpecifiers(t *testing.T) {
+	// export { bar as baz }
+	// const foo = 1
+	// function bar() {}
+	// export { foo }
+	f := file(
+		exportSpecs(exportSpec("bar", "baz")),
+		varDecl("const", "foo", num(1)),
+		funcDecl("bar", nil),
+		exportSpecs(exportSpec("foo", "foo")),
+	)
+
+	for _, opts := range []CompileOptions{{Package: "mod"}, {Package: "mod", WrapMain: true}, {Package: "mod", InferTypes: true}} {
+		res, err := Compile(f, opts)
+		if err != nil {
+			t.Fatalf("error compiling with %+v: %s", opts, err)
+		}
+
+		for _, want := range []string{"Foo", "Baz = NewFunction("} {
+			if !strings.Contains(res.Source, want) {
+				t.Errorf("compiled code with %+v doesn't contain %q:\n%s", opts, want, res.Source)
+			}
+		}
+		if want := map[string]string{"foo": "Foo", "bar": "Baz"}; res.Symbols["foo"] != want["foo"] || res.Symbols["bar"] != want["bar"] {
+			t.Errorf("want symbols %v, got %v", want, res.Symbols)
+		}
+	}
+}
+
+func TestCompile_ExportSpecifiersErrors(t *testing.T) {
+	tests := []struct {
+		f    *ast.File
+		want string
+	}{
+		// export { foo }
+		{file(exportSpecs(exportSpec("foo", "foo"))), "export of undeclared foo"},
+		// const foo = 1
+		// export { foo, foo as bar }
+		{file(
+			varDecl("const", "foo", num(1)),
+			exportSpecs(exportSpec("foo", "foo"), exportSpec("foo", "bar")),
+		), "export of foo under more than one name is not supported"},
+		// import foo from "./foo"
+		// export { foo }
+		{file(
+			importDecl("./foo", importDefault("foo")),
+			exportSpecs(exportSpec("foo", "foo")),
+		), "export of imported binding foo is not supported"},
+	}
+
+	for _, test := range tests {
+		_, err := Compile(test.f, CompileOptions{Package: "mod", Resolver: BaseResolver{Base: "example.com/app"}})
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("want an error containing %q, got %v", test.want, err)
+		}
+	}
+}
+
+func TestCompile_ExportDefaultDeclaration(t *testing.T) {
+	// export default function() {}
+	astJSON := `{"type":"File","start":0,"end":28,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":28}},"program":{"type":"Program","start":0,"end":28,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":28}},"sourceType":"module","body":[{"type":"ExportDefaultDeclaration","start":0,"end":28,"loc":{"start":{"line":1,"column":0},"end":{"line":1,"column":28}},"declaration":{"type":"FunctionDeclaration","start":15,"end":28,"loc":{"start":{"line":1,"column":15},"end":{"line":1,"column":28}},"id":null,"generator":false,"expression":false,"async":false,"params":[],"body":{"type":"BlockStatement","start":26,"end":28,"loc":{"start":{"line":1,"column":26},"end":{"line":1,"column":28}},"body":[],"directives":[]}}}],"directives":[]}}`
+
+	f := &ast.File{}
+	if err := json.Unmarshal([]byte(astJSON), f); err != nil {
+		t.Fatalf("error decoding AST JSON: %s", err)
+	}
+
+	code := compile(t, f, CompileOptions{Package: "mod"})
+	for _, want := range []string{
+		"var Default Object",
+		"Default = NewFunction(func(args []Object) Object {",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_ExportDefaultNamed(t *testing.T) {
+	// foo()
+	// export default function foo() {}
+	f := file(
+		exprStmt(call(ident("foo"))),
+		exportDefault(funcDecl("foo", nil)),
+	)
+
+	code := compile(t, f, CompileOptions{Package: "mod"})
+	for _, want := range []string{
+		"var Foo Object",
+		"Foo = NewFunction(func(args []Object) Object {",
+		"Default = Foo\n",
+		"Call(Foo, []Object{})",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+	// the function is hoisted above the call
+	if strings.Index(code, "Default = Foo") > strings.Index(code, "Call(Foo") {
+		t.Fatalf("default export isn't hoisted:\n%s", code)
+	}
+
+	// export default class Bar {}
+	code = compile(t, file(exportDefault(class("Bar"))), CompileOptions{Package: "mod"})
+	for _, want := range []string{
+		"var Bar_ Object",
+		`Bar_ = NewClass("Bar", func(this *JSObject) {`,
+		"Default = Bar_\n",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_ExportDefaultAnonymousClass(t *testing.T) {
+	// export default class {}
+	anonymous := class("")
+	anonymous.ID = nil
+
+	code := compile(t, file(exportDefault(anonymous)), CompileOptions{Package: "mod"})
+	if want := `Default = NewClass("default", func(this *JSObject) {`; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompileProgram_Exports(t *testing.T) {
+	// a.js: export const x = 1
+	// b.js: console.log(x)
+	a := file(exportNamed(varDecl("const", "x", num(1))))
+	b := file(exprStmt(call(member(ident("console"), ident("log")), ident("x"))))
+
+	out, err := CompileProgram(map[string]*ast.File{"a.js": a, "b.js": b}, CompileOptions{Package: "mod"})
+	if err != nil {
+		t.Fatalf("error compiling program: %s", err)
+	}
+
+	if want := "var X Object\n"; !strings.Contains(out["a.js"], want) {
+		t.Fatalf("a.js doesn't contain %q:\n%s", want, out["a.js"])
+	}
+	if want := "Console_Log([]Object{X})"; !strings.Contains(out["b.js"], want) {
+		t.Fatalf("b.js doesn't contain %q:\n%s", want, out["b.js"])
+	}
+}
+
+func TestCompile_ImportDeclaration(t *testing.T) {
+	// import greet, { name, default as hello } from "github.com/acme/greeter"
+	// import * as util from "github.com/acme/util"
+	// util.log(greet(name), hello)
+	f := file(
+		importDecl("github.com/acme/greeter",
+			importDefault("greet"),
+			importNamed("name", "name"),
+			importNamed("default", "hello"),
+		),
+		importDecl("github.com/acme/util", importNamespace("util")),
+		exprStmt(call(member(ident("util"), ident("log")), call(ident("greet"), ident("name")), ident("hello"))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		`m_greeter "github.com/acme/greeter"`,
+		`m_util "github.com/acme/util"`,
+		"Call(m_util.Log, []Object{Call(m_greeter.Default, []Object{m_greeter.Name}), m_greeter.Default})",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_ImportDeclarationUnused(t *testing.T) {
+	// import "github.com/acme/polyfill"
+	// import { x } from "github.com/acme/util"
+	f := file(
+		importDecl("github.com/acme/polyfill"),
+		importDecl("github.com/acme/util", importNamed("x", "x")),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		`_ "github.com/acme/polyfill"`,
+		`_ "github.com/acme/util"`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_ImportNamespaceAsValue(t *testing.T) {
+	// import * as util from "github.com/acme/util"
+	// console.log(util)
+	f := file(
+		importDecl("github.com/acme/util", importNamespace("util")),
+		exprStmt(call(member(ident("console"), ident("log")), ident("util"))),
+	)
+
+	_, err := Compile(f, CompileOptions{})
+	if err == nil {
+		t.Fatal("expected an error compiling a namespace import used as a value")
+	}
+	if want := "namespace import util"; !strings.Contains(err.Error(), want) {
+		t.Fatalf("error %q doesn't contain %q", err, want)
+	}
+}
+
+func TestCompileProgram_ImportsAreFileLocal(t *testing.T) {
+	// a.js: import { x } from "github.com/acme/util"; console.log(x)
+	// b.js: console.log(x)
+	log := func() *ast.ExpressionStatement {
+		return exprStmt(call(member(ident("console"), ident("log")), ident("x")))
+	}
+	a := file(importDecl("github.com/acme/util", importNamed("x", "x")), log())
+	b := file(log())
+
+	out, err := CompileProgram(map[string]*ast.File{"a.js": a, "b.js": b}, CompileOptions{})
+	if err != nil {
+		t.Fatalf("error compiling program: %s", err)
+	}
+
+	if want := "Console_Log([]Object{m_util.X})"; !strings.Contains(out["a.js"], want) {
+		t.Fatalf("a.js doesn't contain %q:\n%s", want, out["a.js"])
+	}
+	if want := `Console_Log([]Object{global.Resolve("x")})`; !strings.Contains(out["b.js"], want) {
+		t.Fatalf("b.js doesn't contain %q:\n%s", want, out["b.js"])
+	}
+	if strings.Contains(out["b.js"], "github.com/acme/util") {
+		t.Fatalf("b.js imports a.js's module:\n%s", out["b.js"])
+	}
+}
+
+type stubResolver map[string]string
+
+func (r stubResolver) Resolve(source string) (string, error) {
+	if p, ok := r[source]; ok {
+		return p, nil
+	}
+
+	return "", fmt.Errorf("unknown module %q", source)
+}
+
+func TestCompile_ModuleResolver(t *testing.T) {
+	// import foo from "./foo"
+	// foo()
+	f := file(
+		importDecl("./foo", importDefault("foo")),
+		exprStmt(call(ident("foo"))),
+	)
+
+	code := compile(t, f, CompileOptions{Resolver: stubResolver{"./foo": "example.com/app/foo"}})
+	for _, want := range []string{
+		`m_foo "example.com/app/foo"`,
+		"Call(m_foo.Default, []Object{})",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_ModuleResolverError(t *testing.T) {
+	// import bar from "./bar"
+	f := file(importDecl("./bar", importDefault("bar")))
+
+	_, err := Compile(f, CompileOptions{Resolver: stubResolver{"./foo": "example.com/app/foo"}})
+	if err == nil {
+		t.Fatal("expected an error compiling an unresolvable import")
+	}
+	if want := `cannot resolve module "./bar": unknown module "./bar"`; !strings.Contains(err.Error(), want) {
+		t.Fatalf("error %q doesn't contain %q", err, want)
+	}
+}
+
+func TestCompile_Reexport(t *testing.T) {
+	// export { x, default as y } from "./mod"
+	// const x = 1
+	reexport := exportSpecs(exportSpec("x", "x"), exportSpec("default", "y"))
+	reexport.Source = str("./mod")
+	f := file(reexport, varDecl("const", "x", num(1)))
+
+	res, err := Compile(f, CompileOptions{Package: "mod", Resolver: stubResolver{"./mod": "example.com/app/mod"}})
+	if err != nil {
+		t.Fatalf("error compiling: %s", err)
+	}
+	for _, want := range []string{
+		`m_mod "example.com/app/mod"`,
+		"var X Object\n",
+		"var Y Object\n",
+		"X = m_mod.X\n",
+		"Y = m_mod.Default\n",
+		"x = JSNumber(1)\n",
+	} {
+		if !strings.Contains(res.Source, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, res.Source)
+		}
+	}
+	// the re-exported names aren't declared in the module
+	if want := map[string]string{"x": "x"}; !reflect.DeepEqual(want, res.Symbols) {
+		t.Errorf("want symbols %v, got %v", want, res.Symbols)
+	}
+
+	// export { x, x } from "./mod"
+	reexport = exportSpecs(exportSpec("x", "x"), exportSpec("x", "x"))
+	reexport.Source = str("./mod")
+	_, err = Compile(file(reexport), CompileOptions{Package: "mod", Resolver: stubResolver{"./mod": "example.com/app/mod"}})
+	if want := "duplicate export x"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("want an error containing %q, got %v", want, err)
+	}
+
+	// export { x } from "./bar"
+	reexport = exportSpecs(exportSpec("x", "x"))
+	reexport.Source = str("./bar")
+	_, err = Compile(file(reexport), CompileOptions{Package: "mod", Resolver: stubResolver{"./mod": "example.com/app/mod"}})
+	if want := `cannot resolve module "./bar"`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("want an error containing %q, got %v", want, err)
+	}
+}
+
+func TestCompile_ExportFromMain(t *testing.T) {
+	// export const x = 1
+	_, err := Compile(file(exportNamed(varDecl("const", "x", num(1)))), CompileOptions{})
+	if want := "export from package main is not supported"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("want an error containing %q, got %v", want, err)
+	}
+
+	if _, err := Compile(file(), CompileOptions{Package: "my-lib"}); err == nil {
+		t.Error("want an error compiling to an invalid package name")
+	}
+}
+
+// moduleImporter imports the type-checked compiled modules, and the other
+// packages from source
+type moduleImporter struct {
+	modules map[string]*types.Package
+	types.Importer
+}
+
+func (i moduleImporter) Import(path string) (*types.Package, error) {
+	if pkg, ok := i.modules[path]; ok {
+		return pkg, nil
+	}
+
+	return i.Importer.Import(path)
+}
+
+func TestCompile_ModulesBuild(t *testing.T) {
+	// greeter.js:
+	//   export const greeting = "hello"
+	//   export function greet(name) { return greeting + " " + name }
+	// relay.js:
+	//   export { greet as hi, greeting } from "./greeter"
+	// main.js:
+	//   import { greet } from "./greeter"
+	//   import { hi, greeting } from "./relay"
+	//   console.log(greet("a"), hi("b"), greeting)
+	relay := exportSpecs(exportSpec("greet", "hi"), exportSpec("greeting", "greeting"))
+	relay.Source = str("./greeter")
+	modules := []struct {
+		path, pkg string
+		f         *ast.File
+	}{
+		{"example.com/app/greeter", "greeter", file(
+			exportNamed(varDecl("const", "greeting", str("hello"))),
+			exportNamed(funcDecl("greet", []string{"name"}, ret(binary("+", binary("+", ident("greeting"), str(" ")), ident("name"))))),
+		)},
+		{"example.com/app/relay", "relay", file(relay)},
+		{"example.com/app", "", file(
+			importDecl("./greeter", importNamed("greet", "greet")),
+			importDecl("./relay", importNamed("hi", "hi"), importNamed("greeting", "greeting")),
+			exprStmt(call(member(ident("console"), ident("log")), call(ident("greet"), str("a")), call(ident("hi"), str("b")), ident("greeting"))),
+		)},
+	}
+
+	fset := token.NewFileSet()
+	imp := moduleImporter{make(map[string]*types.Package), importer.ForCompiler(fset, "source", nil)}
+	for _, m := range modules {
+		opts := CompileOptions{Package: m.pkg, Resolver: BaseResolver{Base: "example.com/app"}}
+		code := compile(t, m.f, opts)
+
+		f, err := parser.ParseFile(fset, m.path+".go", code, 0)
+		if err != nil {
+			t.Fatalf("%s isn't valid Go: %s\n%s", m.path, err, code)
+		}
+		pkg, err := (&types.Config{Importer: imp}).Check(m.path, fset, []*goast.File{f}, nil)
+		if err != nil {
+			t.Fatalf("%s doesn't build: %s\n%s", m.path, err, code)
+		}
+		imp.modules[m.path] = pkg
+	}
+
+	if name := imp.modules["example.com/app"].Name(); name != "main" {
+		t.Errorf("want the importing module in package main, got %s", name)
+	}
+}
+
+func TestBaseResolver(t *testing.T) {
+	tests := []struct {
+		base   string
+		source string
+		want   string
+		err    bool
+	}{
+		{"example.com/app", "./foo", "example.com/app/foo", false},
+		{"example.com/app/lib", "../foo/bar", "example.com/app/foo/bar", false},
+		{"example.com/app", "github.com/acme/util", "github.com/acme/util", false},
+		{"", "github.com/acme/util", "github.com/acme/util", false},
+		{"", "./foo", "", true},
+		{"example.com/app", "../../../foo", "", true},
+	}
+
+	for _, test := range tests {
+		got, err := BaseResolver{Base: test.base}.Resolve(test.source)
+		if test.err {
+			if err == nil {
+				t.Errorf("resolving %q against %q: expected an error, got %q", test.source, test.base, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("resolving %q against %q: %s", test.source, test.base, err)
+		} else if got != test.want {
+			t.Errorf("resolving %q against %q: want=%s got=%s", test.source, test.base, test.want, got)
+		}
+	}
+}
+
+func exportNamed(decl ast.Statement) *ast.ExportNamedDeclaration {
+	return &ast.ExportNamedDeclaration{Attr: attr("ExportNamedDeclaration"), Declaration: decl}
+}
+
+func exportSpecs(specs ...*ast.ExportSpecifier) *ast.ExportNamedDeclaration {
+	return &ast.ExportNamedDeclaration{Attr: attr("ExportNamedDeclaration"), Specifiers: specs}
+}
+
+func exportSpec(local, exported string) *ast.ExportSpecifier {
+	return &ast.ExportSpecifier{Attr: attr("ExportSpecifier"), Local: ident(local), Exported: ident(exported)}
+}
+
+func exportDefault(decl ast.Node) *ast.ExportDefaultDeclaration {
+	return &ast.ExportDefaultDeclaration{Attr: attr("ExportDefaultDeclaration"), Declaration: decl}
+}
+
+func importDecl(source string, specs ...ast.Node) *ast.ImportDeclaration {
+	return &ast.ImportDeclaration{Attr: attr("ImportDeclaration"), Specifiers: specs, Source: str(source)}
+}
+
+func importDefault(local string) *ast.ImportDefaultSpecifier {
+	return &ast.ImportDefaultSpecifier{Attr: attr("ImportDefaultSpecifier"), Local: ident(local)}
+}
+
+func importNamed(imported, local string) *ast.ImportSpecifier {
+	return &ast.ImportSpecifier{Attr: attr("ImportSpecifier"), Imported: ident(imported), Local: ident(local)}
+}
+
+func importNamespace(local string) *ast.ImportNamespaceSpecifier {
+	return &ast.ImportNamespaceSpecifier{Attr: attr("ImportNamespaceSpecifier"), Local: ident(local)}
+}
@@ -0,0 +1,77 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"go/scanner"
+	"go/token"
+	"math"
+	"strconv"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// compileNumericLiteral compiles a number to a Go constant. Numbers beyond
+// float64 range are infinite, which Go constants can't be.
+func (c *compiler) compileNumericLiteral(n *ast.NumericLiteral) {
+	if math.IsInf(n.Value, 0) {
+		c.compileNumber(n.Value)
+		return
+	}
+
+	c.code.Write(fmt.Sprintf("JSNumber(%s)", goNumber(n)))
+}
+
+// compileNumber compiles a number the compiler computes, e.g. by folding
+// `1 / 0`. Infinities, NaN and -0 aren't Go constants and are computed by
+// the math package, e.g. `math.Inf(1)`.
+func (c *compiler) compileNumber(f float64) {
+	var value string
+	switch {
+	case math.IsInf(f, 0):
+		value = fmt.Sprintf("math.Inf(%d)", int(math.Copysign(1, f)))
+	case math.IsNaN(f):
+		value = "math.NaN()"
+	case f == 0 && math.Signbit(f):
+		value = "math.Copysign(0, -1)"
+	default:
+		c.code.Write(fmt.Sprintf("JSNumber(%s)", strconv.FormatFloat(f, 'g', -1, 64)))
+		return
+	}
+
+	c.code.Import(`"math"`)
+	c.code.Write(fmt.Sprintf("JSNumber(%s)", value))
+}
+
+// goNumber returns the Go literal of a finite number. The literal is kept
+// as written, e.g. `0x1f`, `1e3` or `9007199254740993`, when Go reads it as
+// the same float64, as Go rounds constants converted to float64 like
+// JavaScript rounds number literals.
+func goNumber(n *ast.NumericLiteral) string {
+	if n.Extra != nil {
+		if raw, ok := n.Extra.Raw.(string); ok && isGoNumber(raw, n.Value) {
+			return raw
+		}
+	}
+
+	return strconv.FormatFloat(n.Value, 'g', -1, 64)
+}
+
+// isGoNumber tells whether lit is a Go number literal of value
+func isGoNumber(lit string, value float64) bool {
+	var s scanner.Scanner
+	errs := 0
+	file := token.NewFileSet().AddFile("", -1, len(lit))
+	s.Init(file, []byte(lit), func(token.Position, string) { errs++ }, 0)
+
+	_, tok, scanned := s.Scan()
+	if tok != token.INT && tok != token.FLOAT || scanned != lit {
+		return false
+	}
+	if _, next, _ := s.Scan(); (next != token.SEMICOLON && next != token.EOF) || errs > 0 {
+		return false
+	}
+
+	f, _ := constant.Float64Val(constant.MakeFromLiteral(lit, tok, 0))
+	return f == value
+}
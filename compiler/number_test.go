@@ -0,0 +1,104 @@
+package compiler
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_NumericLiterals(t *testing.T) {
+	tests := []struct {
+		raw   string
+		value float64
+		want  string
+	}{
+		// beyond float64 precision, Go rounds it like JavaScript does
+		{"9007199254740993", 9007199254740993, "JSNumber(9007199254740993)"},
+		{"0x1f", 31, "JSNumber(0x1f)"},
+		{"1e3", 1000, "JSNumber(1e3)"},
+		{"1.50", 1.5, "JSNumber(1.50)"},
+		{"1_000", 1000, "JSNumber(1_000)"},
+		// 08 is decimal in JavaScript and invalid in Go
+		{"08", 8, "JSNumber(8)"},
+		{"1e400", math.Inf(1), "JSNumber(math.Inf(1))"},
+		// no raw literal
+		{"", 0.1, "JSNumber(0.1)"},
+	}
+
+	for _, test := range tests {
+		n := num(test.value)
+		if test.raw != "" {
+			n.Extra = &ast.Extra{Raw: test.raw}
+		}
+
+		code := compile(t, file(exprStmt(n)), CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("%s: compiled code doesn't contain %q:\n%s", test.raw, test.want, code)
+		}
+	}
+}
+
+func TestCompile_NegativeZero(t *testing.T) {
+	// -0
+	neg := &ast.UnaryExpression{Attr: attr("UnaryExpression"), Operator: "-", Argument: num(0)}
+
+	code := compile(t, file(exprStmt(neg)), CompileOptions{})
+	// Go constants have no negative zero
+	if want := "Neg(JSNumber(0))"; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_FoldedDivisions(t *testing.T) {
+	neg := func(e ast.Expression) ast.Expression {
+		return &ast.UnaryExpression{Attr: attr("UnaryExpression"), Operator: "-", Argument: e}
+	}
+	tests := []struct {
+		e    ast.Expression
+		want string
+	}{
+		// 1 / 0
+		{binary("/", num(1), num(0)), "JSNumber(math.Inf(1))"},
+		// -1 / 0
+		{binary("/", neg(num(1)), num(0)), "JSNumber(math.Inf(-1))"},
+		// 0 / 0
+		{binary("/", num(0), num(0)), "JSNumber(math.NaN())"},
+		// 0 / -1
+		{binary("/", num(0), neg(num(1))), "JSNumber(math.Copysign(0, -1))"},
+		// 1 % 0
+		{binary("%", num(1), num(0)), "JSNumber(math.NaN())"},
+		// 6 / 4
+		{binary("/", num(6), num(4)), "JSNumber(1.5)"},
+	}
+
+	for _, test := range tests {
+		code := compile(t, file(exprStmt(test.e)), CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("%s: compiled code doesn't contain %q:\n%s", test.e, test.want, code)
+		}
+		if strings.Contains(test.want, "math.") && !strings.Contains(code, `"math"`) {
+			t.Errorf("%s: compiled code doesn't import math:\n%s", test.e, code)
+		}
+	}
+}
+
+func TestCompile_FoldedDivisionShadowingImport(t *testing.T) {
+	// let math = 1
+	// console.log(math, 1 / 0)
+	f := file(
+		varDecl("let", "math", num(1)),
+		exprStmt(call(member(ident("console"), ident("log")), ident("math"), binary("/", num(1), num(0)))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		"var math_ Object",
+		"Console_Log([]Object{math_, JSNumber(math.Inf(1))})",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// compileDestructuringAssignment compiles an assignment to an array or
+// object pattern. Swapping variables, as in `[a, b] = [b, a]`, compiles to a
+// Go parallel assignment, other values are destructured in a func literal
+// returning the assigned value.
+func (c *compiler) compileDestructuringAssignment(pattern, right ast.Expression) {
+	if targets, ok := c.parallelTargets(pattern, right); ok {
+		ae := right.(*ast.ArrayExpression)
+		values := make([]string, len(targets))
+		for i := range targets {
+			if i < len(ae.Elements) && ae.Elements[i] != nil {
+				values[i] = c.code.Capture(func() { c.compileExpression(ae.Elements[i]) })
+			} else {
+				values[i] = "nil"
+			}
+		}
+
+		c.code.Write(fmt.Sprintf("%s = %s", strings.Join(targets, ", "), strings.Join(values, ", ")))
+		return
+	}
+
+	v := c.tempVar("d")
+	c.code.WriteLine("func() Object {")
+	c.code.Write(v + " := ")
+	c.compileExpression(right)
+	c.code.WriteLine("")
+	c.compilePatternElements(pattern, v)
+	c.code.WriteLine("return " + v)
+	c.code.Write("}()")
+}
+
+// parallelTargets returns the Go vars an array pattern assigns the elements
+// of an array literal to, when it only has distinct declared variables as
+// elements and the literal has no more elements than the pattern, none of
+// them spread
+func (c *compiler) parallelTargets(pattern, right ast.Expression) ([]string, bool) {
+	ap, ok := pattern.(*ast.ArrayPattern)
+	if !ok {
+		return nil, false
+	}
+	ae, ok := right.(*ast.ArrayExpression)
+	if !ok || len(ae.Elements) > len(ap.Elements) || hasSpread(ae.Elements) {
+		return nil, false
+	}
+
+	var targets []string
+	for _, e := range ap.Elements {
+		id, ok := e.(*ast.Identifier)
+		if !ok {
+			return nil, false
+		}
+
+		b := c.scope.lookup(id.Name)
+		if b == nil || b.goType != "" {
+			return nil, false
+		}
+		for _, t := range targets {
+			if t == b.goName {
+				return nil, false
+			}
+		}
+		targets = append(targets, b.goName)
+	}
+
+	return targets, true
+}
+
+// compileAssignTarget assigns the Go expression value to target, which may
+// be a nested pattern or a target with a default
+func (c *compiler) compileAssignTarget(target ast.Expression, value string) {
+	switch t := target.(type) {
+	case *ast.Identifier:
+		if b := c.lookup(t.Name); b != nil && b.module != nil {
+			c.errorf(t, "assignment to imported binding %s", t.Name)
+		}
+		if b := c.lookup(t.Name); b != nil && b.goType != "" {
+			c.code.WriteLine(fmt.Sprintf("%s = %s", b.goName, convertValue(b.goType, value)))
+			return
+		}
+		if c.lookup(t.Name) == nil {
+			c.code.WriteLine(globalSet(t.Name, value))
+			return
+		}
+
+		c.compileIdentifier(t)
+		c.code.WriteLine(" = " + value)
+	case *ast.MemberExpression:
+		c.code.Write("Set(")
+		c.compileExpression(t.Object)
+		c.code.Write(", ")
+		c.compileMemberKey(t.Property, t.Computed)
+		c.code.WriteLine(", " + value + ")")
+	case *ast.ArrayPattern, *ast.ObjectPattern:
+		v := c.tempVar("d")
+		c.code.WriteLine(fmt.Sprintf("%s := %s", v, value))
+		c.compilePatternElements(t, v)
+	case *ast.AssignmentPattern:
+		// the default is only evaluated when the value is undefined
+		v := c.tempVar("d")
+		c.code.WriteLine(fmt.Sprintf("%s := %s", v, value))
+		c.code.WriteLine(fmt.Sprintf("if %s == nil {", v))
+		c.code.Write(v + " = ")
+		c.compileExpression(t.Right)
+		c.code.WriteLine("")
+		c.code.WriteLine("}")
+		c.compileAssignTarget(t.Left, v)
+	case *ast.OptionalMemberExpression:
+		c.errorf(target, "invalid assignment to optional chain %s", target)
+	default:
+		c.errorf(target, "assignment to %s is not supported", target)
+	}
+}
+
+// compilePatternElements assigns the elements or properties of the value
+// held by the Go var v to the targets of pattern
+func (c *compiler) compilePatternElements(pattern ast.Expression, v string) {
+	switch p := pattern.(type) {
+	case *ast.ArrayPattern:
+		empty := true
+		for i, e := range p.Elements {
+			if e != nil {
+				c.compileAssignTarget(e, fmt.Sprintf("Get(%s, JSNumber(%d))", v, i))
+				empty = false
+			}
+		}
+		if empty {
+			c.code.WriteLine("_ = " + v)
+		}
+	case *ast.ObjectPattern:
+		if len(p.Properties) == 0 {
+			c.code.WriteLine("_ = " + v)
+		}
+		for _, prop := range p.Properties {
+			op, ok := prop.(*ast.ObjectProperty)
+			if !ok {
+				c.errorf(prop, "%s in object pattern is not supported", prop)
+			}
+
+			key := c.code.Capture(func() { c.compileMemberKey(op.Key, op.Computed) })
+			c.compileAssignTarget(op.Value, fmt.Sprintf("Get(%s, %s)", v, key))
+		}
+	}
+}
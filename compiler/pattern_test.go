@@ -0,0 +1,248 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_DestructuringSwap(t *testing.T) {
+	// let a = 1, b = 2
+	// [a, b] = [b, a]
+	f := file(
+		varDecl("let", "a", num(1)),
+		varDecl("let", "b", num(2)),
+		exprStmt(assign("=", arrayPattern(ident("a"), ident("b")), array(ident("b"), ident("a")))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	if want := "a, b = b, a\n"; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_DestructuringObjectPattern(t *testing.T) {
+	// let a, y, z
+	// ({x: a, y, w: [, z]} = obj)
+	f := file(
+		varDecl("let", "a", nil),
+		varDecl("let", "y", nil),
+		varDecl("let", "z", nil),
+		exprStmt(assign("=", objectPattern(
+			prop(ident("x"), ident("a")),
+			shorthand("y"),
+			prop(ident("w"), arrayPattern(nil, ident("z"))),
+		), ident("obj"))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	want := `func() Object {
+d1 := global.Resolve("obj")
+a = Get(d1, JSString("x"))
+y = Get(d1, JSString("y"))
+d2 := Get(d1, JSString("w"))
+z = Get(d2, JSNumber(1))
+return d1
+}()`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_DestructuringNested(t *testing.T) {
+	tests := []struct {
+		pattern ast.Expression
+		want    string
+	}{
+		// ({a: [b, c]} = obj)
+		{objectPattern(prop(ident("a"), arrayPattern(ident("b"), ident("c")))), `d1 := global.Resolve("obj")
+d2 := Get(d1, JSString("a"))
+b = Get(d2, JSNumber(0))
+c = Get(d2, JSNumber(1))
+return d1`},
+		// [{b}, [, c]] = obj
+		{arrayPattern(objectPattern(shorthand("b")), arrayPattern(nil, ident("c"))), `d1 := global.Resolve("obj")
+d2 := Get(d1, JSNumber(0))
+b = Get(d2, JSString("b"))
+d3 := Get(d1, JSNumber(1))
+c = Get(d3, JSNumber(1))
+return d1`},
+		// ({a: {b, c: [c = 1]}} = obj)
+		{objectPattern(prop(ident("a"), objectPattern(shorthand("b"), prop(ident("c"), arrayPattern(patternDefault(ident("c"), num(1))))))), `d1 := global.Resolve("obj")
+d2 := Get(d1, JSString("a"))
+b = Get(d2, JSString("b"))
+d3 := Get(d2, JSString("c"))
+d4 := Get(d3, JSNumber(0))
+if d4 == nil {
+d4 = JSNumber(1)
+}
+c = d4
+return d1`},
+	}
+
+	for _, test := range tests {
+		f := file(
+			varDecl("let", "b", nil),
+			varDecl("let", "c", nil),
+			exprStmt(assign("=", test.pattern, ident("obj"))),
+		)
+
+		code := compile(t, f, CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("compiled code doesn't contain %q:\n%s", test.want, code)
+		}
+	}
+}
+
+func TestCompile_DestructuringDefaults(t *testing.T) {
+	tests := []struct {
+		right *ast.ArrayExpression
+		want  string
+	}{
+		// [, b = 5] = [1]
+		{array(num(1)), `d1 := NewArray([]Object{JSNumber(1)})
+d2 := Get(d1, JSNumber(1))
+if d2 == nil {
+d2 = JSNumber(5)
+}
+b = d2
+return d1`},
+		// [, b = 5] = [1, 2]
+		{array(num(1), num(2)), `d1 := NewArray([]Object{JSNumber(1), JSNumber(2)})
+d2 := Get(d1, JSNumber(1))
+if d2 == nil {
+d2 = JSNumber(5)
+}
+b = d2
+return d1`},
+	}
+
+	for _, test := range tests {
+		f := file(
+			varDecl("let", "b", nil),
+			exprStmt(assign("=", arrayPattern(nil, patternDefault(ident("b"), num(5))), test.right)),
+		)
+
+		code := compile(t, f, CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("compiled code doesn't contain %q:\n%s", test.want, code)
+		}
+	}
+
+	// const [, b = 5] = arr
+	f := file(patternDecl("const", arrayPattern(nil, patternDefault(ident("b"), num(5))), ident("arr")))
+
+	code := compile(t, f, CompileOptions{})
+	want := `var b Object
+_ = b
+d1 := global.Resolve("arr")
+d2 := Get(d1, JSNumber(1))
+if d2 == nil {
+d2 = JSNumber(5)
+}
+b = d2
+`
+	if !strings.Contains(code, want) {
+		t.Errorf("compiled declaration doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_DestructuringGlobal(t *testing.T) {
+	// [q] = arr
+	f := file(exprStmt(assign("=", arrayPattern(ident("q")), ident("arr"))))
+
+	code := compile(t, f, CompileOptions{})
+	if want := `Set(global, JSString("q"), Get(d1, JSNumber(0)))`; !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+	typeCheck(t, code)
+}
+
+func TestCompile_DestructuringDeclaration(t *testing.T) {
+	// const {a: [b, c]} = obj
+	f := file(patternDecl("const", objectPattern(prop(ident("a"), arrayPattern(ident("b"), ident("c")))), ident("obj")))
+
+	code := compile(t, f, CompileOptions{InferTypes: true})
+	want := `var b Object
+_ = b
+var c Object
+_ = c
+d1 := global.Resolve("obj")
+d2 := Get(d1, JSString("a"))
+b = Get(d2, JSNumber(0))
+c = Get(d2, JSNumber(1))
+`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_DestructuringParams(t *testing.T) {
+	// function f({a: [b, c = 1]}, d) {
+	//   return c
+	// }
+	fd := funcDecl("f", nil, ret(ident("c")))
+	fd.Params = []ast.Expression{
+		objectPattern(prop(ident("a"), arrayPattern(ident("b"), patternDefault(ident("c"), num(1))))),
+		ident("d"),
+	}
+
+	code := compile(t, file(fd), CompileOptions{})
+	want := `var b Object
+_ = b
+var c Object
+_ = c
+d1 := Arg(args, 0)
+d2 := Get(d1, JSString("a"))
+b = Get(d2, JSNumber(0))
+d3 := Get(d2, JSNumber(1))
+if d3 == nil {
+d3 = JSNumber(1)
+}
+c = d3
+d := Arg(args, 1)
+`
+	if !strings.Contains(code, want) {
+		t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+	}
+}
+
+func TestCompile_DestructuringDeclarationWithoutInit(t *testing.T) {
+	// let {a}
+	f := file(patternDecl("let", objectPattern(shorthand("a")), nil))
+
+	if _, err := Compile(f, CompileOptions{}); err == nil || !strings.Contains(err.Error(), "has no initializer") {
+		t.Errorf("want a missing initializer error, got %v", err)
+	}
+}
+
+func array(elements ...ast.Expression) *ast.ArrayExpression {
+	return &ast.ArrayExpression{Attr: attr("ArrayExpression"), Elements: elements}
+}
+
+func arrayPattern(elements ...ast.Expression) *ast.ArrayPattern {
+	return &ast.ArrayPattern{Attr: attr("ArrayPattern"), Elements: elements}
+}
+
+func objectPattern(props ...ast.Node) *ast.ObjectPattern {
+	return &ast.ObjectPattern{Attr: attr("ObjectPattern"), Properties: props}
+}
+
+func shorthand(name string) *ast.ObjectProperty {
+	return &ast.ObjectProperty{Attr: attr("ObjectProperty"), Key: ident(name), Value: ident(name), Shorthand: true}
+}
+
+func patternDefault(left, right ast.Expression) *ast.AssignmentPattern {
+	return &ast.AssignmentPattern{Attr: attr("AssignmentPattern"), Left: left, Right: right}
+}
+
+func patternDecl(kind string, pattern, init ast.Expression) *ast.VariableDeclaration {
+	return &ast.VariableDeclaration{
+		Attr: attr("VariableDeclaration"),
+		Kind: kind,
+		Declarations: []*ast.VariableDeclarator{
+			{Attr: attr("VariableDeclarator"), ID: pattern, Init: init},
+		},
+	}
+}
@@ -0,0 +1,132 @@
+package compiler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// goRegExp returns the Go regexp pattern of the regular expression r, its
+// i, m and s flags turned into Go flags. Patterns Go regexps can't match,
+// e.g. with lookaheads or backreferences, are errors.
+func goRegExp(r *ast.RegExpLiteral) (string, error) {
+	var flags string
+	for _, f := range r.Flags {
+		switch f {
+		case 'i', 'm', 's':
+			flags += string(f)
+		case 'g', 'u':
+			// global matching is up to the runtime, and Go regexps match
+			// code points
+		default:
+			return "", fmt.Errorf("flag %c is not supported", f)
+		}
+	}
+
+	pattern := r.Pattern
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return "", err
+	}
+
+	return pattern, nil
+}
+
+// compileGoRegExp compiles r to the Go *regexp.Regexp of its pattern
+func (c *compiler) compileGoRegExp(r *ast.RegExpLiteral) {
+	pattern, err := goRegExp(r)
+	if err != nil {
+		c.errorf(r, "regular expression %s: %s", r, err)
+	}
+
+	c.code.Import(`"regexp"`)
+	c.code.Write(fmt.Sprintf("regexp.MustCompile(%s)", strconv.Quote(pattern)))
+}
+
+// compileRegExpLiteral compiles a regular expression literal to a JSRegExp,
+// a new one on every evaluation like in JavaScript
+func (c *compiler) compileRegExpLiteral(r *ast.RegExpLiteral) {
+	c.code.Write("NewRegExp(")
+	c.compileGoRegExp(r)
+	c.code.Write(fmt.Sprintf(", %s, %s)", strconv.Quote(r.Pattern), strconv.Quote(r.Flags)))
+}
+
+// regExpHelpers are the runtime helpers of the methods matching regular
+// expressions
+var regExpHelpers = map[string]string{
+	"test":    "RegExp_Test",
+	"exec":    "RegExp_Exec",
+	"match":   "String_Match",
+	"replace": "String_Replace",
+}
+
+// compileRegExpCall compiles the calls of the test and exec methods of
+// regular expression literals, and of the match and replace methods of
+// strings with regular expression literals, to the runtime helpers matching
+// their Go regexp, e.g. `RegExp_Test(regexp.MustCompile("\\d+"), s)` for
+// `/\d+/.test(s)`. It tells whether it did. The receivers of match and
+// replace must be known to be strings, other objects may have methods of
+// the same names.
+func (c *compiler) compileRegExpCall(ce *ast.CallExpression) bool {
+	me, ok := ce.Callee.(*ast.MemberExpression)
+	if !ok || me.Computed || hasSpread(ce.Arguments) {
+		return false
+	}
+	prop, ok := me.Property.(*ast.Identifier)
+	if !ok {
+		return false
+	}
+
+	switch method := prop.Name; {
+	case (method == "test" || method == "exec") && len(ce.Arguments) == 1:
+		r, ok := me.Object.(*ast.RegExpLiteral)
+		if !ok {
+			return false
+		}
+
+		c.code.Write(regExpHelpers[method] + "(")
+		c.compileGoRegExp(r)
+		c.code.Write(", ")
+		c.compileExpression(ce.Arguments[0])
+		c.code.Write(")")
+	case method == "match" && len(ce.Arguments) == 1 || method == "replace" && len(ce.Arguments) == 2:
+		r, ok := ce.Arguments[0].(*ast.RegExpLiteral)
+		if !ok || !c.isString(me.Object) {
+			return false
+		}
+
+		c.code.Write(regExpHelpers[method] + "(")
+		c.compileExpression(me.Object)
+		c.code.Write(", ")
+		c.compileGoRegExp(r)
+		c.code.Write(fmt.Sprintf(", %t", strings.Contains(r.Flags, "g")))
+		if method == "replace" {
+			c.code.Write(", ")
+			c.compileExpression(ce.Arguments[1])
+		}
+		c.code.Write(")")
+	default:
+		return false
+	}
+
+	return true
+}
+
+// isString tells whether e is known to evaluate to a string: a string or
+// template literal, or a variable of the string type
+func (c *compiler) isString(e ast.Expression) bool {
+	switch v := e.(type) {
+	case *ast.StringLiteral, *ast.TemplateLiteral:
+		return true
+	case *ast.Identifier:
+		b := c.lookup(v.Name)
+		return b != nil && b.goType == "string"
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func regExp(pattern, flags string) *ast.RegExpLiteral {
+	return &ast.RegExpLiteral{Attr: attr("RegExpLiteral"), Pattern: pattern, Flags: flags}
+}
+
+func TestCompile_RegExp(t *testing.T) {
+	tests := []struct {
+		e    ast.Expression
+		want string
+	}{
+		// /\d+/.test("a1")
+		{
+			call(member(regExp(`\d+`, ""), ident("test")), str("a1")),
+			`RegExp_Test(regexp.MustCompile("\\d+"), JSString("a1"))`,
+		},
+		// "a1b2".replace(/\d/g, "#")
+		{
+			call(member(str("a1b2"), ident("replace")), regExp(`\d`, "g"), str("#")),
+			`String_Replace(JSString("a1b2"), regexp.MustCompile("\\d"), true, JSString("#"))`,
+		},
+		// "a1b2".match(/[a-z]/i)
+		{
+			call(member(str("a1b2"), ident("match")), regExp(`[a-z]`, "i")),
+			`String_Match(JSString("a1b2"), regexp.MustCompile("(?i)[a-z]"), false)`,
+		},
+		// /x/m.exec(s)
+		{
+			call(member(regExp("x", "m"), ident("exec")), ident("s")),
+			`RegExp_Exec(regexp.MustCompile("(?m)x"), global.Resolve("s"))`,
+		},
+		// s.replace(/x/, "y"), where s may not be a string
+		{
+			call(member(ident("s"), ident("replace")), regExp("x", ""), str("y")),
+			`Call(Get(global.Resolve("s"), JSString("replace")), []Object{NewRegExp(regexp.MustCompile("x"), "x", ""), JSString("y")})`,
+		},
+	}
+
+	for _, test := range tests {
+		code := compile(t, file(exprStmt(test.e)), CompileOptions{})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("compiled code of %s doesn't contain %q:\n%s", test.e, test.want, code)
+		}
+		if !strings.Contains(code, `"regexp"`) {
+			t.Errorf("compiled code of %s doesn't import regexp:\n%s", test.e, code)
+		}
+	}
+}
+
+func TestCompile_RegExpShadowingImport(t *testing.T) {
+	// let regexp = 1
+	// /a/.test(regexp)
+	f := file(
+		varDecl("let", "regexp", num(1)),
+		exprStmt(call(member(regExp("a", ""), ident("test")), ident("regexp"))),
+	)
+
+	code := compile(t, f, CompileOptions{})
+	for _, want := range []string{
+		"var regexp_ Object",
+		`RegExp_Test(regexp.MustCompile("a"), regexp_)`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_RegExpUnsupported(t *testing.T) {
+	tests := []struct {
+		r    *ast.RegExpLiteral
+		want string
+	}{
+		{regExp(`a(?=b)`, ""), "regular expression /a(?=b)/: error parsing regexp"},
+		{regExp("a", "y"), "regular expression /a/y: flag y is not supported"},
+	}
+
+	for _, test := range tests {
+		_, err := Compile(file(exprStmt(test.r)), CompileOptions{})
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("want error %q, got %v", test.want, err)
+		}
+	}
+}
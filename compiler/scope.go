@@ -0,0 +1,79 @@
+package compiler
+
+// scope maps the JavaScript names declared in a lexical scope to the Go
+// identifiers they compile to.
+type scope struct {
+	parent *scope
+	names  map[string]*binding
+}
+
+type binding struct {
+	goName string
+	kind   string
+	// goType is the inferred Go type of the var, which is an Object when
+	// it's empty
+	goType string
+	// module is the imported module of an import binding
+	module *moduleImport
+	// enum maps the members of an enum const to the runtime values of their
+	// Go constants
+	enum map[string]string
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{
+		parent: parent,
+		names:  make(map[string]*binding),
+	}
+}
+
+func (s *scope) declare(name, goName, kind string) *binding {
+	b := &binding{goName: goName, kind: kind}
+	s.names[name] = b
+
+	return b
+}
+
+// lookup finds the binding of name in this scope or any enclosing one.
+func (s *scope) lookup(name string) *binding {
+	for ss := s; ss != nil; ss = ss.parent {
+		if b, ok := ss.names[name]; ok {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// isGoNameUsed tells whether a binding visible in this scope compiles to
+// goName.
+func (s *scope) isGoNameUsed(goName string) bool {
+	for ss := s; ss != nil; ss = ss.parent {
+		for _, b := range ss.names {
+			if b.goName == goName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// symbols maps the JavaScript names declared in this scope to their Go
+// identifiers, leaving out the bindings of generated helpers and of
+// re-exports, which declare no JavaScript names.
+func (s *scope) symbols() map[string]string {
+	symbols := make(map[string]string)
+	for name, b := range s.names {
+		if b.kind != "helper" && b.kind != "reexport" {
+			symbols[name] = b.goName
+		}
+	}
+
+	return symbols
+}
+
+// lookupLocal finds the binding of name in this scope only.
+func (s *scope) lookupLocal(name string) *binding {
+	return s.names[name]
+}
@@ -0,0 +1,72 @@
+package compiler
+
+import (
+	"github.com/jingweno/godzilla/ast"
+	"github.com/jingweno/godzilla/source"
+)
+
+// Session compiles a program one statement at a time, e.g. for a REPL.
+// Each statement compiles to a Go file of the same package which declares
+// the top-level variables and functions the statement introduces at package
+// level and runs it in an init function, so that later statements can
+// reference them. The files must be built in the order they're compiled in,
+// e.g. by naming them after their index. As Go imports are file-local, an
+// import declaration is only visible to its own statement.
+type Session struct {
+	opts   CompileOptions
+	module *scope
+	// compiled is the number of statements compiled
+	compiled int
+}
+
+// NewSession returns a session compiling statements with opts.
+func NewSession(opts CompileOptions) *Session {
+	return &Session{opts: opts, module: newScope(nil)}
+}
+
+// CompileStatement compiles the statement whose AST JSON is stmtJSON and
+// returns the Go source of its file. A statement which fails to compile
+// leaves the session as it was.
+func (s *Session) CompileStatement(stmtJSON []byte) (string, error) {
+	stmt, err := ast.UnmarshalStatement(stmtJSON)
+	if err != nil {
+		return "", err
+	}
+	s.opts.FeatureStats.add(stmt)
+	f := &ast.File{Program: &ast.Program{Body: []ast.Statement{stmt}}}
+	header, err := fileHeader(s.opts, f)
+	if err != nil {
+		return "", err
+	}
+	opts := s.opts
+	if opts.GoVersion, err = goVersion(opts); err != nil {
+		return "", err
+	}
+	if opts.Package, err = packageName(opts); err != nil {
+		return "", err
+	}
+
+	names := make(map[string]*binding, len(s.module.names))
+	for name, b := range s.module.names {
+		names[name] = b
+	}
+
+	c := newCompiler(source.NewInitCode(), s.module, opts)
+	c.code.SetHeader(header)
+	c.pkgLevel = true
+	if s.compiled == 0 {
+		c.writeGlobal()
+	}
+
+	if err = c.declareTopLevel(f.Program); err == nil {
+		err = c.compile(f)
+	}
+	if err != nil {
+		s.module.names = names
+		return "", err
+	}
+
+	s.compiled++
+
+	return c.code.String(), nil
+}
@@ -0,0 +1,46 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	id := func(name string) string {
+		return fmt.Sprintf(`{"type":"Identifier",%s,"name":%q}`, loc, name)
+	}
+	// let x = 1
+	letX := fmt.Sprintf(`{"type":"VariableDeclaration",%s,"kind":"let","declarations":[{"type":"VariableDeclarator",%s,"id":%s,"init":{"type":"NumericLiteral",%s,"extra":{"rawValue":1,"raw":"1"},"value":1}}]}`, loc, loc, id("x"), loc)
+	// console.log(x)
+	logX := fmt.Sprintf(`{"type":"ExpressionStatement",%s,"expression":{"type":"CallExpression",%s,"callee":{"type":"MemberExpression",%s,"object":%s,"property":%s,"computed":false},"arguments":[%s]}}`, loc, loc, loc, id("console"), id("log"), id("x"))
+
+	s := NewSession(CompileOptions{})
+	first, err := s.CompileStatement([]byte(letX))
+	if err != nil {
+		t.Fatalf("compiling let x: %s", err)
+	}
+	for _, want := range []string{"var global = NewDefaultContext().Global\n", "var x Object\n", "func init() {"} {
+		if !strings.Contains(first, want) {
+			t.Fatalf("compiled let x doesn't contain %q:\n%s", want, first)
+		}
+	}
+
+	// redeclaring x fails and leaves the session as it was
+	if _, err := s.CompileStatement([]byte(letX)); err == nil {
+		t.Fatal("want an error redeclaring x")
+	}
+
+	second, err := s.CompileStatement([]byte(logX))
+	if err != nil {
+		t.Fatalf("compiling console.log(x): %s", err)
+	}
+	if want := "Console_Log([]Object{x})"; !strings.Contains(second, want) {
+		t.Fatalf("compiled console.log(x) doesn't contain %q:\n%s", want, second)
+	}
+	for _, unwanted := range []string{"var global", "var x Object", "func main"} {
+		if strings.Contains(second, unwanted) {
+			t.Fatalf("compiled console.log(x) redeclares %q:\n%s", unwanted, second)
+		}
+	}
+}
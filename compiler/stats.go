@@ -0,0 +1,63 @@
+package compiler
+
+import (
+	"sync"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// FeatureStats collects the counts of the node types and JavaScript
+// features of compilations, as ast.FeatureStats counts them. It's safe for
+// concurrent use.
+type FeatureStats struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// add adds the counts of node, unless s is nil
+func (s *FeatureStats) add(node ast.Node) {
+	if s == nil {
+		return
+	}
+
+	counts := ast.FeatureStats(node)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts == nil {
+		s.counts = make(map[string]int)
+	}
+	for name, n := range counts {
+		s.counts[name] += n
+	}
+}
+
+// Count returns the count of the node type or feature name, or 0 if s is
+// nil.
+func (s *FeatureStats) Count(name string) int {
+	if s == nil {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counts[name]
+}
+
+// Counts returns the counts of the node types and features used, by name,
+// or nil if s is nil.
+func (s *FeatureStats) Counts() map[string]int {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int, len(s.counts))
+	for name, n := range s.counts {
+		counts[name] = n
+	}
+
+	return counts
+}
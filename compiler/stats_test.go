@@ -0,0 +1,51 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_FeatureStats(t *testing.T) {
+	// a.js: console.log(o?.a, o?.b)
+	// b.js: class B extends A {}
+	a := file(exprStmt(call(member(ident("console"), ident("log")), optionalMember(ident("o"), ident("a"), true), optionalMember(ident("o"), ident("b"), true))))
+	cd := class("B")
+	cd.SuperClass = ident("A")
+	b := file(cd)
+
+	stats := &FeatureStats{}
+	if _, err := CompileProgram(map[string]*ast.File{"a.js": a}, CompileOptions{FeatureStats: stats}); err != nil {
+		t.Fatalf("error compiling: %s", err)
+	}
+	// files failing to compile are counted too
+	if _, err := Compile(b, CompileOptions{FeatureStats: stats}); err == nil {
+		t.Fatal("want an error compiling class inheritance")
+	}
+
+	want := map[string]int{
+		"File":                     2,
+		"CallExpression":           1,
+		"OptionalMemberExpression": 2,
+		"OptionalChaining":         2,
+		"ClassDeclaration":         1,
+	}
+	for name, n := range want {
+		if got := stats.Count(name); got != n {
+			t.Errorf("%s: want=%d got=%d", name, n, got)
+		}
+	}
+	if got := stats.Counts()["OptionalChaining"]; got != 2 {
+		t.Errorf("counts: want OptionalChaining=2 got=%d", got)
+	}
+}
+
+func TestFeatureStats_Nil(t *testing.T) {
+	var stats *FeatureStats
+	if got := stats.Count("CallExpression"); got != 0 {
+		t.Errorf("count: want=0 got=%d", got)
+	}
+	if got := stats.Counts(); got != nil {
+		t.Errorf("counts: want=nil got=%v", got)
+	}
+}
@@ -0,0 +1,84 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// testCall returns the description and the function of s when it's a call
+// of the undeclared test or it functions of Jest and Mocha, e.g.
+// `test("adds", () => { ... })`
+func (c *compiler) testCall(s ast.Statement) (string, ast.Expression, bool) {
+	es, ok := s.(*ast.ExpressionStatement)
+	if !ok {
+		return "", nil, false
+	}
+	ce, ok := es.Expression.(*ast.CallExpression)
+	if !ok || len(ce.Arguments) != 2 {
+		return "", nil, false
+	}
+	callee, ok := ce.Callee.(*ast.Identifier)
+	if !ok || (callee.Name != "test" && callee.Name != "it") || c.lookup(callee.Name) != nil {
+		return "", nil, false
+	}
+	desc, ok := ce.Arguments[0].(*ast.StringLiteral)
+	if !ok {
+		return "", nil, false
+	}
+	switch ce.Arguments[1].(type) {
+	case *ast.FunctionExpression, *ast.ArrowFunctionExpression:
+		return desc.Value, ce.Arguments[1], true
+	}
+
+	return "", nil, false
+}
+
+// compileTestFunc compiles s, when it's a top-level test call, to a Go test
+// function calling the test's function. It tells whether it did.
+func (c *compiler) compileTestFunc(s ast.Statement) bool {
+	if !c.testFuncs || !c.pkgLevel || c.scope != c.module {
+		return false
+	}
+	desc, fn, ok := c.testCall(s)
+	if !ok {
+		return false
+	}
+
+	name := testFuncName(desc)
+	for i := 2; c.testNames[name]; i++ {
+		name = fmt.Sprintf("%s_%d", testFuncName(desc), i)
+	}
+	c.testNames[name] = true
+
+	body := c.code.Capture(func() {
+		c.writeLineNo(s)
+		c.code.Write("Call(")
+		c.compileExpression(fn)
+		c.code.Write(", []Object{})")
+	})
+	c.code.Import(`"testing"`)
+	c.code.WriteDecl(fmt.Sprintf("func %s(_ *testing.T) {\n%s\n}", name, body))
+
+	return true
+}
+
+// testFuncName returns the name of the Go test function of a test
+// described by desc, its words capitalized and joined, e.g. "TestAddsTwo"
+// for "adds two". Characters which can't be in Go identifiers are dropped.
+func testFuncName(desc string) string {
+	var b strings.Builder
+	b.WriteString("Test")
+	for _, w := range strings.FieldsFunc(desc, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		r, n := utf8.DecodeRuneInString(w)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(w[n:])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package compiler
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_TestFuncs(t *testing.T) {
+	// function add(a, b) { return a + b }
+	// test("adds", () => { console.log(add(1, 2)) })
+	// it("adds", function() {})
+	// test("handles edge-cases", () => {})
+	arrow := func(body ...ast.Statement) *ast.ArrowFunctionExpression {
+		return &ast.ArrowFunctionExpression{Attr: attr("ArrowFunctionExpression"), Body: block(body...)}
+	}
+	f := file(
+		funcDecl("add", []string{"a", "b"}, ret(binary("+", ident("a"), ident("b")))),
+		exprStmt(call(ident("test"), str("adds"), arrow(exprStmt(call(member(ident("console"), ident("log")), call(ident("add"), num(1), num(2))))))),
+		exprStmt(call(ident("it"), str("adds"), &ast.FunctionExpression{Attr: attr("FunctionExpression"), Body: block()})),
+		exprStmt(call(ident("test"), str("handles edge-cases"), arrow())),
+	)
+
+	code := compile(t, f, CompileOptions{TestFuncs: true})
+	for _, want := range []string{
+		`"testing"`,
+		`func TestAdds(_ *testing.T) {
+// line 1: test("adds", () => {
+Call(NewFunction(func(args []Object) Object {
+// line 1: console.log(add(1, 2));
+Console_Log([]Object{Call(add, []Object{JSNumber(1), JSNumber(2)})})`,
+		"func TestAdds_2(_ *testing.T) {",
+		"func TestHandlesEdgeCases(_ *testing.T) {",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+	if _, err := format.Source([]byte(code)); err != nil {
+		t.Fatalf("compiled code isn't valid Go: %s\n%s", err, code)
+	}
+}
+
+func TestCompile_TestFuncsDeclared(t *testing.T) {
+	// function test(name, fn) {}
+	// test("adds", () => {})
+	f := file(
+		funcDecl("test", []string{"name", "fn"}),
+		exprStmt(call(ident("test"), str("adds"), &ast.ArrowFunctionExpression{Attr: attr("ArrowFunctionExpression"), Body: block()})),
+	)
+
+	// a test function of the program is called like any other
+	code := compile(t, f, CompileOptions{TestFuncs: true})
+	if strings.Contains(code, "func TestAdds") {
+		t.Fatalf("compiled code has a test function:\n%s", code)
+	}
+}
+
+func TestCompile_TestFuncsShadowingImport(t *testing.T) {
+	// let testing = 1
+	// test("reads", () => { console.log(testing) })
+	f := file(
+		varDecl("let", "testing", num(1)),
+		exprStmt(call(ident("test"), str("reads"), &ast.ArrowFunctionExpression{
+			Attr: attr("ArrowFunctionExpression"),
+			Body: block(exprStmt(call(member(ident("console"), ident("log")), ident("testing")))),
+		})),
+	)
+
+	code := compile(t, f, CompileOptions{TestFuncs: true})
+	for _, want := range []string{
+		"var testing_ Object",
+		"Console_Log([]Object{testing_})",
+		"func TestReads(_ *testing.T) {",
+	} {
+		if !strings.Contains(code, want) {
+			t.Fatalf("compiled code doesn't contain %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestCompile_TestFuncsRun(t *testing.T) {
+	// let n = 1
+	// test("adds one", () => { console.log(n + 1) })
+	f := file(
+		varDecl("let", "n", num(1)),
+		exprStmt(call(ident("test"), str("adds one"), &ast.ArrowFunctionExpression{
+			Attr: attr("ArrowFunctionExpression"),
+			Body: block(exprStmt(call(member(ident("console"), ident("log")), binary("+", ident("n"), num(1))))),
+		})),
+	)
+	code := compile(t, f, CompileOptions{TestFuncs: true})
+
+	out := goCommand(t, code, "main_test.go", "test", "-v")
+	if want := "=== RUN   TestAddsOne\n2\n"; !strings.Contains(out, want) {
+		t.Fatalf("go test output doesn't contain %q:\n%s", want, out)
+	}
+}
@@ -0,0 +1,32 @@
+package compiler
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// traceNode writes the trace line of a node about to be compiled, e.g.
+// "1:0: CallExpression"
+func (c *compiler) traceNode(node ast.Node) {
+	typ := reflect.TypeOf(node).Elem().Name()
+	if attr := node.GetAttr(); attr != nil && attr.Loc != nil && attr.Loc.Start != nil {
+		fmt.Fprintf(c.trace, "%d:%d: %s\n", attr.Loc.Start.Line, attr.Loc.Start.Column, typ)
+		return
+	}
+
+	fmt.Fprintln(c.trace, typ)
+}
+
+// traceDiagnostic writes the trace line of a diagnostic reported, e.g.
+// "1:0: warning: debugger statement is ignored", when the compilation is
+// traced
+func (c *compiler) traceDiagnostic(sev Severity, loc *ast.SourceLocation, msg string) {
+	if c.trace == nil {
+		return
+	}
+
+	d := &Diagnostic{Severity: sev, Loc: loc, Msg: msg}
+	fmt.Fprintln(c.trace, d.String())
+}
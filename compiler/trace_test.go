@@ -0,0 +1,41 @@
+package compiler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestCompile_Trace(t *testing.T) {
+	// console.log("hi")
+	// debugger
+	debugger := &ast.DebuggerStatement{Attr: attr("DebuggerStatement")}
+	f := file(exprStmt(call(member(ident("console"), ident("log")), str("hi"))), debugger)
+
+	var trace bytes.Buffer
+	compile(t, f, CompileOptions{Trace: &trace})
+
+	want := `1:0: ExpressionStatement
+1:0: CallExpression
+1:0: MemberExpression
+1:0: StringLiteral
+1:0: DebuggerStatement
+1:0: warning: debugger statement is ignored
+`
+	if got := trace.String(); got != want {
+		t.Fatalf("want trace:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestCompile_TraceError(t *testing.T) {
+	var trace bytes.Buffer
+	if _, err := Compile(file(exprStmt(sequence(ident("a"), ident("b")))), CompileOptions{Trace: &trace}); err == nil {
+		t.Fatal("want an error compiling a sequence expression")
+	}
+
+	if want := "1:0: error: sequence expression is not supported\n"; !strings.HasSuffix(trace.String(), want) {
+		t.Fatalf("trace doesn't end with %q:\n%s", want, trace.String())
+	}
+}
@@ -0,0 +1,124 @@
+package compiler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// goVersion returns the GoVersion of opts prefixed with "go", e.g. "go1.21"
+// for either "1.21" or "go1.21", or "" when it isn't set
+func goVersion(opts CompileOptions) (string, error) {
+	if opts.GoVersion == "" {
+		return "", nil
+	}
+
+	v := opts.GoVersion
+	if !strings.HasPrefix(v, "go") {
+		v = "go" + v
+	}
+	if parseGoVersion(v) == nil {
+		return "", fmt.Errorf("invalid Go version %q", opts.GoVersion)
+	}
+
+	return v, nil
+}
+
+// parseGoVersion returns the numbers of the Go version v, e.g. [1 21 3] for
+// "go1.21.3", or nil when it isn't valid. The prerelease of versions like
+// "go1.22rc1" is dropped, as they have the features of their release.
+func parseGoVersion(v string) []int {
+	if !strings.HasPrefix(v, "go") {
+		return nil
+	}
+	parts := strings.Split(v[len("go"):], ".")
+	if len(parts) > 3 {
+		return nil
+	}
+	if len(parts) == 2 {
+		for _, pre := range []string{"rc", "beta"} {
+			if i := strings.Index(parts[1], pre); i > 0 && isDigits(parts[1][i+len(pre):]) {
+				parts[1] = parts[1][:i]
+			}
+		}
+	}
+
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || !isDigits(part) || (len(part) > 1 && part[0] == '0') {
+			return nil
+		}
+		nums[i] = n
+	}
+	if nums[0] < 1 {
+		return nil
+	}
+
+	return nums
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// targets tells whether the generated code can use the features of Go
+// version v, which it can't when no Go version is targeted
+func (c *compiler) targets(v string) bool {
+	if c.goVersion == "" {
+		return false
+	}
+
+	have, want := parseGoVersion(c.goVersion), parseGoVersion(v)
+	for i := range want {
+		n := 0
+		if i < len(have) {
+			n = have[i]
+		}
+		if n != want[i] {
+			return n > want[i]
+		}
+	}
+
+	return true
+}
+
+// mathBuiltins are the Go builtins of the Math functions, available since
+// Go 1.21
+var mathBuiltins = map[string]string{
+	"Math_Max": "max",
+	"Math_Min": "min",
+}
+
+// compileMathBuiltin compiles a call of Math.max or Math.min with arguments
+// to a call of the Go builtin when the targeted Go version has it, e.g.
+// `JSNumber(max(float64(ToNumber(a)), 1.0))` for `Math.max(a, 1)`, unless
+// they spread values. It tells whether it did.
+func (c *compiler) compileMathBuiltin(ce *ast.CallExpression) bool {
+	me, ok := ce.Callee.(*ast.MemberExpression)
+	if !ok || me.Computed || len(ce.Arguments) == 0 || hasSpread(ce.Arguments) || !c.targets("go1.21") {
+		return false
+	}
+	fn, ok := mathBuiltins[c.getBuiltinFunc(me.Object, me.Property)]
+	if !ok {
+		return false
+	}
+	c.code.Write(fmt.Sprintf("JSNumber(%s(", fn))
+	for i, arg := range ce.Arguments {
+		c.compileTypedValue("float64", arg)
+		if i != len(ce.Arguments)-1 {
+			c.code.Write(", ")
+		}
+	}
+	c.code.Write("))")
+
+	return true
+}
@@ -0,0 +1,52 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompile_GoVersion(t *testing.T) {
+	// let a = 2
+	// console.log(Math.max(a, 1), Math.min())
+	f := file(
+		varDecl("let", "a", num(2)),
+		exprStmt(call(member(ident("console"), ident("log")),
+			call(member(ident("Math"), ident("max")), ident("a"), num(1)),
+			call(member(ident("Math"), ident("min"))))),
+	)
+
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"", `Math_Max([]Object{a, JSNumber(1)})`},
+		{"1.9", `Math_Max([]Object{a, JSNumber(1)})`},
+		{"1.20", `Math_Max([]Object{a, JSNumber(1)})`},
+		{"go1.20.14", `Math_Max([]Object{a, JSNumber(1)})`},
+		{"1.21", `JSNumber(max(float64(ToNumber(a)), 1.0))`},
+		{"go1.22.3", `JSNumber(max(float64(ToNumber(a)), 1.0))`},
+		{"1.21rc1", `JSNumber(max(float64(ToNumber(a)), 1.0))`},
+		{"2", `JSNumber(max(float64(ToNumber(a)), 1.0))`},
+	}
+
+	for _, test := range tests {
+		code := compile(t, f, CompileOptions{GoVersion: test.version})
+		if !strings.Contains(code, test.want) {
+			t.Errorf("%q: compiled code doesn't contain %q:\n%s", test.version, test.want, code)
+		}
+		// the builtins take at least one argument
+		if want := `Math_Min([]Object{})`; !strings.Contains(code, want) {
+			t.Errorf("%q: compiled code doesn't contain %q:\n%s", test.version, want, code)
+		}
+	}
+}
+
+func TestCompile_InvalidGoVersion(t *testing.T) {
+	for _, v := range []string{"1.x", "0.1", "1.21.", "1.021", "1.2.3.4", "1.21.3rc1", "go"} {
+		_, err := Compile(file(), CompileOptions{GoVersion: v})
+		if want := fmt.Sprintf("invalid Go version %q", v); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: want invalid Go version error, got %v", v, err)
+		}
+	}
+}
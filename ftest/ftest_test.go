@@ -44,6 +44,66 @@ func TestAll(t *testing.T) {
 			input:  "console.log(1 + 1)",
 			output: "2\n",
 		},
+		{
+			name:   "division by zero",
+			input:  "console.log(1 / 0, -1 / 0, 0 / 0, -7 % 3)",
+			output: "Infinity -Infinity NaN -1\n",
+		},
+		{
+			name:   "post-increment index",
+			input:  "let a = ['x', 'y']\nlet i = 0\nconsole.log(a[i++])\nconsole.log(a[i++], i)",
+			output: "x\ny 2\n",
+		},
+		{
+			name:   "update read in order",
+			input:  "let a = [10, 20, 30]\nlet i = 0\nconsole.log(a[i++], i, ++i)",
+			output: "10 1 2\n",
+		},
+		{
+			name:   "hoisted function assigning a let",
+			input:  "let n = 1\nfunction inc() { n += 1 }\ninc()\nconsole.log(n)",
+			output: "2\n",
+		},
+		{
+			name:   "hoisted function reading a reassigned let",
+			input:  "function f() { return x }\nlet x = 1\nx = 2\nconsole.log(f())",
+			output: "2\n",
+		},
+		{
+			name:   "nested hoisted function assigning a let",
+			input:  "function outer() {\n  let n = 1\n  function inc() { n += 1 }\n  inc()\n  return n\n}\nconsole.log(outer())",
+			output: "2\n",
+		},
+		{
+			name:   "nested hoisted function reading a reassigned let",
+			input:  "function outer() {\n  function f() { return x }\n  let x = 1\n  x = 2\n  return f()\n}\nconsole.log(outer())",
+			output: "2\n",
+		},
+		{
+			name:   "sparse array keys",
+			input:  "let a = [1, , 3]\nlet b = [...a, , 5]\nconsole.log(Object.keys(a).join(','), Object.keys(b).join(','))",
+			output: "0,2 0,1,2,4\n",
+		},
+		{
+			name:   "let loop variable capture",
+			input:  "let fns = []\nfor (let i = 0; i < 3; i++) { fns[fns.length] = () => i }\nconsole.log(fns.map(f => f()).join(','))",
+			output: "0,1,2\n",
+		},
+		{
+			name:   "var loop variable capture",
+			input:  "let fns = []\nfor (var i = 0; i < 3; i++) { fns[fns.length] = () => i }\nconsole.log(fns.map(f => f()).join(','))",
+			output: "3,3,3\n",
+		},
+		{
+			name:   "nested destructuring declaration",
+			input:  "const obj = {a: [1, 2]}\nconst {a: [b, c]} = obj\nconsole.log(b, c)",
+			output: "1 2\n",
+		},
+		{
+			name:   "nested destructuring parameter",
+			input:  "function f({a: [x, y = 5]}) { return x + y }\nconsole.log(f({a: [1]}), f({a: [1, 2]}))",
+			output: "6 3\n",
+		},
 	}
 
 	bin := filepath.Join(pwd, "..", "bin", "godzilla")
@@ -57,7 +117,7 @@ func TestAll(t *testing.T) {
 			cmd.Stdout = &out
 			cmd.Stderr = &out
 			if err := cmd.Run(); err != nil {
-				t.Fatalf("error running test case %s error=%s stderr=%s", test.name, err, out)
+				t.Fatalf("error running test case %s error=%s stderr=%s", test.name, err, out.String())
 			}
 
 			if want, got := test.output, out.String(); want != got {
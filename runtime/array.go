@@ -0,0 +1,208 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// JSArray is a JavaScript array.
+type JSArray struct {
+	elements []Object
+	// holes are the indices of the missing elements, which are nil in
+	// elements like undefined ones
+	holes map[int]bool
+}
+
+// NewArray returns an array of elements.
+func NewArray(elements []Object) *JSArray {
+	return &JSArray{elements: elements}
+}
+
+// NewArrayWithHoles returns an array of elements missing the ones at the
+// indices holes, as in `[1, , 3]`.
+func NewArrayWithHoles(elements []Object, holes ...int) *JSArray {
+	a := NewArray(elements)
+	for _, i := range holes {
+		a.hole(i)
+	}
+
+	return a
+}
+
+// ToArray returns o as an array, throwing a TypeError when it isn't one.
+func ToArray(o Object) *JSArray {
+	a, ok := o.(*JSArray)
+	if !ok {
+		panic(&TypeError{fmt.Sprintf("%s is not an array", ToString(o))})
+	}
+
+	return a
+}
+
+// SpreadIterable appends the values of iterable to elements, like
+// `[...iterable]` does. Arrays spread their elements and strings their code
+// points, other values aren't iterable.
+func SpreadIterable(elements []Object, iterable Object) []Object {
+	switch v := iterable.(type) {
+	case *JSArray:
+		return append(elements, v.elements...)
+	case JSString:
+		for _, r := range string(v) {
+			elements = append(elements, JSString(string(r)))
+		}
+		return elements
+	}
+
+	panic(&TypeError{fmt.Sprintf("%s is not iterable", ToString(iterable))})
+}
+
+// Iterate returns the values of iterable a for...of loop iterates over, the
+// ones SpreadIterable spreads.
+func Iterate(iterable Object) []Object {
+	return SpreadIterable(nil, iterable)
+}
+
+func (self *JSArray) Type() JSObjectType { return JS_OBJECT_TYPE_OBJECT }
+
+// Elements returns the elements of the array.
+func (self *JSArray) Elements() []Object {
+	return self.elements
+}
+
+// Get returns the value of prop, which is an element for array indices.
+func (self *JSArray) Get(prop string) Object {
+	if prop == "length" {
+		return JSNumber(len(self.elements))
+	}
+
+	if i, err := strconv.Atoi(prop); err == nil && strconv.Itoa(i) == prop {
+		if i >= 0 && i < len(self.elements) {
+			return self.elements[i]
+		}
+		return nil
+	}
+
+	if m, ok := arrayMethods[prop]; ok {
+		return NewFunction(func(args []Object) Object {
+			return m(self, args)
+		})
+	}
+
+	return nil
+}
+
+// element returns the element at index i, undefined past the end of the
+// array
+func (self *JSArray) element(i int) Object {
+	if i < len(self.elements) {
+		return self.elements[i]
+	}
+
+	return nil
+}
+
+// arrayIndex returns the array index key is when it's a number which is
+// one: a non-negative integer
+func arrayIndex(key Object) (int, bool) {
+	n, ok := key.(JSNumber)
+	if !ok || n < 0 || n > math.MaxInt32 || JSNumber(int(n)) != n {
+		return 0, false
+	}
+
+	return int(n), true
+}
+
+// Has tells whether the array has prop: its length, an index within it or
+// a method.
+func (self *JSArray) Has(prop string) bool {
+	if prop == "length" {
+		return true
+	}
+
+	if i, err := strconv.Atoi(prop); err == nil && strconv.Itoa(i) == prop {
+		return i >= 0 && i < len(self.elements) && !self.holes[i]
+	}
+
+	_, ok := arrayMethods[prop]
+	return ok
+}
+
+// Set sets prop to value, growing the array with holes when it's an index
+// past its end. Other properties of arrays aren't supported.
+func (self *JSArray) Set(prop string, value Object) {
+	i, err := strconv.Atoi(prop)
+	if err != nil || i < 0 || strconv.Itoa(i) != prop {
+		return
+	}
+
+	for len(self.elements) <= i {
+		self.elements = append(self.elements, nil)
+		self.hole(len(self.elements) - 1)
+	}
+	self.elements[i] = value
+	delete(self.holes, i)
+}
+
+// Delete leaves a hole at prop when it's an index within the array, which
+// keeps its length. Other properties of arrays aren't supported.
+func (self *JSArray) Delete(prop string) {
+	if i, err := strconv.Atoi(prop); err == nil && strconv.Itoa(i) == prop && i >= 0 && i < len(self.elements) {
+		self.hole(i)
+	}
+}
+
+// hole makes the element at index i, which is within the array, missing
+func (self *JSArray) hole(i int) {
+	self.elements[i] = nil
+	if self.holes == nil {
+		self.holes = make(map[int]bool)
+	}
+	self.holes[i] = true
+}
+
+// arrayMethods are the methods of Array.prototype
+var arrayMethods = map[string]func(a *JSArray, args []Object) Object{
+	"filter": arrayFilter,
+	"join":   arrayJoin,
+	"map":    arrayMap,
+}
+
+func arrayMap(a *JSArray, args []Object) Object {
+	fn := Arg(args, 0)
+	result := make([]Object, len(a.elements))
+	for i, e := range a.elements {
+		result[i] = Call(fn, []Object{e, JSNumber(i), a})
+	}
+
+	return NewArray(result)
+}
+
+func arrayFilter(a *JSArray, args []Object) Object {
+	fn := Arg(args, 0)
+	result := []Object{}
+	for i, e := range a.elements {
+		if Truthy(Call(fn, []Object{e, JSNumber(i), a})) {
+			result = append(result, e)
+		}
+	}
+
+	return NewArray(result)
+}
+
+func arrayJoin(a *JSArray, args []Object) Object {
+	sep := ","
+	if s := Arg(args, 0); s != nil {
+		sep = string(ToString(s))
+	}
+
+	strs := make([]string, len(a.elements))
+	for i, e := range a.elements {
+		if !IsNullish(e) {
+			strs[i] = string(ToString(e))
+		}
+	}
+
+	return JSString(strings.Join(strs, sep))
+}
@@ -0,0 +1,97 @@
+package runtime
+
+import "testing"
+
+func TestArray_Get(t *testing.T) {
+	a := NewArray([]Object{JSString("a"), JSString("b")})
+	tests := []struct {
+		key  Object
+		want Object
+	}{
+		{JSNumber(0), JSString("a")},
+		{JSString("1"), JSString("b")},
+		{JSNumber(2), nil},
+		{JSString("01"), nil},
+		{JSString("length"), JSNumber(2)},
+	}
+
+	for _, test := range tests {
+		if got := Get(a, test.key); got != test.want {
+			t.Errorf("a[%v]: want=%v got=%v", ToString(test.key), ToString(test.want), ToString(got))
+		}
+	}
+}
+
+func TestArray_Set(t *testing.T) {
+	a := NewArray([]Object{JSNumber(1)})
+	Set(a, JSNumber(2), JSNumber(3))
+
+	if got := ToString(a); got != "1,,3" {
+		t.Errorf("a: want=1,,3 got=%v", got)
+	}
+	if got := ToString(Object_Keys([]Object{a})); got != "0,2" {
+		t.Errorf("Object.keys(a): want=0,2 got=%v", got)
+	}
+
+	Set(a, JSNumber(1), nil)
+	Delete(a, JSNumber(0))
+	if got := ToString(Object_Keys([]Object{a})); got != "1,2" {
+		t.Errorf("Object.keys(a) after a[1] = undefined and delete a[0]: want=1,2 got=%v", got)
+	}
+}
+
+func TestArray_MapFilter(t *testing.T) {
+	double := NewFunction(func(args []Object) Object {
+		return Mul(Arg(args, 0), JSNumber(2))
+	})
+	big := NewFunction(func(args []Object) Object {
+		return Greater(Arg(args, 0), JSNumber(2))
+	})
+
+	a := NewArray([]Object{JSNumber(1), JSNumber(2), JSNumber(3)})
+	got := Call(Get(Call(Get(a, JSString("map")), []Object{double}), JSString("filter")), []Object{big})
+	if s := ToString(got); s != "4,6" {
+		t.Errorf("a.map(double).filter(big): want=4,6 got=%v", s)
+	}
+	if s := ToString(a); s != "1,2,3" {
+		t.Errorf("a after map: want=1,2,3 got=%v", s)
+	}
+}
+
+func TestToArray_NotAnArray(t *testing.T) {
+	defer func() {
+		err, ok := recover().(*TypeError)
+		if !ok {
+			t.Fatalf("want a TypeError")
+		}
+		if want := "TypeError: null is not an array"; err.Error() != want {
+			t.Errorf("want=%s got=%s", want, err)
+		}
+	}()
+
+	ToArray(Null)
+}
+
+func TestSpreadIterable(t *testing.T) {
+	// [0, ..."a😀", ...[1, 2]]
+	elements := SpreadIterable([]Object{JSNumber(0)}, JSString("a😀"))
+	elements = SpreadIterable(elements, NewArray([]Object{JSNumber(1), JSNumber(2)}))
+
+	if got, want := ToString(NewArray(elements)), "0,a,😀,1,2"; got != JSString(want) {
+		t.Errorf("want=%s got=%s", want, got)
+	}
+}
+
+func TestSpreadIterable_NotIterable(t *testing.T) {
+	defer func() {
+		err, ok := recover().(*TypeError)
+		if !ok {
+			t.Fatalf("want a TypeError")
+		}
+		if want := "TypeError: [object Object] is not iterable"; err.Error() != want {
+			t.Errorf("want=%s got=%s", want, err)
+		}
+	}()
+
+	SpreadIterable(nil, NewObject())
+}
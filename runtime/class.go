@@ -0,0 +1,38 @@
+package runtime
+
+import "fmt"
+
+// JSClass is a class constructor, whose properties are the static fields of
+// the class.
+type JSClass struct {
+	JSObject
+	name string
+	init func(this *JSObject)
+}
+
+// NewClass returns a class whose instances are initialized by init, which
+// defines their fields.
+func NewClass(name string, init func(this *JSObject)) *JSClass {
+	return &JSClass{JSObject: JSObject{properties: make(map[string]Object)}, name: name, init: init}
+}
+
+func (self *JSClass) Type() JSObjectType { return JS_OBJECT_TYPE_FUNCTION }
+
+// New constructs an object from callee like `new callee(...args)` does. A
+// function which doesn't return an object constructs an empty object.
+func New(callee Object, args []Object) Object {
+	switch v := callee.(type) {
+	case *JSClass:
+		this := NewObject()
+		v.init(this)
+		return this
+	case *JSFunction:
+		switch r := v.Call(args).(type) {
+		case *JSObject, *JSArray, *JSFunction, *JSClass:
+			return r
+		}
+		return NewObject()
+	}
+
+	panic(&TypeError{fmt.Sprintf("%s is not a constructor", ToString(callee))})
+}
@@ -0,0 +1,52 @@
+package runtime
+
+import "testing"
+
+func TestNew_Class(t *testing.T) {
+	c := NewClass("Point", func(this *JSObject) {
+		Set(this, JSString("x"), JSNumber(1))
+	})
+	Set(c, JSString("origin"), JSNumber(0))
+
+	p := New(c, nil)
+	if got := Get(p, JSString("x")); got != JSNumber(1) {
+		t.Errorf("p.x: want=1 got=%v", got)
+	}
+	if got := Get(c, JSString("origin")); got != JSNumber(0) {
+		t.Errorf("Point.origin: want=0 got=%v", got)
+	}
+	if New(c, nil) == p {
+		t.Errorf("new Point() should construct a new object")
+	}
+	if got := c.Type(); got != JS_OBJECT_TYPE_FUNCTION {
+		t.Errorf("typeof Point: want=function got=%v", got)
+	}
+}
+
+func TestNew_NotAConstructor(t *testing.T) {
+	defer func() {
+		err, ok := recover().(*TypeError)
+		if !ok {
+			t.Fatalf("want a TypeError")
+		}
+		if want := "TypeError: 5 is not a constructor"; err.Error() != want {
+			t.Errorf("want=%s got=%s", want, err)
+		}
+	}()
+
+	New(JSNumber(5), nil)
+}
+
+func TestCall_Class(t *testing.T) {
+	defer func() {
+		err, ok := recover().(*TypeError)
+		if !ok {
+			t.Fatalf("want a TypeError")
+		}
+		if want := "TypeError: Class constructor Point cannot be invoked without 'new'"; err.Error() != want {
+			t.Errorf("want=%s got=%s", want, err)
+		}
+	}()
+
+	Call(NewClass("Point", func(*JSObject) {}), nil)
+}
@@ -1,13 +1,19 @@
 package runtime
 
 func NewDefaultContext() *Context {
-	return &Context{
-		Global: &JSObject{
-			properties: map[string]Object{
-				"console": console,
-			},
+	global := &JSObject{
+		properties: map[string]Object{
+			"console": console,
+			"Math":    mathObject,
+			"Array":   arrayObject,
+			"Object":  objectObject,
 		},
+		keys: []string{"console", "Math", "Array", "Object"},
 	}
+	// the global object is a property of itself
+	global.DefineProperty("globalThis", global)
+
+	return &Context{Global: global}
 }
 
 type Context struct {
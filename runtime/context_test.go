@@ -0,0 +1,16 @@
+package runtime
+
+import "testing"
+
+func TestNewDefaultContext_GlobalThis(t *testing.T) {
+	global := NewDefaultContext().Global
+	if got := Get(global, JSString("globalThis")); got != global {
+		t.Errorf("globalThis: want the global object got=%v", got)
+	}
+	if got := Get(global, JSString("window")); got != nil {
+		t.Errorf("window: want undefined got=%v", got)
+	}
+	if got := Get(Get(global, JSString("globalThis")), JSString("console")); got != console {
+		t.Errorf("globalThis.console: want console got=%v", got)
+	}
+}
@@ -9,3 +9,11 @@ type ReferenceError struct {
 func (self *ReferenceError) Error() string {
 	return fmt.Sprintf("ReferenceError: %s is not defined", self.ref)
 }
+
+type TypeError struct {
+	msg string
+}
+
+func (self *TypeError) Error() string {
+	return fmt.Sprintf("TypeError: %s", self.msg)
+}
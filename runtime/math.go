@@ -0,0 +1,48 @@
+package runtime
+
+import "math"
+
+var (
+	mathObject = &JSObject{
+		properties: map[string]Object{
+			"max": &JSFunction{
+				fn: Math_Max,
+			},
+			"min": &JSFunction{
+				fn: Math_Min,
+			},
+		},
+		keys: []string{"max", "min"},
+	}
+)
+
+// Math_Max returns the largest of the numbers of args, -Infinity when there
+// are none and NaN when any of them is NaN. +0 is larger than -0.
+func Math_Max(args []Object) Object {
+	return mathReduce(args, math.Inf(-1), func(x, y float64) bool {
+		return x > y || x == y && math.Signbit(y)
+	})
+}
+
+// Math_Min returns the smallest of the numbers of args, Infinity when there
+// are none and NaN when any of them is NaN. -0 is smaller than +0.
+func Math_Min(args []Object) Object {
+	return mathReduce(args, math.Inf(1), func(x, y float64) bool {
+		return x < y || x == y && math.Signbit(x)
+	})
+}
+
+// mathReduce returns the number of args which is preferred to all the
+// others, every one of them being converted to a number
+func mathReduce(args []Object, r float64, prefer func(x, y float64) bool) Object {
+	for _, a := range args {
+		n := float64(ToNumber(a))
+		if math.IsNaN(n) || math.IsNaN(r) {
+			r = math.NaN()
+		} else if prefer(n, r) {
+			r = n
+		}
+	}
+
+	return JSNumber(r)
+}
@@ -0,0 +1,42 @@
+package runtime
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMath_MaxMin(t *testing.T) {
+	tests := []struct {
+		args     []Object
+		max, min float64
+	}{
+		{nil, math.Inf(-1), math.Inf(1)},
+		{[]Object{JSNumber(1), JSString("3"), JSBoolean(true)}, 3, 1},
+		{[]Object{JSNumber(-1)}, -1, -1},
+	}
+
+	for _, test := range tests {
+		if got := Math_Max(test.args); got != JSNumber(test.max) {
+			t.Errorf("Math_Max(%v): want=%v got=%v", test.args, test.max, got)
+		}
+		if got := Math_Min(test.args); got != JSNumber(test.min) {
+			t.Errorf("Math_Min(%v): want=%v got=%v", test.args, test.min, got)
+		}
+	}
+
+	nan := []Object{JSNumber(1), nil, JSNumber(2)}
+	if got := Math_Max(nan); !math.IsNaN(float64(got.(JSNumber))) {
+		t.Errorf("Math_Max(%v): want=NaN got=%v", nan, got)
+	}
+	if got := Math_Min(nan); !math.IsNaN(float64(got.(JSNumber))) {
+		t.Errorf("Math_Min(%v): want=NaN got=%v", nan, got)
+	}
+
+	zeros := []Object{JSNumber(math.Copysign(0, -1)), JSNumber(0)}
+	if got := Math_Max(zeros); math.Signbit(float64(got.(JSNumber))) {
+		t.Errorf("Math_Max(-0, 0): want=0 got=-0")
+	}
+	if got := Math_Min(zeros); !math.Signbit(float64(got.(JSNumber))) {
+		t.Errorf("Math_Min(-0, 0): want=-0 got=0")
+	}
+}
@@ -0,0 +1,153 @@
+package runtime
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf16"
+)
+
+// IsNullish tells whether o is null or undefined.
+func IsNullish(o Object) bool {
+	return o == nil || o == Null
+}
+
+// Get returns the value of the key property of obj, like obj[key] does.
+// Keys are converted to strings, e.g. obj[0] reads the "0" property of
+// objects, but arrays are indexed by number keys directly. Reading a
+// property of null or undefined is a TypeError naming the key.
+func Get(obj Object, key Object) Object {
+	if a, ok := obj.(*JSArray); ok {
+		if i, ok := arrayIndex(key); ok {
+			return a.element(i)
+		}
+	}
+
+	prop := ToString(key)
+
+	switch v := obj.(type) {
+	case nil, JSNull:
+		panic(&TypeError{fmt.Sprintf("Cannot read properties of %s (reading '%s')", ToString(obj), prop)})
+	case *JSObject:
+		return v.Get(string(prop))
+	case *JSClass:
+		return v.Get(string(prop))
+	case *JSArray:
+		return v.Get(string(prop))
+	case *JSRegExp:
+		return v.Get(string(prop))
+	case JSString:
+		if prop == "length" {
+			return JSNumber(len(utf16.Encode([]rune(string(v)))))
+		}
+		if i, err := strconv.Atoi(string(prop)); err == nil && strconv.Itoa(i) == string(prop) {
+			return charAt(v, i)
+		}
+		return stringMethod(v, string(prop))
+	}
+
+	return nil
+}
+
+// Delete removes the key property of obj, like `delete obj[key]` does, and
+// returns true. Deleting an element of an array leaves a hole rather than
+// removing it. The properties of frozen objects are kept, which returns
+// false.
+func Delete(obj Object, key Object) Object {
+	prop := string(ToString(key))
+
+	switch v := obj.(type) {
+	case nil, JSNull:
+		panic(&TypeError{fmt.Sprintf("Cannot convert %s to object", ToString(obj))})
+	case *JSObject:
+		if v.frozen {
+			return JSBoolean(!v.Has(prop))
+		}
+		v.DeleteProperty(prop)
+	case *JSClass:
+		v.DeleteProperty(prop)
+	case *JSArray:
+		v.Delete(prop)
+	}
+
+	return JSBoolean(true)
+}
+
+// In tells whether obj has the key property, like `key in obj` does.
+// Searching a primitive is a TypeError.
+func In(key Object, obj Object) Object {
+	prop := string(ToString(key))
+
+	switch v := obj.(type) {
+	case *JSObject:
+		return JSBoolean(v.Has(prop))
+	case *JSClass:
+		return JSBoolean(v.Has(prop))
+	case *JSArray:
+		return JSBoolean(v.Has(prop))
+	case *JSFunction:
+		return JSBoolean(false)
+	}
+
+	panic(&TypeError{fmt.Sprintf("Cannot use 'in' operator to search for '%s' in %s", prop, ToString(obj))})
+}
+
+// GetOptional returns the value of the key property of obj like obj?.[key]
+// does, which is undefined when obj is nullish.
+func GetOptional(obj Object, key Object) Object {
+	if IsNullish(obj) {
+		return nil
+	}
+
+	return Get(obj, key)
+}
+
+// SpreadObject copies the properties of source to target, like
+// `{...source}` does. Arrays and strings spread their indices, other
+// primitives and nullish sources have no properties to copy.
+func SpreadObject(target *JSObject, source Object) {
+	switch v := source.(type) {
+	case *JSObject:
+		for _, k := range v.keys {
+			target.DefineProperty(k, v.Get(k))
+		}
+	case *JSArray:
+		for i, e := range v.elements {
+			target.DefineProperty(strconv.Itoa(i), e)
+		}
+	case JSString:
+		for i, u := range utf16.Encode([]rune(string(v))) {
+			target.DefineProperty(strconv.Itoa(i), JSString(utf16.Decode([]uint16{u})))
+		}
+	}
+}
+
+// DefineGetter defines the key property of obj as an accessor property
+// whose value get computes on every access, like `get key() {...}` in an
+// object literal does.
+func DefineGetter(obj *JSObject, key Object, get *JSFunction) {
+	obj.DefineGetter(string(ToString(key)), get)
+}
+
+// Set sets the key property of obj to value, like obj[key] = value does, and
+// returns value. Setting the properties of frozen objects is ignored.
+func Set(obj Object, key Object, value Object) Object {
+	prop := ToString(key)
+
+	switch v := obj.(type) {
+	case nil, JSNull:
+		panic(&TypeError{fmt.Sprintf("Cannot set property '%s' of %s", prop, ToString(obj))})
+	case *JSObject:
+		if v.HasGetter(string(prop)) {
+			panic(&TypeError{fmt.Sprintf("Cannot set property %s of [object Object] which has only a getter", prop)})
+		}
+		if !v.frozen {
+			v.DefineProperty(string(prop), value)
+		}
+	case *JSClass:
+		v.DefineProperty(string(prop), value)
+	case *JSArray:
+		v.Set(string(prop), value)
+	}
+
+	return value
+}
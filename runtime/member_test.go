@@ -0,0 +1,258 @@
+package runtime
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	obj := &JSObject{properties: map[string]Object{"a": JSNumber(1)}}
+	if got := Get(obj, JSString("a")); got != JSNumber(1) {
+		t.Errorf("obj.a: want=1 got=%v", got)
+	}
+	if got := Get(obj, JSString("b")); got != nil {
+		t.Errorf("obj.b: want=undefined got=%v", got)
+	}
+	if got := Get(JSString("héllo"), JSString("length")); got != JSNumber(5) {
+		t.Errorf(`"héllo".length: want=5 got=%v`, got)
+	}
+}
+
+func TestGet_NumericKeys(t *testing.T) {
+	// obj[0] reads the "0" property of an object
+	obj := NewObject()
+	obj.DefineProperty("0", JSString("zero"))
+	obj.DefineProperty("1.5", JSString("one and a half"))
+	arr := NewArray([]Object{JSString("a"), JSString("b")})
+
+	tests := []struct {
+		obj  Object
+		key  Object
+		want Object
+	}{
+		{obj, JSNumber(0), JSString("zero")},
+		{obj, JSString("0"), JSString("zero")},
+		{obj, JSNumber(1.5), JSString("one and a half")},
+		{arr, JSNumber(1), JSString("b")},
+		{arr, JSString("1"), JSString("b")},
+		{arr, JSNumber(math.Copysign(0, -1)), JSString("a")},
+		{arr, JSNumber(2), nil},
+		{arr, JSNumber(0.5), nil},
+		{arr, JSNumber(-1), nil},
+	}
+	for _, test := range tests {
+		if got := Get(test.obj, test.key); got != test.want {
+			t.Errorf("%v[%v]: want=%v got=%v", ToString(test.obj), ToString(test.key), test.want, got)
+		}
+	}
+}
+
+func TestGet_Nullish(t *testing.T) {
+	tests := []struct {
+		obj  Object
+		want string
+	}{
+		{nil, "TypeError: Cannot read properties of undefined (reading 'a')"},
+		{Null, "TypeError: Cannot read properties of null (reading 'a')"},
+	}
+	for _, tt := range tests {
+		obj := tt.obj
+		func() {
+			defer func() {
+				err, ok := recover().(*TypeError)
+				if !ok {
+					t.Errorf("reading a property of %v: want a TypeError", ToString(obj))
+					return
+				}
+				if got := err.Error(); got != tt.want {
+					t.Errorf("reading a property of %v: want=%q got=%q", ToString(obj), tt.want, got)
+				}
+			}()
+
+			Get(obj, JSString("a"))
+		}()
+	}
+}
+
+func TestSet(t *testing.T) {
+	obj := &JSObject{properties: map[string]Object{}}
+	if got := Set(obj, JSNumber(1), JSString("a")); got != JSString("a") {
+		t.Errorf("obj[1] = 'a': want='a' got=%v", got)
+	}
+	if got := Get(obj, JSString("1")); got != JSString("a") {
+		t.Errorf("obj['1']: want='a' got=%v", got)
+	}
+
+	// properties of primitives are silently dropped
+	if got := Set(JSString("s"), JSString("a"), JSNumber(1)); got != JSNumber(1) {
+		t.Errorf("'s'.a = 1: want=1 got=%v", got)
+	}
+}
+
+func TestSet_Nullish(t *testing.T) {
+	for _, obj := range []Object{nil, Null} {
+		func() {
+			defer func() {
+				if _, ok := recover().(*TypeError); !ok {
+					t.Errorf("setting a property of %v: want a TypeError", ToString(obj))
+				}
+			}()
+
+			Set(obj, JSString("a"), JSNumber(1))
+		}()
+	}
+}
+
+func TestDelete(t *testing.T) {
+	// delete arr[1] leaves a hole
+	arr := NewArray([]Object{JSNumber(1), JSNumber(2), JSNumber(3)})
+	if got := Delete(arr, JSNumber(1)); got != JSBoolean(true) {
+		t.Errorf("delete arr[1]: want=true got=%v", got)
+	}
+	if got := Get(arr, JSString("length")); got != JSNumber(3) {
+		t.Errorf("arr.length: want=3 got=%v", got)
+	}
+	if got := Get(arr, JSNumber(1)); got != nil {
+		t.Errorf("arr[1]: want=undefined got=%v", got)
+	}
+	if got := In(JSNumber(1), arr); got != JSBoolean(false) {
+		t.Errorf("1 in arr: want=false got=%v", got)
+	}
+
+	obj := NewObject()
+	obj.DefineProperty("a", JSNumber(1))
+	obj.DefineProperty("b", JSNumber(2))
+	Delete(obj, JSString("a"))
+	if got := In(JSString("a"), obj); got != JSBoolean(false) {
+		t.Errorf(`"a" in obj: want=false got=%v`, got)
+	}
+	if got := strings.Join(obj.Keys(), ","); got != "b" {
+		t.Errorf("keys: want=b got=%s", got)
+	}
+}
+
+func TestIn(t *testing.T) {
+	obj := NewObject()
+	obj.DefineProperty("a", nil)
+	a := NewArray([]Object{JSString("x")})
+
+	tests := []struct {
+		key  Object
+		obj  Object
+		want JSBoolean
+	}{
+		{JSString("a"), obj, true},
+		{JSString("b"), obj, false},
+		{JSNumber(0), a, true},
+		{JSNumber(1), a, false},
+		{JSString("length"), a, true},
+		{JSString("map"), a, true},
+	}
+	for _, test := range tests {
+		if got := In(test.key, test.obj); got != test.want {
+			t.Errorf("%v in %v: want=%v got=%v", ToString(test.key), ToString(test.obj), test.want, got)
+		}
+	}
+}
+
+func TestIn_Primitive(t *testing.T) {
+	for _, obj := range []Object{JSNumber(5), JSString("s"), nil, Null} {
+		func() {
+			defer func() {
+				err, ok := recover().(*TypeError)
+				if !ok {
+					t.Errorf("k in %v: want a TypeError", ToString(obj))
+				} else if want := "TypeError: Cannot use 'in' operator to search for 'k' in " + string(ToString(obj)); err.Error() != want {
+					t.Errorf("k in %v: want=%s got=%s", ToString(obj), want, err)
+				}
+			}()
+
+			In(JSString("k"), obj)
+		}()
+	}
+}
+
+func TestGetOptional(t *testing.T) {
+	a := NewArray([]Object{JSString("a")})
+	for _, obj := range []Object{nil, Null} {
+		if got := GetOptional(obj, JSNumber(0)); got != nil {
+			t.Errorf("%v?.[0]: want=undefined got=%v", ToString(obj), ToString(got))
+		}
+	}
+	if got := GetOptional(a, JSNumber(0)); got != JSString("a") {
+		t.Errorf("a?.[0]: want=a got=%v", ToString(got))
+	}
+}
+
+func TestSpreadObject(t *testing.T) {
+	a := NewObject()
+	a.DefineProperty("x", JSNumber(1))
+	a.DefineProperty("c", JSNumber(0))
+	b := NewObject()
+	b.DefineProperty("x", JSNumber(2))
+	b.DefineProperty("y", JSNumber(3))
+
+	// {...a, ...b, c: 1}
+	o := NewObject()
+	SpreadObject(o, a)
+	SpreadObject(o, b)
+	SpreadObject(o, nil)
+	Set(o, JSString("c"), JSNumber(1))
+
+	want := map[string]Object{"x": JSNumber(2), "c": JSNumber(1), "y": JSNumber(3)}
+	for k, v := range want {
+		if got := o.Get(k); got != v {
+			t.Errorf("o.%s: want=%v got=%v", k, ToString(v), ToString(got))
+		}
+	}
+	if got := strings.Join(o.Keys(), ","); got != "x,c,y" {
+		t.Errorf("keys of o: want=x,c,y got=%s", got)
+	}
+}
+
+func TestSpreadObject_Iterables(t *testing.T) {
+	// {...[1, 2], ..."ab"}
+	o := NewObject()
+	SpreadObject(o, NewArray([]Object{JSNumber(1), JSNumber(2)}))
+	SpreadObject(o, JSString("ab"))
+
+	want := map[string]Object{"0": JSString("a"), "1": JSString("b")}
+	for k, v := range want {
+		if got := o.Get(k); got != v {
+			t.Errorf("o[%s]: want=%v got=%v", k, ToString(v), ToString(got))
+		}
+	}
+}
+
+func TestDefineGetter(t *testing.T) {
+	calls := 0
+	o := NewObject()
+	DefineGetter(o, JSString("x"), NewFunction(func(args []Object) Object {
+		calls++
+		return JSNumber(calls)
+	}))
+
+	// the getter runs on every access
+	for want := 1; want <= 2; want++ {
+		if got := Get(o, JSString("x")); got != JSNumber(want) {
+			t.Errorf("o.x: want=%d got=%v", want, got)
+		}
+	}
+	if got, err := o.GetProperty("x"); err != nil || got != JSNumber(3) {
+		t.Errorf("GetProperty(x): want=3 got=%v, %v", got, err)
+	}
+
+	dst := NewObject()
+	SpreadObject(dst, o)
+	if dst.HasGetter("x") || dst.Get("x") != JSNumber(4) {
+		t.Errorf("spread should copy the value of the getter, got %v", dst.Get("x"))
+	}
+
+	defer func() {
+		if _, ok := recover().(*TypeError); !ok {
+			t.Errorf("o.x = 1: want a TypeError")
+		}
+	}()
+	Set(o, JSString("x"), JSNumber(1))
+}
@@ -17,21 +17,96 @@ const (
 	JS_OBJECT_TYPE_OBJECT   = "object"
 	JS_OBJECT_TYPE_STRING   = "string"
 	JS_OBJECT_TYPE_NUMBER   = "number"
+	JS_OBJECT_TYPE_BOOLEAN  = "boolean"
 	JS_OBJECT_TYPE_FUNCTION = "function"
+	JS_OBJECT_TYPE_NULL     = "null"
 )
 
 type JSObject struct {
 	properties map[string]Object
+	// getters compute the values of the accessor properties on every access
+	getters map[string]*JSFunction
+	// keys are the names of the properties in the order they're defined
+	keys []string
+	// frozen tells whether the properties can no longer be set nor deleted
+	frozen bool
+}
+
+// NewObject returns an empty object.
+func NewObject() *JSObject {
+	return &JSObject{properties: make(map[string]Object)}
 }
 
 func (self *JSObject) Type() JSObjectType { return JS_OBJECT_TYPE_OBJECT }
 
 func (self *JSObject) DefineProperty(prop string, value Object) {
+	if self.properties == nil {
+		self.properties = make(map[string]Object)
+	}
+	if _, ok := self.properties[prop]; !ok {
+		self.keys = append(self.keys, prop)
+	}
+
 	self.properties[prop] = value
+	delete(self.getters, prop)
+}
+
+// DefineGetter defines prop as an accessor property whose value get
+// computes on every access.
+func (self *JSObject) DefineGetter(prop string, get *JSFunction) {
+	self.DefineProperty(prop, nil)
+	if self.getters == nil {
+		self.getters = make(map[string]*JSFunction)
+	}
+
+	self.getters[prop] = get
+}
+
+// HasGetter tells whether prop is an accessor property.
+func (self *JSObject) HasGetter(prop string) bool {
+	_, ok := self.getters[prop]
+	return ok
+}
+
+// DeleteProperty removes prop from the object.
+func (self *JSObject) DeleteProperty(prop string) {
+	if _, ok := self.properties[prop]; !ok {
+		return
+	}
+
+	delete(self.properties, prop)
+	delete(self.getters, prop)
+	for i, k := range self.keys {
+		if k == prop {
+			self.keys = append(self.keys[:i:i], self.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the names of the properties of the object, in the order
+// they were defined.
+func (self *JSObject) Keys() []string {
+	return self.keys
+}
+
+// Get returns the value of prop, undefined when it's not defined.
+func (self *JSObject) Get(prop string) Object {
+	if get, ok := self.getters[prop]; ok {
+		return get.Call(nil)
+	}
+
+	return self.properties[prop]
+}
+
+// Has tells whether prop is defined, even to undefined.
+func (self *JSObject) Has(prop string) bool {
+	_, ok := self.properties[prop]
+	return ok
 }
 
 func (self *JSObject) GetProperty(prop string) (Object, error) {
-	obj := self.properties[prop]
+	obj := self.Get(prop)
 	if obj == nil {
 		return nil, &ReferenceError{prop}
 	}
@@ -39,6 +114,17 @@ func (self *JSObject) GetProperty(prop string) (Object, error) {
 	return obj, nil
 }
 
+// Resolve returns the value of prop like a reference to the global variable
+// prop does, panicking with a ReferenceError when it's not defined.
+func (self *JSObject) Resolve(prop string) Object {
+	obj, err := self.GetProperty(prop)
+	if err != nil {
+		panic(err)
+	}
+
+	return obj
+}
+
 type JSString string
 
 func (self JSString) Type() JSObjectType { return JS_OBJECT_TYPE_STRING }
@@ -47,8 +133,27 @@ type JSNumber float64
 
 func (self JSNumber) Type() JSObjectType { return JS_OBJECT_TYPE_NUMBER }
 
+type JSBoolean bool
+
+func (self JSBoolean) Type() JSObjectType { return JS_OBJECT_TYPE_BOOLEAN }
+
+type JSNull struct{}
+
+// Null is the JavaScript null, while undefined is a nil Object.
+var Null = JSNull{}
+
+func (self JSNull) Type() JSObjectType { return JS_OBJECT_TYPE_NULL }
+
 type JSFunction struct {
-	fn func([]Object)
+	fn func([]Object) Object
+}
+
+func NewFunction(fn func([]Object) Object) *JSFunction {
+	return &JSFunction{fn: fn}
+}
+
+func (self *JSFunction) Call(args []Object) Object {
+	return self.fn(args)
 }
 
 func (self *JSFunction) FuncName() string {
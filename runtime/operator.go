@@ -0,0 +1,274 @@
+package runtime
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Truthy converts o to a boolean the way JavaScript conditions do.
+func Truthy(o Object) bool {
+	switch v := o.(type) {
+	case nil, JSNull:
+		return false
+	case JSBoolean:
+		return bool(v)
+	case JSNumber:
+		return v != 0 && !math.IsNaN(float64(v))
+	case JSString:
+		return v != ""
+	default:
+		return true
+	}
+}
+
+// ToNumber converts o to a number the way JavaScript's Number(o) does.
+func ToNumber(o Object) JSNumber {
+	switch v := o.(type) {
+	case nil:
+		return JSNumber(math.NaN())
+	case JSNull:
+		return 0
+	case JSNumber:
+		return v
+	case JSBoolean:
+		if v {
+			return 1
+		}
+		return 0
+	case JSString:
+		return stringToNumber(string(v))
+	case *JSArray:
+		return stringToNumber(string(ToString(v)))
+	default:
+		return JSNumber(math.NaN())
+	}
+}
+
+func stringToNumber(s string) JSNumber {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "":
+		return 0
+	case "Infinity", "+Infinity":
+		return JSNumber(math.Inf(1))
+	case "-Infinity":
+		return JSNumber(math.Inf(-1))
+	}
+
+	if len(s) > 2 && s[0] == '0' {
+		base := 0
+		switch s[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 0 {
+			n, err := strconv.ParseUint(s[2:], base, 64)
+			if err != nil {
+				return JSNumber(math.NaN())
+			}
+			return JSNumber(n)
+		}
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		// overflows to an infinity or underflows to zero
+		err = nil
+	}
+	// ParseFloat accepts hex floats, underscores and spelled out infinities
+	// which Number doesn't
+	if err != nil || strings.ContainsAny(s, "xXpP_iI") {
+		return JSNumber(math.NaN())
+	}
+
+	return JSNumber(f)
+}
+
+// ToString converts o to a string the way JavaScript's String(o) does.
+func ToString(o Object) JSString {
+	switch v := o.(type) {
+	case nil:
+		return "undefined"
+	case JSNull:
+		return "null"
+	case JSString:
+		return v
+	case JSNumber:
+		return JSString(numberToString(float64(v)))
+	case JSBoolean:
+		return JSString(strconv.FormatBool(bool(v)))
+	case *JSFunction:
+		return "function () { [native code] }"
+	case *JSClass:
+		return JSString("class " + v.name + " { [native code] }")
+	case *JSArray:
+		return arrayJoin(v, nil).(JSString)
+	case *JSRegExp:
+		return JSString("/" + v.source + "/" + v.flags)
+	default:
+		return "[object Object]"
+	}
+}
+
+func numberToString(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		return "0"
+	}
+
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	// exponents are written without leading zeros, e.g. 1e-7
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exp := s[:strings.IndexByte(s, 'e')], s[strings.IndexByte(s, 'e')+1:]
+	sign := exp[:1]
+	exp = strings.TrimLeft(exp[1:], "0")
+
+	return mantissa + "e" + sign + exp
+}
+
+// Add implements the + operator: strings are concatenated, anything else is
+// added as numbers.
+func Add(a, b Object) Object {
+	_, aStr := a.(JSString)
+	_, bStr := b.(JSString)
+	if aStr || bStr {
+		return ToString(a) + ToString(b)
+	}
+
+	return ToNumber(a) + ToNumber(b)
+}
+
+// TypeOf returns the type of o like the typeof operator does, which is
+// "object" for null.
+func TypeOf(o Object) JSString {
+	switch o.(type) {
+	case nil:
+		return "undefined"
+	case JSNull:
+		return JSString(JS_OBJECT_TYPE_OBJECT)
+	}
+
+	return JSString(o.Type())
+}
+
+// Sub implements the - operator.
+func Sub(a, b Object) Object {
+	return ToNumber(a) - ToNumber(b)
+}
+
+// Neg negates a, which may be 0, giving -0.
+func Neg(a Object) Object {
+	return -ToNumber(a)
+}
+
+// Mul implements the * operator.
+func Mul(a, b Object) Object {
+	return ToNumber(a) * ToNumber(b)
+}
+
+// Divide implements the / operator, dividing as float64 so that a division
+// by zero gives an infinity, or NaN for 0 / 0, rather than panicking.
+func Divide(a, b Object) Object {
+	return ToNumber(a) / ToNumber(b)
+}
+
+// Modulo implements the % operator, whose result has the sign of a. It's
+// NaN when b is zero or a is infinite.
+func Modulo(a, b Object) Object {
+	return JSNumber(math.Mod(float64(ToNumber(a)), float64(ToNumber(b))))
+}
+
+// StrictEquals implements the === operator: values of different types are
+// never equal and objects are only equal to themselves. NaN isn't equal to
+// anything and 0 equals -0, as Go compares floats.
+func StrictEquals(a, b Object) bool {
+	return a == b
+}
+
+// Coalesce implements the ?? operator: it returns a unless it's nullish, and
+// the value of b otherwise, which is only evaluated then.
+func Coalesce(a Object, b func() Object) Object {
+	if IsNullish(a) {
+		return b()
+	}
+
+	return a
+}
+
+// Or implements the || operator: it returns a when it's truthy, and the
+// value of b otherwise, which is only evaluated then.
+func Or(a Object, b func() Object) Object {
+	if Truthy(a) {
+		return a
+	}
+
+	return b()
+}
+
+// And implements the && operator: it returns a when it's falsy, and the
+// value of b otherwise, which is only evaluated then.
+func And(a Object, b func() Object) Object {
+	if !Truthy(a) {
+		return a
+	}
+
+	return b()
+}
+
+// Ternary implements the conditional operator: it returns the value of
+// consequent when test holds and the value of alternate otherwise, only
+// evaluating the one it returns.
+func Ternary(test bool, consequent, alternate func() Object) Object {
+	if test {
+		return consequent()
+	}
+
+	return alternate()
+}
+
+// Less implements the < operator: strings are compared lexicographically,
+// anything else as numbers.
+func Less(a, b Object) Object {
+	as, aStr := a.(JSString)
+	bs, bStr := b.(JSString)
+	if aStr && bStr {
+		return JSBoolean(as < bs)
+	}
+
+	return JSBoolean(ToNumber(a) < ToNumber(b))
+}
+
+// Greater implements the > operator.
+func Greater(a, b Object) Object {
+	return Less(b, a)
+}
+
+// LessOrEqual implements the <= operator.
+func LessOrEqual(a, b Object) Object {
+	as, aStr := a.(JSString)
+	bs, bStr := b.(JSString)
+	if aStr && bStr {
+		return JSBoolean(as <= bs)
+	}
+
+	return JSBoolean(ToNumber(a) <= ToNumber(b))
+}
+
+// GreaterOrEqual implements the >= operator.
+func GreaterOrEqual(a, b Object) Object {
+	return LessOrEqual(b, a)
+}
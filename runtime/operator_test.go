@@ -0,0 +1,238 @@
+package runtime
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestToNumber(t *testing.T) {
+	tests := []struct {
+		o    Object
+		want float64
+	}{
+		{JSNumber(1.5), 1.5},
+		{JSBoolean(true), 1},
+		{JSString(" 42 "), 42},
+		{JSString(""), 0},
+		{JSString("0x1f"), 31},
+		{JSString("1e400"), math.Inf(1)},
+		{JSString("-Infinity"), math.Inf(-1)},
+	}
+
+	for _, test := range tests {
+		if got := ToNumber(test.o); float64(got) != test.want {
+			t.Errorf("ToNumber(%#v): want=%v got=%v", test.o, test.want, got)
+		}
+	}
+
+	for _, o := range []Object{nil, JSString("abc"), JSString("infinity"), JSString("1_000"), &JSObject{}} {
+		if got := ToNumber(o); !math.IsNaN(float64(got)) {
+			t.Errorf("ToNumber(%#v): want=NaN got=%v", o, got)
+		}
+	}
+}
+
+func TestToString(t *testing.T) {
+	tests := []struct {
+		o    Object
+		want JSString
+	}{
+		{nil, "undefined"},
+		{JSNumber(2), "2"},
+		{JSNumber(-0.5), "-0.5"},
+		{JSNumber(1e21), "1e+21"},
+		{JSNumber(1.5e-7), "1.5e-7"},
+		{JSNumber(math.NaN()), "NaN"},
+		{JSBoolean(false), "false"},
+		{&JSObject{}, "[object Object]"},
+		{NewArray([]Object{JSNumber(1), JSNumber(2)}), "1,2"},
+		{NewArray([]Object{JSNumber(1), nil, Null, NewArray([]Object{JSString("a"), JSString("b")})}), "1,,,a,b"},
+	}
+
+	for _, test := range tests {
+		if got := ToString(test.o); got != test.want {
+			t.Errorf("ToString(%#v): want=%q got=%q", test.o, test.want, got)
+		}
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	tests := []struct {
+		o    Object
+		want JSString
+	}{
+		{nil, "undefined"},
+		{Null, "object"},
+		{JSNumber(1), "number"},
+		{JSString("s"), "string"},
+		{JSBoolean(true), "boolean"},
+		{NewObject(), "object"},
+		{NewArray(nil), "object"},
+		{NewFunction(func(args []Object) Object { return nil }), "function"},
+		{NewClass("C", func(*JSObject) {}), "function"},
+	}
+
+	for _, test := range tests {
+		if got := TypeOf(test.o); got != test.want {
+			t.Errorf("TypeOf(%#v): want=%s got=%s", test.o, test.want, got)
+		}
+	}
+}
+
+func TestNeg(t *testing.T) {
+	if got := Neg(JSString("2")); got != JSNumber(-2) {
+		t.Errorf("-\"2\": want=-2 got=%v", got)
+	}
+	if got := Neg(JSNumber(0)).(JSNumber); got != 0 || !math.Signbit(float64(got)) {
+		t.Errorf("-0: want=-0 got=%v", got)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	if got := Add(JSNumber(1), JSNumber(2)); got != JSNumber(3) {
+		t.Errorf("1 + 2: want=3 got=%v", got)
+	}
+	if got := Add(JSString("x"), JSNumber(1)); got != JSString("x1") {
+		t.Errorf(`"x" + 1: want="x1" got=%v`, got)
+	}
+	if got := Add(JSBoolean(true), JSNumber(1)); got != JSNumber(2) {
+		t.Errorf("true + 1: want=2 got=%v", got)
+	}
+}
+
+func TestDivideModulo(t *testing.T) {
+	inf := JSNumber(math.Inf(1))
+	tests := []struct {
+		name string
+		got  Object
+		want JSNumber
+	}{
+		{"1 / 0", Divide(JSNumber(1), JSNumber(0)), inf},
+		{"-1 / 0", Divide(JSNumber(-1), JSNumber(0)), -inf},
+		{"1 / -0", Divide(JSNumber(1), Neg(JSNumber(0))), -inf},
+		{`"6" / 4`, Divide(JSString("6"), JSNumber(4)), 1.5},
+		{"7 % 3", Modulo(JSNumber(7), JSNumber(3)), 1},
+		{"-7 % 3", Modulo(JSNumber(-7), JSNumber(3)), -1},
+		{"5.5 % 2", Modulo(JSNumber(5.5), JSNumber(2)), 1.5},
+		{"1 % Infinity", Modulo(JSNumber(1), inf), 1},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: want=%v got=%v", test.name, test.want, test.got)
+		}
+	}
+
+	for name, got := range map[string]Object{
+		"0 / 0":        Divide(JSNumber(0), JSNumber(0)),
+		"1 % 0":        Modulo(JSNumber(1), JSNumber(0)),
+		"Infinity % 1": Modulo(inf, JSNumber(1)),
+		`"a" / 1`:      Divide(JSString("a"), JSNumber(1)),
+	} {
+		if n, ok := got.(JSNumber); !ok || !math.IsNaN(float64(n)) {
+			t.Errorf("%s: want=NaN got=%v", name, got)
+		}
+	}
+}
+
+func TestStrictEquals(t *testing.T) {
+	o := NewObject()
+	tests := []struct {
+		a, b Object
+		want bool
+	}{
+		{JSNumber(1), JSNumber(1), true},
+		{JSNumber(1), JSString("1"), false},
+		{JSNumber(0), JSNumber(math.Copysign(0, -1)), true},
+		{JSNumber(math.NaN()), JSNumber(math.NaN()), false},
+		{JSString("a"), JSString("a"), true},
+		{JSBoolean(true), JSNumber(1), false},
+		{nil, nil, true},
+		{nil, Null, false},
+		{o, o, true},
+		{o, NewObject(), false},
+	}
+
+	for _, test := range tests {
+		if got := StrictEquals(test.a, test.b); got != test.want {
+			t.Errorf("%v === %v: want=%v got=%v", ToString(test.a), ToString(test.b), test.want, got)
+		}
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	tests := []struct {
+		a    Object
+		want Object
+	}{
+		{nil, JSString("b")},
+		{Null, JSString("b")},
+		{JSNumber(0), JSNumber(0)},
+		{JSString(""), JSString("")},
+		{JSBoolean(false), JSBoolean(false)},
+	}
+
+	for _, test := range tests {
+		evaluated := false
+		got := Coalesce(test.a, func() Object {
+			evaluated = true
+			return JSString("b")
+		})
+		if got != test.want {
+			t.Errorf("%v ?? \"b\": want=%v got=%v", ToString(test.a), ToString(test.want), ToString(got))
+		}
+		if evaluated != IsNullish(test.a) {
+			t.Errorf("%v ?? \"b\": want the right side evaluated=%t", ToString(test.a), IsNullish(test.a))
+		}
+	}
+}
+
+func TestOrAnd(t *testing.T) {
+	tests := []struct {
+		op   string
+		fn   func(Object, func() Object) Object
+		a    Object
+		want Object
+	}{
+		{"||", Or, JSString(""), JSString("b")},
+		{"||", Or, nil, JSString("b")},
+		{"||", Or, JSNumber(1), JSNumber(1)},
+		{"&&", And, JSString(""), JSString("")},
+		{"&&", And, Null, Null},
+		{"&&", And, JSNumber(1), JSString("b")},
+	}
+
+	for _, test := range tests {
+		evaluated := false
+		got := test.fn(test.a, func() Object {
+			evaluated = true
+			return JSString("b")
+		})
+		if got != test.want {
+			t.Errorf("%v %s \"b\": want=%v got=%v", ToString(test.a), test.op, ToString(test.want), ToString(got))
+		}
+		if evaluated != (test.want == JSString("b")) {
+			t.Errorf("%v %s \"b\": want the right side evaluated=%t", ToString(test.a), test.op, !evaluated)
+		}
+	}
+}
+
+func TestTernary(t *testing.T) {
+	var evaluated []string
+	branch := func(name string) func() Object {
+		return func() Object {
+			evaluated = append(evaluated, name)
+			return JSString(name)
+		}
+	}
+
+	if got := Ternary(true, branch("a"), branch("b")); got != JSString("a") {
+		t.Errorf("true ? a : b: want=a got=%v", got)
+	}
+	if got := Ternary(false, branch("a"), branch("b")); got != JSString("b") {
+		t.Errorf("false ? a : b: want=b got=%v", got)
+	}
+	if got := strings.Join(evaluated, ","); got != "a,b" {
+		t.Errorf("want only the branches taken evaluated, got %s", got)
+	}
+}
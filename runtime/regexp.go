@@ -0,0 +1,220 @@
+package runtime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// JSRegExp is a regular expression, matching with the Go regexp its
+// pattern compiles to. Global regular expressions match all occurrences
+// but don't keep a lastIndex: test and exec always match from the start.
+type JSRegExp struct {
+	re     *regexp.Regexp
+	source string
+	flags  string
+}
+
+// NewRegExp returns the regular expression of the pattern source with
+// flags, which re is compiled from.
+func NewRegExp(re *regexp.Regexp, source, flags string) *JSRegExp {
+	return &JSRegExp{re: re, source: source, flags: flags}
+}
+
+func (self *JSRegExp) Type() JSObjectType { return JS_OBJECT_TYPE_OBJECT }
+
+func (self *JSRegExp) global() bool {
+	return strings.Contains(self.flags, "g")
+}
+
+// Get returns the prop property of the regular expression, such as its
+// source or its methods bound to it.
+func (self *JSRegExp) Get(prop string) Object {
+	switch prop {
+	case "source":
+		return JSString(self.source)
+	case "flags":
+		return JSString(self.flags)
+	case "global":
+		return JSBoolean(self.global())
+	case "test":
+		return NewFunction(func(args []Object) Object {
+			return RegExp_Test(self.re, Arg(args, 0))
+		})
+	case "exec":
+		return NewFunction(func(args []Object) Object {
+			return RegExp_Exec(self.re, Arg(args, 0))
+		})
+	}
+
+	return nil
+}
+
+// RegExp_Test tells whether re matches the string of s, like
+// RegExp.prototype.test.
+func RegExp_Test(re *regexp.Regexp, s Object) Object {
+	return JSBoolean(re.MatchString(string(ToString(s))))
+}
+
+// RegExp_Exec returns the first match of re in the string of s followed by
+// its groups, unmatched ones being undefined, or null when re doesn't
+// match, like RegExp.prototype.exec.
+func RegExp_Exec(re *regexp.Regexp, s Object) Object {
+	str := string(ToString(s))
+	loc := re.FindStringSubmatchIndex(str)
+	if loc == nil {
+		return Null
+	}
+
+	return NewArray(submatches(str, loc))
+}
+
+// String_Match returns the matches of re in the string of s, or null when
+// re doesn't match, like String.prototype.match. A global match returns
+// all the matches, others the first one as RegExp_Exec does.
+func String_Match(s Object, re *regexp.Regexp, global bool) Object {
+	if !global {
+		return RegExp_Exec(re, s)
+	}
+
+	matches := re.FindAllString(string(ToString(s)), -1)
+	if matches == nil {
+		return Null
+	}
+	elements := make([]Object, len(matches))
+	for i, m := range matches {
+		elements[i] = JSString(m)
+	}
+
+	return NewArray(elements)
+}
+
+// String_Replace replaces the first match of re in the string of s, or all
+// of them when global, like String.prototype.replace. The replacement is
+// either a function called with the match, its groups, its index and the
+// string, or a string in which $&, $`, $', $n and $$ are expanded.
+func String_Replace(s Object, re *regexp.Regexp, global bool, replacement Object) Object {
+	str := string(ToString(s))
+	n := 1
+	if global {
+		n = -1
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringSubmatchIndex(str, n) {
+		b.WriteString(str[last:loc[0]])
+		groups := submatches(str, loc)
+		if fn, ok := replacement.(*JSFunction); ok {
+			index := JSNumber(len(utf16.Encode([]rune(str[:loc[0]]))))
+			b.WriteString(string(ToString(fn.Call(append(groups, index, JSString(str))))))
+		} else {
+			b.WriteString(expandReplacement(string(ToString(replacement)), str, loc, groups))
+		}
+		last = loc[1]
+	}
+	b.WriteString(str[last:])
+
+	return JSString(b.String())
+}
+
+// submatches returns the match of loc in s followed by its groups, the
+// ones which didn't participate in the match being undefined
+func submatches(s string, loc []int) []Object {
+	groups := make([]Object, len(loc)/2)
+	for i := range groups {
+		if loc[2*i] >= 0 {
+			groups[i] = JSString(s[loc[2*i]:loc[2*i+1]])
+		}
+	}
+
+	return groups
+}
+
+// expandReplacement expands the $ patterns of the replacement of the match
+// loc of s, whose match and groups are groups
+func expandReplacement(replacement, s string, loc []int, groups []Object) string {
+	var b strings.Builder
+	for i := 0; i < len(replacement); i++ {
+		c := replacement[i]
+		if c != '$' || i+1 == len(replacement) {
+			b.WriteByte(c)
+			continue
+		}
+
+		switch next := replacement[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '&':
+			b.WriteString(s[loc[0]:loc[1]])
+			i++
+		case next == '`':
+			b.WriteString(s[:loc[0]])
+			i++
+		case next == '\'':
+			b.WriteString(s[loc[1]:])
+			i++
+		case next >= '0' && next <= '9':
+			// two digit groups are preferred when there are that many
+			digits := 1
+			if i+2 < len(replacement) && replacement[i+2] >= '0' && replacement[i+2] <= '9' {
+				if n, _ := strconv.Atoi(replacement[i+1 : i+3]); n >= 1 && n < len(groups) {
+					digits = 2
+				}
+			}
+			n, _ := strconv.Atoi(replacement[i+1 : i+1+digits])
+			if n < 1 || n >= len(groups) {
+				b.WriteByte(c)
+				continue
+			}
+			if groups[n] != nil {
+				b.WriteString(string(groups[n].(JSString)))
+			}
+			i += digits
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+func stringMatch(s JSString, args []Object) Object {
+	re := toRegExp(Arg(args, 0))
+
+	return String_Match(s, re.re, re.global())
+}
+
+func stringReplace(s JSString, args []Object) Object {
+	pattern, replacement := Arg(args, 0), Arg(args, 1)
+	if re, ok := pattern.(*JSRegExp); ok {
+		return String_Replace(s, re.re, re.global(), replacement)
+	}
+
+	// a string pattern is replaced where it first occurs
+	re := regexp.MustCompile(regexp.QuoteMeta(string(ToString(pattern))))
+
+	return String_Replace(s, re, false, replacement)
+}
+
+// toRegExp returns o when it's a regular expression, or the one of its
+// string as a pattern
+func toRegExp(o Object) *JSRegExp {
+	if re, ok := o.(*JSRegExp); ok {
+		return re
+	}
+
+	source := ""
+	if o != nil {
+		source = string(ToString(o))
+	}
+	re, err := regexp.Compile(source)
+	if err != nil {
+		panic(&TypeError{fmt.Sprintf("Invalid regular expression: /%s/", source)})
+	}
+
+	return NewRegExp(re, source, "")
+}
@@ -0,0 +1,55 @@
+package runtime
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestString_Replace(t *testing.T) {
+	tests := []struct {
+		s           string
+		re          string
+		global      bool
+		replacement Object
+		want        JSString
+	}{
+		{"a1b2", `\d`, true, JSString("#"), "a#b#"},
+		{"a1b2", `\d`, false, JSString("#"), "a#b2"},
+		{"john smith", `(\w+)\s(\w+)`, false, JSString("$2, $1"), "smith, john"},
+		{"abc", `b`, false, JSString("[$&|$`|$'|$$|$3]"), "a[b|a|c|$|$3]c"},
+		{"ab", `(x)?b`, false, JSString("<$1>"), "a<>"},
+		{"ab", `x*`, true, JSString("-"), "-a-b-"},
+		{"a1b22", `\d+`, true, NewFunction(func(args []Object) Object {
+			return Add(args[0], args[1])
+		}), "a11b223"},
+	}
+
+	for _, test := range tests {
+		got := String_Replace(JSString(test.s), regexp.MustCompile(test.re), test.global, test.replacement)
+		if got != test.want {
+			t.Errorf("%q.replace(/%s/): want=%q got=%q", test.s, test.re, test.want, got)
+		}
+	}
+}
+
+func TestRegExp_Methods(t *testing.T) {
+	re := NewRegExp(regexp.MustCompile(`(\d)(x)?`), `(\d)(x)?`, "g")
+	tests := []struct {
+		o    Object
+		want JSString
+	}{
+		{Call(Get(re, JSString("test")), []Object{JSString("a1")}), "true"},
+		{Call(Get(re, JSString("exec")), []Object{JSString("a12")}), "1,1,"},
+		{Call(Get(re, JSString("exec")), []Object{JSString("ab")}), "null"},
+		{Call(Get(JSString("a12"), JSString("match")), []Object{re}), "1,2"},
+		{Call(Get(JSString("a1.2"), JSString("replace")), []Object{JSString("."), JSString("!")}), "a1!2"},
+		{Get(re, JSString("source")), `(\d)(x)?`},
+		{re, `/(\d)(x)?/g`},
+	}
+
+	for i, test := range tests {
+		if got := ToString(test.o); got != test.want {
+			t.Errorf("%d: want=%q got=%q", i, test.want, got)
+		}
+	}
+}
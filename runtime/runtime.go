@@ -9,14 +9,39 @@ var (
 				fn: Console_Log,
 			},
 		},
+		keys: []string{"log"},
 	}
 )
 
-func Console_Log(data []Object) {
+func Console_Log(data []Object) Object {
 	var i []interface{}
 	for _, d := range data {
-		i = append(i, d)
+		i = append(i, ToString(d))
 	}
 
 	fmt.Println(i...)
+
+	return nil
+}
+
+// Call calls fn with args, fn must be a function.
+func Call(fn Object, args []Object) Object {
+	if c, ok := fn.(*JSClass); ok {
+		panic(&TypeError{fmt.Sprintf("Class constructor %s cannot be invoked without 'new'", c.name)})
+	}
+	f, ok := fn.(*JSFunction)
+	if !ok {
+		panic(&TypeError{fmt.Sprintf("%v is not a function", fn)})
+	}
+
+	return f.Call(args)
+}
+
+// Arg returns the i-th argument, or undefined when fewer were passed.
+func Arg(args []Object, i int) Object {
+	if i < len(args) {
+		return args[i]
+	}
+
+	return nil
 }
@@ -0,0 +1,169 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+var (
+	arrayObject = &JSObject{
+		properties: map[string]Object{
+			"isArray": &JSFunction{
+				fn: Array_IsArray,
+			},
+		},
+		keys: []string{"isArray"},
+	}
+	objectObject = &JSObject{
+		properties: map[string]Object{
+			"assign": &JSFunction{
+				fn: Object_Assign,
+			},
+			"entries": &JSFunction{
+				fn: Object_Entries,
+			},
+			"freeze": &JSFunction{
+				fn: Object_Freeze,
+			},
+			"keys": &JSFunction{
+				fn: Object_Keys,
+			},
+			"values": &JSFunction{
+				fn: Object_Values,
+			},
+		},
+		keys: []string{"assign", "entries", "freeze", "keys", "values"},
+	}
+)
+
+// Array_IsArray tells whether the first of args is an array.
+func Array_IsArray(args []Object) Object {
+	_, ok := Arg(args, 0).(*JSArray)
+
+	return JSBoolean(ok)
+}
+
+// Object_Keys returns the names of the own properties of the first of args,
+// array indices first in ascending order and then the other names in the
+// order they were defined, like Object.keys.
+func Object_Keys(args []Object) Object {
+	keys := ownKeys(Arg(args, 0))
+	elements := make([]Object, len(keys))
+	for i, k := range keys {
+		elements[i] = JSString(k)
+	}
+
+	return NewArray(elements)
+}
+
+// Object_Values returns the values of the own properties of the first of
+// args, in the order of Object_Keys.
+func Object_Values(args []Object) Object {
+	obj := Arg(args, 0)
+	keys := ownKeys(obj)
+	elements := make([]Object, len(keys))
+	for i, k := range keys {
+		elements[i] = Get(obj, JSString(k))
+	}
+
+	return NewArray(elements)
+}
+
+// Object_Entries returns the [name, value] pairs of the own properties of
+// the first of args, in the order of Object_Keys.
+func Object_Entries(args []Object) Object {
+	obj := Arg(args, 0)
+	keys := ownKeys(obj)
+	elements := make([]Object, len(keys))
+	for i, k := range keys {
+		elements[i] = NewArray([]Object{JSString(k), Get(obj, JSString(k))})
+	}
+
+	return NewArray(elements)
+}
+
+// Object_Assign sets the own properties of the other args on the first one,
+// which it returns, like Object.assign. Nullish sources are skipped.
+func Object_Assign(args []Object) Object {
+	target := Arg(args, 0)
+	if IsNullish(target) {
+		panic(&TypeError{fmt.Sprintf("Cannot convert %s to object", ToString(target))})
+	}
+
+	for _, source := range args[1:] {
+		if IsNullish(source) {
+			continue
+		}
+		for _, k := range ownKeys(source) {
+			Set(target, JSString(k), Get(source, JSString(k)))
+		}
+	}
+
+	return target
+}
+
+// Object_Freeze freezes the first of args when it's an object, so that its
+// properties can no longer be set nor deleted, and returns it, like
+// Object.freeze. Arrays aren't frozen.
+func Object_Freeze(args []Object) Object {
+	if obj, ok := Arg(args, 0).(*JSObject); ok {
+		obj.frozen = true
+	}
+
+	return Arg(args, 0)
+}
+
+// ownKeys returns the names of the own enumerable properties of o, which
+// must not be nullish
+func ownKeys(o Object) []string {
+	switch v := o.(type) {
+	case nil, JSNull:
+		panic(&TypeError{fmt.Sprintf("Cannot convert %s to object", ToString(o))})
+	case *JSObject:
+		return orderKeys(v.Keys())
+	case *JSClass:
+		return orderKeys(v.Keys())
+	case *JSArray:
+		keys := []string{}
+		for i := range v.elements {
+			if !v.holes[i] {
+				keys = append(keys, strconv.Itoa(i))
+			}
+		}
+		return keys
+	case JSString:
+		return indexKeys(int(Get(v, JSString("length")).(JSNumber)))
+	}
+
+	return nil
+}
+
+// orderKeys returns keys with the array indices moved first in ascending
+// order, the order JavaScript enumerates properties in
+func orderKeys(keys []string) []string {
+	var indices, names []string
+	for _, k := range keys {
+		if i, err := strconv.ParseUint(k, 10, 32); err == nil && strconv.FormatUint(i, 10) == k {
+			indices = append(indices, k)
+		} else {
+			names = append(names, k)
+		}
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		a, _ := strconv.ParseUint(indices[i], 10, 32)
+		b, _ := strconv.ParseUint(indices[j], 10, 32)
+		return a < b
+	})
+
+	return append(indices, names...)
+}
+
+func indexKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	return keys
+}
@@ -0,0 +1,95 @@
+package runtime
+
+import "testing"
+
+func TestArray_IsArray(t *testing.T) {
+	for _, test := range []struct {
+		arg  Object
+		want JSBoolean
+	}{
+		{NewArray(nil), true},
+		{NewObject(), false},
+		{JSString("abc"), false},
+		{nil, false},
+	} {
+		if got := Array_IsArray([]Object{test.arg}); got != test.want {
+			t.Errorf("Array.isArray(%v): want=%v got=%v", ToString(test.arg), test.want, got)
+		}
+	}
+}
+
+func TestObject_Keys(t *testing.T) {
+	// Object.keys({b: 1, 2: 2, a: 3, 1: 4})
+	obj := NewObject()
+	for i, k := range []string{"b", "2", "a", "1"} {
+		obj.DefineProperty(k, JSNumber(i))
+	}
+
+	tests := []struct {
+		fn   func([]Object) Object
+		arg  Object
+		want string
+	}{
+		{Object_Keys, obj, "1,2,b,a"},
+		{Object_Values, obj, "3,1,0,2"},
+		{Object_Entries, obj, "1,3,2,1,b,0,a,2"},
+		{Object_Keys, NewArray([]Object{JSString("x"), JSString("y")}), "0,1"},
+		// Object.keys([1, , 3]), Object.values([1, , 3]), Object.keys([undefined])
+		{Object_Keys, NewArrayWithHoles([]Object{JSNumber(1), nil, JSNumber(3)}, 1), "0,2"},
+		{Object_Values, NewArrayWithHoles([]Object{JSNumber(1), nil, JSNumber(3)}, 1), "1,3"},
+		{Object_Keys, NewArray([]Object{nil}), "0"},
+		{Object_Keys, JSString("hi"), "0,1"},
+		{Object_Keys, JSNumber(1), ""},
+	}
+	for _, test := range tests {
+		if got := ToString(test.fn([]Object{test.arg})); got != JSString(test.want) {
+			t.Errorf("want=%s got=%s", test.want, got)
+		}
+	}
+}
+
+func TestObject_Assign(t *testing.T) {
+	target := NewObject()
+	target.DefineProperty("a", JSNumber(1))
+	source := NewObject()
+	source.DefineProperty("a", JSNumber(2))
+	source.DefineProperty("b", JSNumber(3))
+
+	if got := Object_Assign([]Object{target, nil, source}); got != target {
+		t.Fatalf("want the target returned got=%v", got)
+	}
+	if got := ToString(Object_Values([]Object{target})); got != "2,3" {
+		t.Errorf("want=2,3 got=%s", got)
+	}
+}
+
+func TestObject_Freeze(t *testing.T) {
+	obj := NewObject()
+	obj.DefineProperty("a", JSNumber(1))
+
+	if got := Object_Freeze([]Object{obj}); got != obj {
+		t.Fatalf("want the object returned got=%v", got)
+	}
+	Set(obj, JSString("a"), JSNumber(2))
+	Set(obj, JSString("b"), JSNumber(3))
+	if got := Delete(obj, JSString("a")); got != JSBoolean(false) {
+		t.Errorf("delete: want=false got=%v", got)
+	}
+	if got := ToString(Object_Entries([]Object{obj})); got != "a,1" {
+		t.Errorf("want=a,1 got=%s", got)
+	}
+}
+
+func TestObject_Keys_Nullish(t *testing.T) {
+	defer func() {
+		err, ok := recover().(*TypeError)
+		if !ok {
+			t.Fatalf("want a TypeError")
+		}
+		if want := "TypeError: Cannot convert null to object"; err.Error() != want {
+			t.Errorf("want=%s got=%s", want, err)
+		}
+	}()
+
+	Object_Keys([]Object{Null})
+}
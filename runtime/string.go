@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// stringMethods are the methods of String.prototype
+var stringMethods = map[string]func(s JSString, args []Object) Object{
+	"match":       stringMatch,
+	"replace":     stringReplace,
+	"split":       stringSplit,
+	"toLowerCase": stringToLowerCase,
+	"toUpperCase": stringToUpperCase,
+	"trim":        stringTrim,
+}
+
+// StringIndex returns the character of s at index, the string of its UTF-16
+// code unit there, or undefined when s has none there, like `s[index]`.
+// Other keys read the properties of s as Get does.
+func StringIndex(s JSString, index Object) Object {
+	if i, ok := arrayIndex(index); ok {
+		return charAt(s, i)
+	}
+
+	return Get(s, index)
+}
+
+// charAt returns the string of the UTF-16 code unit of s at i, or undefined
+// when i isn't an index of s
+func charAt(s JSString, i int) Object {
+	units := utf16.Encode([]rune(string(s)))
+	if i < 0 || i >= len(units) {
+		return nil
+	}
+
+	return JSString(utf16.Decode(units[i : i+1]))
+}
+
+// stringMethod returns the method prop of s bound to s, if there's one
+func stringMethod(s JSString, prop string) Object {
+	m, ok := stringMethods[prop]
+	if !ok {
+		return nil
+	}
+
+	return NewFunction(func(args []Object) Object {
+		return m(s, args)
+	})
+}
+
+func stringToLowerCase(s JSString, args []Object) Object {
+	return JSString(strings.ToLower(string(s)))
+}
+
+// stringToUpperCase upper cases s like toUpperCase, except that of the
+// special casings of Unicode, which map a character to several, only ß's is
+// applied: ligatures such as ﬁ are kept.
+func stringToUpperCase(s JSString, args []Object) Object {
+	return JSString(strings.ToUpper(strings.Replace(string(s), "ß", "SS", -1)))
+}
+
+// stringTrim strips the white space and line terminators of JavaScript
+// from both ends of s, which include U+FEFF but not U+0085 unlike Go's
+// unicode.IsSpace.
+func stringTrim(s JSString, args []Object) Object {
+	return JSString(strings.TrimFunc(string(s), isWhiteSpace))
+}
+
+// isWhiteSpace tells whether r is a JavaScript white space or line
+// terminator
+func isWhiteSpace(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', '\ufeff', '\u2028', '\u2029':
+		return true
+	}
+
+	return unicode.Is(unicode.Zs, r)
+}
+
+func stringSplit(s JSString, args []Object) Object {
+	limit := -1
+	if l := Arg(args, 1); l != nil {
+		limit = int(uint32(ToNumber(l)))
+	}
+
+	var parts []string
+	switch sep := Arg(args, 0); {
+	case sep == nil:
+		parts = []string{string(s)}
+	case ToString(sep) == "":
+		units := utf16.Encode([]rune(string(s)))
+		for _, u := range units {
+			parts = append(parts, string(utf16.Decode([]uint16{u})))
+		}
+	default:
+		parts = strings.Split(string(s), string(ToString(sep)))
+	}
+
+	if limit >= 0 && limit < len(parts) {
+		parts = parts[:limit]
+	}
+
+	elements := make([]Object, len(parts))
+	for i, p := range parts {
+		elements[i] = JSString(p)
+	}
+
+	return NewArray(elements)
+}
@@ -0,0 +1,68 @@
+package runtime
+
+import "testing"
+
+func TestString_Split(t *testing.T) {
+	tests := []struct {
+		s    JSString
+		args []Object
+		want JSString
+	}{
+		{"a,b,c", []Object{JSString(",")}, "a|b|c"},
+		{"a,b,c", []Object{JSString(","), JSNumber(2)}, "a|b"},
+		{"abc", []Object{JSString("")}, "a|b|c"},
+		{"abc", nil, "abc"},
+		{"", []Object{JSString(",")}, ""},
+	}
+
+	for _, test := range tests {
+		got := Call(Get(test.s, JSString("split")), test.args)
+		if s := ToString(Call(Get(got, JSString("join")), []Object{JSString("|")})); s != test.want {
+			t.Errorf("%q.split(%v): want=%s got=%s", test.s, test.args, test.want, s)
+		}
+	}
+}
+
+func TestString_Case(t *testing.T) {
+	tests := []struct {
+		s, method string
+		want      JSString
+	}{
+		{"aBc", "toUpperCase", "ABC"},
+		{"aBc", "toLowerCase", "abc"},
+		{"é", "toUpperCase", "É"},
+		{"straße", "toUpperCase", "STRASSE"},
+		{" \t a b \n", "trim", "a b"},
+		{"\ufeff\u00a0\u2028a\u3000", "trim", "a"},
+		{"\u0085a", "trim", "\u0085a"},
+	}
+
+	for _, test := range tests {
+		if got := Call(Get(JSString(test.s), JSString(test.method)), nil); got != test.want {
+			t.Errorf("%q.%s(): want=%q got=%q", test.s, test.method, test.want, got)
+		}
+	}
+}
+
+func TestStringIndex(t *testing.T) {
+	tests := []struct {
+		s     JSString
+		index Object
+		want  Object
+	}{
+		{"héllo", JSNumber(1), JSString("é")},
+		{"héllo", JSNumber(2), JSString("l")},
+		{"héllo", JSNumber(5), nil},
+		{"héllo", JSNumber(-1), nil},
+		{"héllo", JSNumber(1.5), nil},
+		{"héllo", JSString("1"), JSString("é")},
+		{"héllo", JSString("length"), JSNumber(5)},
+		{"a😀b", JSNumber(3), JSString("b")},
+	}
+
+	for _, test := range tests {
+		if got := StringIndex(test.s, test.index); got != test.want {
+			t.Errorf("%q[%v]: want=%v got=%v", test.s, test.index, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package source
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+// LineIndex translates between the positions in a JavaScript source, as
+// reported by the parser, and byte offsets in it. Lines are 1-based and
+// separated by \n, \r\n, \r, U+2028 or U+2029 like JavaScript does.
+// Columns are 0-based and count UTF-16 code units, as JavaScript strings
+// do.
+type LineIndex struct {
+	src []byte
+	// lines are the offsets of the starts of the lines, and ends the
+	// offsets of their terminators
+	lines []int
+	ends  []int
+}
+
+// NewLineIndex returns the line index of src.
+func NewLineIndex(src []byte) *LineIndex {
+	idx := &LineIndex{src: src, lines: []int{0}}
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRune(src[i:])
+		if r == '\r' && i+1 < len(src) && src[i+1] == '\n' {
+			size = 2
+		}
+		if r == '\n' || r == '\r' || r == '\u2028' || r == '\u2029' {
+			idx.ends = append(idx.ends, i)
+			idx.lines = append(idx.lines, i+size)
+		}
+		i += size
+	}
+	idx.ends = append(idx.ends, len(src))
+
+	return idx
+}
+
+// Offset returns the byte offset of pos, or -1 when pos isn't in the
+// source. The end of a line is in it, as is the end of the source.
+func (idx *LineIndex) Offset(pos ast.Position) int {
+	if pos.Line < 1 || pos.Line > len(idx.lines) || pos.Column < 0 {
+		return -1
+	}
+
+	offset, end := idx.lines[pos.Line-1], idx.ends[pos.Line-1]
+	for col := 0; col < pos.Column; {
+		if offset >= end {
+			return -1
+		}
+
+		r, size := utf8.DecodeRune(idx.src[offset:])
+		offset += size
+		col += utf16Len(r)
+	}
+
+	return offset
+}
+
+// Position returns the position of the byte offset, or the zero Position
+// when offset isn't in the source. Offsets within a line terminator are at
+// the end of its line.
+func (idx *LineIndex) Position(offset int) ast.Position {
+	if offset < 0 || offset > len(idx.src) {
+		return ast.Position{}
+	}
+
+	line := sort.Search(len(idx.lines), func(i int) bool { return idx.lines[i] > offset }) - 1
+	if end := idx.ends[line]; offset > end {
+		offset = end
+	}
+
+	col := 0
+	for i := idx.lines[line]; i < offset; {
+		r, size := utf8.DecodeRune(idx.src[i:])
+		i += size
+		col += utf16Len(r)
+	}
+
+	return ast.Position{Line: line + 1, Column: col}
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+
+	return 1
+}
@@ -0,0 +1,71 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/jingweno/godzilla/ast"
+)
+
+func TestLineIndex(t *testing.T) {
+	// "€" is 3 bytes and one UTF-16 unit, "😀" 4 bytes and two units
+	src := []byte("let a;\r\nb = '€😀';\nc\rd")
+	idx := NewLineIndex(src)
+
+	tests := []struct {
+		pos    ast.Position
+		offset int
+	}{
+		{ast.Position{Line: 1, Column: 0}, 0},
+		{ast.Position{Line: 1, Column: 6}, 6},
+		{ast.Position{Line: 2, Column: 0}, 8},
+		{ast.Position{Line: 2, Column: 5}, 13},
+		{ast.Position{Line: 2, Column: 6}, 16},
+		{ast.Position{Line: 2, Column: 8}, 20},
+		{ast.Position{Line: 3, Column: 0}, 23},
+		{ast.Position{Line: 4, Column: 0}, 25},
+		{ast.Position{Line: 4, Column: 1}, 26},
+	}
+
+	for _, test := range tests {
+		if got := idx.Offset(test.pos); got != test.offset {
+			t.Errorf("Offset(%v): want=%d got=%d", test.pos, test.offset, got)
+		}
+		if got := idx.Position(test.offset); got != test.pos {
+			t.Errorf("Position(%d): want=%v got=%v", test.offset, test.pos, got)
+		}
+	}
+}
+
+func TestLineIndex_LineTerminator(t *testing.T) {
+	idx := NewLineIndex([]byte("a\r\nb"))
+
+	// offsets within \r\n are at the end of the line
+	for _, offset := range []int{1, 2} {
+		if got, want := idx.Position(offset), (ast.Position{Line: 1, Column: 1}); got != want {
+			t.Errorf("Position(%d): want=%v got=%v", offset, want, got)
+		}
+	}
+}
+
+func TestLineIndex_OutOfRange(t *testing.T) {
+	idx := NewLineIndex([]byte("ab\ncd"))
+
+	for _, pos := range []ast.Position{
+		{Line: 0, Column: 0},
+		{Line: 3, Column: 0},
+		{Line: 1, Column: -1},
+		// past the end of the line
+		{Line: 1, Column: 3},
+		{Line: 2, Column: 3},
+	} {
+		if got := idx.Offset(pos); got != -1 {
+			t.Errorf("Offset(%v): want=-1 got=%d", pos, got)
+		}
+	}
+
+	for _, offset := range []int{-1, 6} {
+		if got := idx.Position(offset); got != (ast.Position{}) {
+			t.Errorf("Position(%d): want the zero Position got=%v", offset, got)
+		}
+	}
+}
@@ -2,44 +2,98 @@ package source
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 )
 
-const tmpl = `package main
+const RuntimeImport = `. "github.com/jingweno/godzilla/runtime"`
 
-import (
-	. "github.com/jingweno/godzilla/runtime"
-)
+const tmpl = `{{with .Header}}{{.}}
 
-func main() {
-	global := NewDefaultContext().Global
-	_ = global
+{{end}}package {{.Package}}
 
-	{{.}}
+import (
+	{{.Imports}}
+)
+{{with .Decls}}
+{{.}}
+{{end}}
+func {{.Func}}() {
+	{{.Body}}
 }`
 
+// NewCode returns code whose body runs in the main function.
 func NewCode() *Code {
-	return &Code{bytes.NewBuffer(nil)}
+	return newCode("main")
+}
+
+// NewInitCode returns code whose body runs in an init function, for files
+// sharing a package with other compiled files.
+func NewInitCode() *Code {
+	return newCode("init")
+}
+
+func newCode(fn string) *Code {
+	return &Code{
+		pkg:   "main",
+		fn:    fn,
+		decls: bytes.NewBuffer(nil),
+		buf:   bytes.NewBuffer(nil),
+	}
 }
 
 type Code struct {
-	buf *bytes.Buffer
+	header  string
+	pkg     string
+	fn      string
+	imports []string
+	decls   *bytes.Buffer
+	buf     *bytes.Buffer
+	// preserveLines tells whether the code is padded so that statements
+	// are on their JavaScript line
+	preserveLines bool
 }
 
-func (c *Code) WriteTo(w io.Writer) error {
+func (c *Code) WriteTo(w io.Writer) (int64, error) {
 	t, err := template.New("main").Parse(tmpl)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return t.Execute(w, strings.TrimSpace(c.buf.String()))
+	result := bytes.NewBuffer(nil)
+	err = t.Execute(result, struct {
+		Header  string
+		Package string
+		Imports string
+		Decls   string
+		Func    string
+		Body    string
+	}{
+		Header:  c.header,
+		Package: c.pkg,
+		Imports: c.importBlock(),
+		Decls:   strings.TrimSpace(c.decls.String()),
+		Func:    c.fn,
+		Body:    strings.TrimSpace(c.buf.String()),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if c.preserveLines {
+		result = bytes.NewBufferString(padLines(result.String()))
+	}
+
+	return result.WriteTo(w)
 }
 
 func (c *Code) String() string {
 	result := bytes.NewBuffer(nil)
-	err := c.WriteTo(result)
+	_, err := c.WriteTo(result)
 	if err != nil {
 		panic(err)
 	}
@@ -47,11 +101,133 @@ func (c *Code) String() string {
 	return result.String()
 }
 
+// SetHeader sets the comments written before the package clause, such as
+// build constraints. Comment blocks are separated by blank lines.
+func (c *Code) SetHeader(header string) {
+	c.header = strings.TrimSpace(header)
+}
+
+// SetPackage sets the name of the package of the code, main by default.
+func (c *Code) SetPackage(name string) {
+	c.pkg = name
+}
+
+// PreserveLineNumbers pads the code with blank lines so that the code of
+// each statement, following its line comment, is on the line of its
+// JavaScript source. Statements whose line is already behind, such as the
+// first ones of a file which follow the package clause and the imports,
+// aren't moved. gofmt collapses the padding.
+func (c *Code) PreserveLineNumbers() {
+	c.preserveLines = true
+}
+
+// Import adds an import spec such as `"fmt"` to the code, once.
+func (c *Code) Import(spec string) {
+	for _, i := range c.imports {
+		if i == spec {
+			return
+		}
+	}
+
+	c.imports = append(c.imports, spec)
+}
+
+// Imports returns the import specs of the code.
+func (c *Code) Imports() []string {
+	return c.imports
+}
+
+// importBlock returns the import specs of the code the way goimports groups
+// them: the standard library packages, then the other ones, each group
+// sorted by path.
+func (c *Code) importBlock() string {
+	var std, others []string
+	for _, spec := range c.imports {
+		if path := importPath(spec); strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+			others = append(others, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+
+	var groups []string
+	for _, g := range [][]string{std, others} {
+		if len(g) == 0 {
+			continue
+		}
+		sort.SliceStable(g, func(i, j int) bool { return importPath(g[i]) < importPath(g[j]) })
+		groups = append(groups, strings.Join(g, "\n\t"))
+	}
+
+	return strings.Join(groups, "\n\n\t")
+}
+
+// importPath returns the package path of an import spec, e.g. "fmt" for
+// `f "fmt"`
+func importPath(spec string) string {
+	fields := strings.Fields(spec)
+	path, err := strconv.Unquote(fields[len(fields)-1])
+	if err != nil {
+		return spec
+	}
+
+	return path
+}
+
+// WriteDecl writes a package level declaration.
+func (c *Code) WriteDecl(s string) {
+	c.decls.WriteString(s)
+	c.decls.WriteString("\n")
+}
+
 func (c *Code) Write(s string) {
 	c.buf.WriteString(s)
 }
 
+// Capture returns what fn writes instead of writing it to the code.
+func (c *Code) Capture(fn func()) string {
+	buf := c.buf
+	c.buf = bytes.NewBuffer(nil)
+	defer func() {
+		c.buf = buf
+	}()
+
+	fn()
+
+	return c.buf.String()
+}
+
 func (c *Code) WriteLine(s string) {
 	c.Write(s)
 	c.Write("\n")
 }
+
+// LineComment returns the comment written ahead of the code of the
+// statement on line of the JavaScript source, which quotes its first line
+// src.
+func LineComment(line int, src string) string {
+	return fmt.Sprintf("// line %d: %s", line, src)
+}
+
+var lineCommentPattern = regexp.MustCompile(`^\s*// line (\d+): `)
+
+// padLines inserts blank lines ahead of the line comments of src so that the
+// lines after them are on the lines the comments tell, when they aren't
+// past them
+func padLines(src string) string {
+	var b strings.Builder
+	n := 0
+	for _, l := range strings.SplitAfter(src, "\n") {
+		if m := lineCommentPattern.FindStringSubmatch(l); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			// the comment is on line n+1 and the code after it on n+2
+			for ; n+2 < line; n++ {
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString(l)
+		n++
+	}
+
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package source
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCode_Imports(t *testing.T) {
+	code := NewCode()
+	for _, spec := range []string{RuntimeImport, `"math"`, `lib "example.com/lib"`, `"fmt"`, `"math"`} {
+		code.Import(spec)
+	}
+
+	want := `import (
+	"fmt"
+	"math"
+
+	lib "example.com/lib"
+	. "github.com/jingweno/godzilla/runtime"
+)`
+	if got := code.String(); !strings.Contains(got, want) {
+		t.Fatalf("code doesn't contain %q:\n%s", want, got)
+	}
+}
+
+func TestCode_SetPackage(t *testing.T) {
+	code := NewInitCode()
+	code.SetPackage("greeter")
+	code.WriteLine("x = 1")
+
+	want := "package greeter\n"
+	if got := code.String(); !strings.HasPrefix(got, want) || !strings.Contains(got, "func init() {") {
+		t.Fatalf("code doesn't start with %q and run in init:\n%s", want, got)
+	}
+}